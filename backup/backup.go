@@ -0,0 +1,286 @@
+package backup
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/satelliondao/satellion/bip329"
+	"github.com/satelliondao/satellion/config"
+	"github.com/satelliondao/satellion/mnemonic"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// FormatVersion is the version of the backup file layout written by this build.
+const FormatVersion uint16 = 1
+
+// Extension is the file extension of backup files.
+const Extension = ".satbak"
+
+var magic = [8]byte{'S', 'A', 'T', 'B', 'A', 'C', 'K', 0}
+
+var (
+	ErrInvalidBackup      = errors.New("not a satellion backup file")
+	ErrUnsupportedVersion = errors.New("unsupported backup format version")
+	ErrWrongPassword      = errors.New("wrong password or corrupted backup")
+)
+
+// kdfParams are the argon2id parameters used to derive the file key from the password.
+type kdfParams struct {
+	Time    uint32
+	Memory  uint32
+	Threads uint8
+}
+
+var defaultKDF = kdfParams{Time: 3, Memory: 64 * 1024, Threads: 4}
+
+// maxKDF bounds the parameters read from a backup, which are not authenticated until
+// the key they derive opens it: a crafted file could otherwise make Read allocate
+// gigabytes or run for hours. It leaves room above defaultKDF for future builds.
+var maxKDF = kdfParams{Time: 16, Memory: 256 * 1024, Threads: 16}
+
+// header precedes the ciphertext and is authenticated as additional data.
+type header struct {
+	Magic   [8]byte
+	Version uint16
+	KDF     kdfParams
+	Salt    [16]byte
+	Nonce   [chacha20poly1305.NonceSizeX]byte
+}
+
+// File is the decrypted content of a backup.
+type File struct {
+	CreatedAt    time.Time      `json:"created_at"`
+	Network      string         `json:"network"`
+	ActiveWallet string         `json:"active_wallet,omitempty"`
+	Wallets      []Wallet       `json:"wallets"`
+	Settings     *config.Config `json:"settings,omitempty"`
+}
+
+// Wallet is the backup of a single wallet. Mnemonic and Passphrase are empty when seeds
+// were not included. Wallets stored before seeds were encrypted have a Lock instead of a
+// Fingerprint, and are unlocked with their BIP39 passphrase. Watch-only wallets only have
+// the AccountXpub of their external signer. AccountID tells wallets apart on restore
+// when their seed is not included.
+type Wallet struct {
+	Name             string         `json:"name"`
+	Lock             string         `json:"lock,omitempty"`
+	Fingerprint      string         `json:"fingerprint,omitempty"`
+	Mnemonic         []string       `json:"mnemonic,omitempty"`
+	Passphrase       string         `json:"passphrase,omitempty"`
+	AccountXpub      string         `json:"account_xpub,omitempty"`
+	AccountID        string         `json:"account_id,omitempty"`
+	NextReceiveIndex uint32         `json:"next_receive_index"`
+	NextChangeIndex  uint32         `json:"next_change_index"`
+	CreatedAt        time.Time      `json:"created_at"`
+	Accounts         []string       `json:"accounts"`
+	Labels           []bip329.Label `json:"labels,omitempty"`
+}
+
+// HasSeeds reports whether any wallet in the backup carries its mnemonic.
+func (f *File) HasSeeds() bool {
+	for _, w := range f.Wallets {
+		if len(w.Mnemonic) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// WithoutSeeds lists the wallets whose mnemonic is not in the backup. Watch-only wallets
+// have none.
+func (f *File) WithoutSeeds() []string {
+	var names []string
+	for _, w := range f.Wallets {
+		if len(w.Mnemonic) == 0 && w.AccountXpub == "" {
+			names = append(names, w.Name)
+		}
+	}
+	return names
+}
+
+// RestoredSettings returns the settings of f to save over local, and the names of the
+// local settings kept although the backup has other values. The HWI command and the proxy
+// settings are always kept: the app runs the HWI command to sign and routes peer traffic
+// through the proxy, so a foreign or tampered backup must not be able to redirect either.
+func (f *File) RestoredSettings(local *config.Config) (*config.Config, []string) {
+	restored := *f.Settings
+	var kept []string
+	for _, s := range []struct {
+		name    string
+		differs bool
+	}{
+		{"hwi_path", restored.HWIPath != local.HWIPath},
+		{"proxy", restored.Proxy != local.Proxy},
+		{"proxy_stream_isolation", restored.ProxyStreamIsolation != local.ProxyStreamIsolation},
+		{"proxy_only", restored.ProxyOnly != local.ProxyOnly},
+	} {
+		if s.differs {
+			kept = append(kept, s.name)
+		}
+	}
+	restored.HWIPath = local.HWIPath
+	restored.Proxy = local.Proxy
+	restored.ProxyStreamIsolation = local.ProxyStreamIsolation
+	restored.ProxyOnly = local.ProxyOnly
+	return &restored, kept
+}
+
+// Write encrypts f with password and writes it to out.
+func Write(out io.Writer, f *File, password string) error {
+	if password == "" {
+		return fmt.Errorf("backup password cannot be empty")
+	}
+	plaintext, err := json.Marshal(f)
+	if err != nil {
+		return err
+	}
+	h := header{Magic: magic, Version: FormatVersion, KDF: defaultKDF}
+	if _, err := rand.Read(h.Salt[:]); err != nil {
+		return err
+	}
+	if _, err := rand.Read(h.Nonce[:]); err != nil {
+		return err
+	}
+	var hb bytes.Buffer
+	if err := binary.Write(&hb, binary.BigEndian, &h); err != nil {
+		return err
+	}
+	aead, err := chacha20poly1305.NewX(deriveKey(password, h))
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(hb.Bytes()); err != nil {
+		return err
+	}
+	_, err = out.Write(aead.Seal(nil, h.Nonce[:], plaintext, hb.Bytes()))
+	return err
+}
+
+// Read decrypts a backup with password and validates its content.
+func Read(in io.Reader, password string) (*File, error) {
+	raw, err := io.ReadAll(in)
+	if err != nil {
+		return nil, err
+	}
+	var h header
+	size := binary.Size(h)
+	if len(raw) < size || !bytes.Equal(raw[:len(magic)], magic[:]) {
+		return nil, ErrInvalidBackup
+	}
+	if err := binary.Read(bytes.NewReader(raw[:size]), binary.BigEndian, &h); err != nil {
+		return nil, ErrInvalidBackup
+	}
+	if h.Version != FormatVersion {
+		return nil, fmt.Errorf("%w: %d", ErrUnsupportedVersion, h.Version)
+	}
+	if !h.KDF.valid() {
+		return nil, ErrInvalidBackup
+	}
+	aead, err := chacha20poly1305.NewX(deriveKey(password, h))
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, h.Nonce[:], raw[size:], raw[:size])
+	if err != nil {
+		return nil, ErrWrongPassword
+	}
+	var f File
+	if err := json.Unmarshal(plaintext, &f); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	if err := f.validate(); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	return &f, nil
+}
+
+// WriteFile encrypts f into a new file at path. Existing files are never overwritten.
+func WriteFile(path string, f *File, password string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	out, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := Write(out, f, password); err != nil {
+		out.Close()
+		os.Remove(path)
+		return err
+	}
+	return out.Close()
+}
+
+// ReadFile decrypts the backup at path.
+func ReadFile(path string, password string) (*File, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer in.Close()
+	return Read(in, password)
+}
+
+// DefaultPath returns a new timestamped backup path under ~/.satellion/backups.
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	name := "satellion-" + time.Now().Format("20060102-150405") + Extension
+	return filepath.Join(home, ".satellion", "backups", name)
+}
+
+func (p kdfParams) valid() bool {
+	return p.Time > 0 && p.Time <= maxKDF.Time &&
+		p.Memory > 0 && p.Memory <= maxKDF.Memory &&
+		p.Threads > 0 && p.Threads <= maxKDF.Threads
+}
+
+func deriveKey(password string, h header) []byte {
+	return argon2.IDKey([]byte(password), h.Salt[:], h.KDF.Time, h.KDF.Memory, h.KDF.Threads, chacha20poly1305.KeySize)
+}
+
+func (f *File) validate() error {
+	if f.Network == "" {
+		return fmt.Errorf("missing network")
+	}
+	seen := make(map[string]bool, len(f.Wallets))
+	validator := mnemonic.NewValidator()
+	for _, w := range f.Wallets {
+		if w.Name == "" {
+			return fmt.Errorf("wallet without a name")
+		}
+		if seen[w.Name] {
+			return fmt.Errorf("duplicate wallet %q", w.Name)
+		}
+		seen[w.Name] = true
+		if w.Lock == "" && w.Fingerprint == "" {
+			return fmt.Errorf("wallet %q has neither a fingerprint nor a lock", w.Name)
+		}
+		if len(w.Mnemonic) > 0 {
+			if err := validator.Validate(strings.Join(w.Mnemonic, " ")); err != nil {
+				return fmt.Errorf("wallet %q has an invalid mnemonic: %v", w.Name, err)
+			}
+		}
+		for _, l := range w.Labels {
+			if err := l.Validate(); err != nil {
+				return fmt.Errorf("wallet %q: %v", w.Name, err)
+			}
+		}
+	}
+	if f.ActiveWallet != "" && !seen[f.ActiveWallet] {
+		return fmt.Errorf("active wallet %q is not in the backup", f.ActiveWallet)
+	}
+	return nil
+}
@@ -0,0 +1,314 @@
+package backup
+
+import (
+	"bytes"
+	"encoding/binary"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/satelliondao/satellion/bip329"
+	"github.com/satelliondao/satellion/config"
+	"github.com/satelliondao/satellion/mnemonic"
+	"github.com/satelliondao/satellion/wallet"
+	"github.com/satelliondao/satellion/walletdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testWords = []string{
+	"abandon", "abandon", "abandon", "abandon", "abandon", "abandon",
+	"abandon", "abandon", "abandon", "abandon", "abandon", "about",
+}
+
+func init() {
+	// Keeps the tests fast; real backups use defaultKDF as declared.
+	defaultKDF = kdfParams{Time: 1, Memory: 1024, Threads: 1}
+	wallet.LockParams = wallet.KDFParams{Time: 1, MemoryKiB: 1024, Threads: 1}
+}
+
+func setupRepo(t *testing.T) *walletdb.WalletDB {
+	db, err := walletdb.Open(filepath.Join(t.TempDir(), "wallets.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return walletdb.New(db, &chaincfg.MainNetParams)
+}
+
+const testPassword = "password"
+
+func addWallet(t *testing.T, repo *walletdb.WalletDB, name string, words []string, receive uint32) *wallet.Wallet {
+	m := mnemonic.New(words)
+	w := wallet.New(&m, "", "", &chaincfg.MainNetParams)
+	w.Name = name
+	w.NextReceiveIndex = receive
+	w.CreatedAt = time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.Seal(w, "", []byte(testPassword)))
+	return w
+}
+
+func encode(t *testing.T, f *File, password string) []byte {
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, f, password))
+	return buf.Bytes()
+}
+
+func TestWriteRead_RoundTrip(t *testing.T) {
+	repo := setupRepo(t)
+	addWallet(t, repo, "main", testWords, 7)
+	require.NoError(t, repo.SetDefault("main"))
+	cfg := &config.Config{Network: "regtest", MinPeers: 1}
+
+	f, err := Export(repo, cfg, true, []byte(testPassword))
+	require.NoError(t, err)
+	got, err := Read(bytes.NewReader(encode(t, f, "secret")), "secret")
+	require.NoError(t, err)
+
+	assert.Equal(t, "regtest", got.Network)
+	assert.Equal(t, "main", got.ActiveWallet)
+	assert.Equal(t, cfg, got.Settings)
+	require.Len(t, got.Wallets, 1)
+	assert.Equal(t, testWords, got.Wallets[0].Mnemonic)
+	assert.Equal(t, "73c5da0a", got.Wallets[0].Fingerprint)
+	assert.Equal(t, uint32(7), got.Wallets[0].NextReceiveIndex)
+	assert.Equal(t, []string{wallet.AccountPath}, got.Wallets[0].Accounts)
+}
+
+func TestRestoredSettings_KeepsSignerAndProxy(t *testing.T) {
+	local := &config.Config{MinPeers: 5, HWIPath: "/usr/bin/hwi", Proxy: "127.0.0.1:9050", ProxyOnly: true}
+	f := &File{Settings: &config.Config{MinPeers: 2, HWIPath: "/tmp/evil", Proxy: "203.0.113.7:1080", ProxyStreamIsolation: true}}
+
+	restored, kept := f.RestoredSettings(local)
+	assert.Equal(t, 2, restored.MinPeers, "other settings come from the backup")
+	assert.Equal(t, "/usr/bin/hwi", restored.HWIPath)
+	assert.Equal(t, "127.0.0.1:9050", restored.Proxy)
+	assert.False(t, restored.ProxyStreamIsolation)
+	assert.True(t, restored.ProxyOnly)
+	assert.Equal(t, []string{"hwi_path", "proxy", "proxy_stream_isolation", "proxy_only"}, kept)
+	assert.Equal(t, "/tmp/evil", f.Settings.HWIPath, "the backup is not modified")
+
+	_, kept = (&File{Settings: local}).RestoredSettings(local)
+	assert.Empty(t, kept)
+}
+
+func TestExport_WithoutSeeds(t *testing.T) {
+	repo := setupRepo(t)
+	addWallet(t, repo, "main", testWords, 0)
+	f, err := Export(repo, &config.Config{}, false, []byte(testPassword))
+	require.NoError(t, err)
+	assert.False(t, f.HasSeeds())
+	withWrongPassword, err := Export(repo, &config.Config{}, true, []byte("wrong"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main"}, withWrongPassword.WithoutSeeds())
+	assert.Equal(t, "mainnet", f.Network)
+}
+
+func TestRead_RejectsBadInput(t *testing.T) {
+	f := &File{Network: "mainnet"}
+	raw := encode(t, f, "secret")
+
+	_, err := Read(bytes.NewReader(raw), "wrong")
+	assert.ErrorIs(t, err, ErrWrongPassword)
+
+	tampered := append([]byte(nil), raw...)
+	tampered[len(tampered)-1] ^= 1
+	_, err = Read(bytes.NewReader(tampered), "secret")
+	assert.ErrorIs(t, err, ErrWrongPassword)
+
+	_, err = Read(bytes.NewReader([]byte("{\"wallets\":[]}")), "secret")
+	assert.ErrorIs(t, err, ErrInvalidBackup)
+
+	future := append([]byte(nil), raw...)
+	binary.BigEndian.PutUint16(future[len(magic):], FormatVersion+1)
+	_, err = Read(bytes.NewReader(future), "secret")
+	assert.ErrorIs(t, err, ErrUnsupportedVersion)
+
+	assert.Error(t, Write(&bytes.Buffer{}, f, ""))
+}
+
+func TestRead_CapsKDF(t *testing.T) {
+	raw := encode(t, &File{Network: "mainnet"}, "secret")
+	kdf := len(magic) + 2
+	for name, patch := range map[string]func([]byte){
+		"time":    func(b []byte) { binary.BigEndian.PutUint32(b[kdf:], maxKDF.Time+1) },
+		"memory":  func(b []byte) { binary.BigEndian.PutUint32(b[kdf+4:], 0xffffffff) },
+		"threads": func(b []byte) { b[kdf+8] = maxKDF.Threads + 1 },
+	} {
+		crafted := append([]byte(nil), raw...)
+		patch(crafted)
+		_, err := Read(bytes.NewReader(crafted), "secret")
+		assert.ErrorIs(t, err, ErrInvalidBackup, name)
+	}
+}
+
+func TestRead_ValidatesContent(t *testing.T) {
+	invalid := &File{Network: "mainnet", Wallets: []Wallet{
+		{Name: "a", Lock: "x", Mnemonic: []string{"not", "a", "mnemonic"}},
+	}}
+	_, err := Read(bytes.NewReader(encode(t, invalid, "secret")), "secret")
+	assert.ErrorIs(t, err, ErrInvalidBackup)
+
+	duplicate := &File{Network: "mainnet", Wallets: []Wallet{{Name: "a", Lock: "x"}, {Name: "a", Lock: "y"}}}
+	_, err = Read(bytes.NewReader(encode(t, duplicate, "secret")), "secret")
+	assert.ErrorIs(t, err, ErrInvalidBackup)
+}
+
+func TestWriteFile_DoesNotOverwrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallets"+Extension)
+	f := &File{Network: "mainnet"}
+	require.NoError(t, WriteFile(path, f, "secret"))
+	assert.Error(t, WriteFile(path, f, "secret"))
+	got, err := ReadFile(path, "secret")
+	require.NoError(t, err)
+	assert.Equal(t, "mainnet", got.Network)
+}
+
+func TestRestore_MergesWithoutClobbering(t *testing.T) {
+	source := setupRepo(t)
+	other := mnemonic.NewRandom().Words
+	third := mnemonic.NewRandom().Words
+	addWallet(t, source, "main", testWords, 9)
+	addWallet(t, source, "savings", other, 3)
+	addWallet(t, source, "cold", third, 0)
+	require.NoError(t, source.SetDefault("savings"))
+	f, err := Export(source, &config.Config{}, true, []byte(testPassword))
+	require.NoError(t, err)
+	// "cold" is present without its seed, as in a backup taken without seeds.
+	for i := range f.Wallets {
+		if f.Wallets[i].Name == "cold" {
+			f.Wallets[i].Mnemonic = nil
+		}
+	}
+
+	target := setupRepo(t)
+	addWallet(t, target, "main", testWords, 2)
+	// A different wallet already uses the name "savings".
+	addWallet(t, target, "savings", mnemonic.NewRandom().Words, 1)
+
+	report, err := Restore(target, f, &config.Config{}, []byte("new-password"))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main"}, report.Merged)
+	assert.Equal(t, []string{"savings-restored"}, report.Added)
+	assert.Equal(t, map[string]string{"savings": "savings-restored"}, report.Renamed)
+	assert.Contains(t, report.Skipped, "cold")
+
+	main, err := target.Get("main", []byte(testPassword))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(9), main.NextReceiveIndex)
+	untouched, err := target.Get("savings", []byte(testPassword))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(1), untouched.NextReceiveIndex)
+	restored, err := target.Get("savings-restored", []byte("new-password"))
+	require.NoError(t, err)
+	assert.Equal(t, other, restored.Mnemonic.Words)
+	active, err := target.GetActiveWalletName()
+	require.NoError(t, err)
+	assert.Equal(t, "savings-restored", active)
+
+	again, err := Restore(target, f, &config.Config{}, []byte("new-password"))
+	require.NoError(t, err)
+	assert.Empty(t, again.Added)
+	assert.Empty(t, again.Merged)
+}
+
+func TestRestore_LegacyWallets(t *testing.T) {
+	source := setupRepo(t)
+	m := mnemonic.New(testWords)
+	lock, err := wallet.NewLock(m.Seed("passphrase"), wallet.LockParams)
+	require.NoError(t, err)
+	legacy := wallet.New(&m, "passphrase", lock, &chaincfg.MainNetParams)
+	legacy.Name = "legacy"
+	legacy.NextReceiveIndex = 4
+	require.NoError(t, source.Save(legacy))
+	f, err := Export(source, &config.Config{}, true, nil)
+	require.NoError(t, err)
+	require.Empty(t, f.Wallets[0].Fingerprint, "the fingerprint of a legacy wallet depends on its unknown passphrase")
+
+	target := setupRepo(t)
+	report, err := Restore(target, f, &config.Config{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"legacy"}, report.Added)
+	restored, err := target.Get("legacy", []byte("passphrase"))
+	require.NoError(t, err)
+	assert.False(t, restored.Sealed)
+	ok, err := wallet.VerifyLock(restored.Lock, restored.Mnemonic.Seed("passphrase"))
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	// Matched by mnemonic on the next restore.
+	again, err := Restore(target, f, &config.Config{}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, again.Added)
+}
+
+func TestRestore_RejectsOtherNetwork(t *testing.T) {
+	_, err := Restore(setupRepo(t), &File{Network: "testnet3"}, &config.Config{Network: "regtest"}, []byte(testPassword))
+	assert.Error(t, err)
+}
+
+func TestRestore_WatchOnlyWallets(t *testing.T) {
+	source := setupRepo(t)
+	w, err := wallet.NewWatchOnly("xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ", "73c5da0a", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	w.Name = "trezor"
+	require.NoError(t, source.Save(w))
+	f, err := Export(source, &config.Config{}, true, []byte(testPassword))
+	require.NoError(t, err)
+	assert.Empty(t, f.WithoutSeeds(), "watch-only wallets have no seed to miss")
+
+	target := setupRepo(t)
+	report, err := Restore(target, f, &config.Config{}, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"trezor"}, report.Added)
+	restored, err := target.Get("trezor", nil)
+	require.NoError(t, err)
+	assert.True(t, restored.WatchOnly())
+}
+
+func TestRestore_Labels(t *testing.T) {
+	source := setupRepo(t)
+	addWallet(t, source, "main", testWords, 0)
+	frozen := false
+	require.NoError(t, source.SetLabels("main",
+		bip329.Label{Type: bip329.Addr, Ref: "bc1qrent", Label: "rent"},
+		bip329.Label{Type: bip329.Output, Ref: "ab:0", Label: "coffee", Spendable: &frozen},
+	))
+	f, err := Export(source, &config.Config{}, false, nil)
+	require.NoError(t, err)
+	got, err := Read(bytes.NewReader(encode(t, f, "secret")), "secret")
+	require.NoError(t, err)
+	require.Len(t, got.Wallets[0].Labels, 2)
+
+	target := setupRepo(t)
+	addWallet(t, target, "main", testWords, 0)
+	require.NoError(t, target.SetLabels("main", bip329.Label{Type: bip329.Addr, Ref: "bc1qrent", Label: "local"}))
+	_, err = Restore(target, got, &config.Config{}, nil)
+	require.NoError(t, err)
+	labels, err := target.Labels("main")
+	require.NoError(t, err)
+	require.Len(t, labels, 2)
+	assert.Equal(t, "local", labels[0].Label, "existing labels are kept")
+	assert.Equal(t, "coffee", labels[1].Label)
+	assert.False(t, *labels[1].Spendable)
+}
+
+func TestRestore_FingerprintCollision(t *testing.T) {
+	target := setupRepo(t)
+	local := addWallet(t, target, "main", testWords, 2)
+	f := &File{Network: "mainnet", Wallets: []Wallet{
+		{Name: "other", Fingerprint: local.Fingerprint, AccountID: "another account", NextReceiveIndex: 50},
+	}}
+
+	report, err := Restore(target, f, &config.Config{}, []byte(testPassword))
+	require.NoError(t, err)
+	assert.Empty(t, report.Merged, "a fingerprint alone does not identify a wallet")
+	assert.Contains(t, report.Skipped, "other")
+	main, err := target.Get("main", []byte(testPassword))
+	require.NoError(t, err)
+	assert.Equal(t, uint32(2), main.NextReceiveIndex)
+
+	f.Wallets[0].AccountID = local.AccountID
+	report, err = Restore(target, f, &config.Config{}, []byte(testPassword))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"main"}, report.Merged)
+}
@@ -0,0 +1,333 @@
+package backup
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/satelliondao/satellion/bip329"
+	"github.com/satelliondao/satellion/config"
+	"github.com/satelliondao/satellion/mnemonic"
+	"github.com/satelliondao/satellion/wallet"
+	"github.com/satelliondao/satellion/walletdb"
+)
+
+// Export collects every wallet in repo together with the settings in cfg. When
+// includeSeeds is set, mnemonics are included for the wallets that password unlocks.
+func Export(repo *walletdb.WalletDB, cfg *config.Config, includeSeeds bool, password []byte) (*File, error) {
+	params, err := cfg.ChainParams()
+	if err != nil {
+		return nil, err
+	}
+	wallets, err := repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	active, err := repo.GetActiveWalletName()
+	if err != nil && !errors.Is(err, walletdb.ErrWalletNotFound) {
+		return nil, err
+	}
+	f := &File{
+		CreatedAt:    time.Now(),
+		Network:      params.Name,
+		ActiveWallet: active,
+		Settings:     cfg,
+	}
+	for _, w := range wallets {
+		entry := Wallet{
+			Name:             w.Name,
+			NextReceiveIndex: w.NextReceiveIndex,
+			NextChangeIndex:  w.NextChangeIndex,
+			CreatedAt:        w.CreatedAt,
+			Accounts:         []string{wallet.AccountPath},
+		}
+		if entry.Labels, err = repo.Labels(w.Name); err != nil {
+			return nil, err
+		}
+		if w.WatchOnly() {
+			entry.Fingerprint = w.Fingerprint
+			entry.AccountXpub = w.AccountKey.String()
+			entry.AccountID = w.AccountID
+			f.Wallets = append(f.Wallets, entry)
+			continue
+		}
+		if !w.Sealed {
+			entry.Lock = w.Lock
+			if includeSeeds {
+				entry.Mnemonic = w.Mnemonic.Words
+			}
+			f.Wallets = append(f.Wallets, entry)
+			continue
+		}
+		entry.Fingerprint = w.Fingerprint
+		entry.AccountID = w.AccountID
+		if includeSeeds {
+			unlocked, passphrase, err := repo.Unseal(w.Name, password)
+			switch {
+			case errors.Is(err, walletdb.ErrInvalidPassword):
+			case err != nil:
+				return nil, err
+			default:
+				entry.Mnemonic = unlocked.Mnemonic.Words
+				entry.Passphrase = passphrase
+			}
+		}
+		f.Wallets = append(f.Wallets, entry)
+	}
+	if f.ActiveWallet != "" && !f.hasWallet(f.ActiveWallet) {
+		f.ActiveWallet = ""
+	}
+	return f, nil
+}
+
+// Report describes what Restore did with every wallet of a backup.
+type Report struct {
+	// Added are wallets created from the backup, by their local name.
+	Added []string
+	// Merged are existing wallets whose address indexes were updated from the backup.
+	Merged []string
+	// Skipped are wallets that could not be restored, with the reason.
+	Skipped map[string]string
+	// Renamed maps backup names to the local names used to avoid clobbering another wallet.
+	Renamed map[string]string
+}
+
+// Restore merges f into repo without overwriting existing wallets. A wallet already
+// present (same account key, lock or mnemonic) only has its address indexes raised. A new
+// wallet is added when the backup carries its mnemonic, under a fresh name if its own is
+// taken, and its seed is encrypted with password. Wallets are never matched by their
+// fingerprint alone, which is only four bytes: a local wallet stored without its account
+// ID is unlocked with password to compute it.
+func Restore(repo *walletdb.WalletDB, f *File, cfg *config.Config, password []byte) (*Report, error) {
+	params, err := cfg.ChainParams()
+	if err != nil {
+		return nil, err
+	}
+	if f.Network != params.Name {
+		return nil, fmt.Errorf("backup is for %s but the wallet is configured for %s", f.Network, params.Name)
+	}
+	local, err := repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	known := make(map[string]*wallet.Wallet, 2*len(local))
+	taken := make(map[string]bool, len(local))
+	// unverified are the local wallets, by fingerprint, whose account ID is unknown.
+	unverified := make(map[string]string)
+	for i := range local {
+		w := &local[i]
+		taken[w.Name] = true
+		if w.Sealed && w.AccountID == "" {
+			if err := identify(repo, w, password); err != nil {
+				unverified[w.Fingerprint] = w.Name
+				continue
+			}
+		}
+		for _, key := range identities(w) {
+			known[key] = w
+		}
+	}
+	report := &Report{Skipped: map[string]string{}, Renamed: map[string]string{}}
+	names := make(map[string]string, len(f.Wallets))
+	for _, entry := range f.Wallets {
+		var existing *wallet.Wallet
+		keys, err := entry.identities(params)
+		if err != nil {
+			return report, fmt.Errorf("wallet %s: %w", entry.Name, err)
+		}
+		for _, key := range keys {
+			if existing = known[key]; existing != nil {
+				break
+			}
+		}
+		if existing != nil {
+			if entry.NextReceiveIndex > existing.NextReceiveIndex || entry.NextChangeIndex > existing.NextChangeIndex {
+				existing.NextReceiveIndex = max(existing.NextReceiveIndex, entry.NextReceiveIndex)
+				existing.NextChangeIndex = max(existing.NextChangeIndex, entry.NextChangeIndex)
+				if err := repo.Save(existing); err != nil {
+					return report, err
+				}
+				report.Merged = append(report.Merged, existing.Name)
+			}
+			if err := mergeLabels(repo, existing.Name, entry.Labels); err != nil {
+				return report, err
+			}
+			names[entry.Name] = existing.Name
+			continue
+		}
+		if name, ok := unverified[entry.Fingerprint]; ok && entry.Fingerprint != "" {
+			report.Skipped[entry.Name] = fmt.Sprintf("has the fingerprint of %s, which the password does not unlock to compare them", name)
+			continue
+		}
+		if len(entry.Mnemonic) == 0 && entry.AccountXpub == "" {
+			report.Skipped[entry.Name] = "backup does not include the seed"
+			continue
+		}
+		w, err := restoreWallet(repo, entry, freeName(entry.Name, taken), password)
+		if err != nil {
+			return report, fmt.Errorf("wallet %s: %w", entry.Name, err)
+		}
+		if err := mergeLabels(repo, w.Name, entry.Labels); err != nil {
+			return report, err
+		}
+		taken[w.Name] = true
+		for _, key := range identities(w) {
+			known[key] = w
+		}
+		names[entry.Name] = w.Name
+		report.Added = append(report.Added, w.Name)
+		if w.Name != entry.Name {
+			report.Renamed[entry.Name] = w.Name
+		}
+	}
+	if _, err := repo.GetActiveWalletName(); errors.Is(err, walletdb.ErrWalletNotFound) {
+		if name, ok := names[f.ActiveWallet]; ok {
+			if err := repo.SetDefault(name); err != nil {
+				return report, err
+			}
+		}
+	}
+	return report, nil
+}
+
+// restoreWallet stores entry as name. Wallets backed up before seeds were encrypted stay
+// unlocked by their passphrase until they are given a password. Watch-only wallets need
+// no password.
+func restoreWallet(repo *walletdb.WalletDB, entry Wallet, name string, password []byte) (*wallet.Wallet, error) {
+	if entry.AccountXpub != "" {
+		w, err := wallet.NewWatchOnly(entry.AccountXpub, entry.Fingerprint, repo.Params())
+		if err != nil {
+			return nil, err
+		}
+		w.Name = name
+		w.NextReceiveIndex = entry.NextReceiveIndex
+		w.NextChangeIndex = entry.NextChangeIndex
+		w.CreatedAt = entry.CreatedAt
+		return w, repo.Save(w)
+	}
+	m := mnemonic.New(entry.Mnemonic)
+	if entry.Fingerprint == "" {
+		lock := entry.Lock
+		if wallet.IsLegacyLock(lock) {
+			var err error
+			if lock, err = wallet.UpgradeLegacyLock(lock, wallet.LockParams); err != nil {
+				return nil, err
+			}
+		}
+		w := wallet.New(&m, "", lock, repo.Params())
+		w.Name = name
+		w.NextReceiveIndex = entry.NextReceiveIndex
+		w.NextChangeIndex = entry.NextChangeIndex
+		w.CreatedAt = entry.CreatedAt
+		return w, repo.Save(w)
+	}
+	if len(password) == 0 {
+		return nil, fmt.Errorf("a password is required to store the seed")
+	}
+	w := wallet.New(&m, entry.Passphrase, "", repo.Params())
+	if w.Fingerprint != entry.Fingerprint {
+		return nil, fmt.Errorf("seed does not match fingerprint %s", entry.Fingerprint)
+	}
+	w.Name = name
+	w.NextReceiveIndex = entry.NextReceiveIndex
+	w.NextChangeIndex = entry.NextChangeIndex
+	w.CreatedAt = entry.CreatedAt
+	return w, repo.Seal(w, entry.Passphrase, password)
+}
+
+// mergeLabels adds the labels of a backup to wallet name, keeping the labels it already
+// has.
+func mergeLabels(repo *walletdb.WalletDB, name string, labels []bip329.Label) error {
+	if len(labels) == 0 {
+		return nil
+	}
+	local, err := repo.Labels(name)
+	if err != nil {
+		return err
+	}
+	have := make(map[string]bool, len(local))
+	for _, l := range local {
+		have[l.Key()] = true
+	}
+	var missing []bip329.Label
+	for _, l := range labels {
+		if !have[l.Key()] {
+			missing = append(missing, l)
+		}
+	}
+	return repo.SetLabels(name, missing...)
+}
+
+// identify fills in the account ID of a sealed wallet stored without one by unlocking
+// it with password, and stores it for the next restore.
+func identify(repo *walletdb.WalletDB, w *wallet.Wallet, password []byte) error {
+	unlocked, err := repo.Get(w.Name, password)
+	if err != nil {
+		return err
+	}
+	w.AccountID = unlocked.AccountID
+	return repo.Save(w)
+}
+
+// identities are the keys a local wallet is recognised by. The account ID of a wallet
+// stored before seeds were encrypted is unknown, as it depends on its passphrase.
+func identities(w *wallet.Wallet) []string {
+	if w.Sealed || w.WatchOnly() {
+		return []string{"id:" + w.AccountID}
+	}
+	keys := []string{"seed:" + strings.Join(w.Mnemonic.Words, " ")}
+	if w.Lock != "" {
+		keys = append(keys, "lock:"+w.Lock)
+	}
+	return keys
+}
+
+// identities are the keys a backed up wallet is recognised by. The account ID is taken
+// from the backup, or derived for params from the xpub or seed of backups written
+// without it.
+func (w Wallet) identities(params *chaincfg.Params) ([]string, error) {
+	var keys []string
+	switch {
+	case w.AccountID != "":
+		keys = append(keys, "id:"+w.AccountID)
+	case w.AccountXpub != "":
+		watched, err := wallet.NewWatchOnly(w.AccountXpub, w.Fingerprint, params)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, "id:"+watched.AccountID)
+	case w.Fingerprint != "" && len(w.Mnemonic) > 0:
+		m := mnemonic.New(w.Mnemonic)
+		keys = append(keys, "id:"+wallet.New(&m, w.Passphrase, "", params).AccountID)
+	}
+	if w.Lock != "" {
+		keys = append(keys, "lock:"+w.Lock)
+	}
+	if w.Fingerprint == "" && len(w.Mnemonic) > 0 {
+		keys = append(keys, "seed:"+strings.Join(w.Mnemonic, " "))
+	}
+	return keys, nil
+}
+
+// freeName returns name, or name-restored[-N] when a local wallet already uses it.
+func freeName(name string, taken map[string]bool) string {
+	if !taken[name] {
+		return name
+	}
+	candidate := name + "-restored"
+	for i := 2; taken[candidate]; i++ {
+		candidate = fmt.Sprintf("%s-restored-%d", name, i)
+	}
+	return candidate
+}
+
+func (f *File) hasWallet(name string) bool {
+	for _, w := range f.Wallets {
+		if w.Name == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,184 @@
+// Package bip21 parses and builds bitcoin: payment URIs as specified by BIP21
+// (https://github.com/bitcoin/bips/blob/master/bip-0021.mediawiki).
+package bip21
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// Scheme is the URI scheme of payment requests, matched case-insensitively.
+const Scheme = "bitcoin"
+
+// ErrInvalidURI is returned for text that is not a well formed payment URI.
+var ErrInvalidURI = errors.New("invalid bitcoin URI")
+
+// ErrUnsupportedRequirement is returned for URIs with a req- parameter this wallet does
+// not understand. BIP21 requires such URIs to be rejected.
+var ErrUnsupportedRequirement = errors.New("bitcoin URI has an unsupported required parameter")
+
+// ErrLightningOnly is returned for URIs that carry a lightning invoice but no on-chain
+// address to fall back to.
+var ErrLightningOnly = errors.New("bitcoin URI only has a lightning invoice, which this wallet cannot pay")
+
+// URI is a payment request.
+type URI struct {
+	// Address is the on-chain address to pay, in its canonical encoding.
+	Address string
+	// Amount is the requested amount, 0 when the payer chooses it.
+	Amount  btcutil.Amount
+	Label   string
+	Message string
+	// Lightning is a BOLT11 invoice offered as an alternative to the on-chain address.
+	// This wallet only pays on-chain and keeps it for display.
+	Lightning string
+	// Params are the other optional parameters, which the wallet may ignore.
+	Params map[string]string
+}
+
+// IsURI reports whether s looks like a payment URI rather than a bare address.
+func IsURI(s string) bool {
+	s = strings.TrimSpace(s)
+	return len(s) > len(Scheme) && strings.EqualFold(s[:len(Scheme)+1], Scheme+":")
+}
+
+// Parse parses a payment URI for the network of params. Addresses are accepted in upper
+// case, as QR codes encode them, and returned in their canonical form.
+func Parse(s string, params *chaincfg.Params) (*URI, error) {
+	s = strings.TrimSpace(s)
+	if !IsURI(s) {
+		return nil, fmt.Errorf("%w: missing %s: scheme", ErrInvalidURI, Scheme)
+	}
+	rest := s[len(Scheme)+1:]
+	// Some wallets write bitcoin://address.
+	rest = strings.TrimPrefix(rest, "//")
+	addr, query, _ := strings.Cut(rest, "?")
+	u := &URI{Params: map[string]string{}}
+	seen := map[string]bool{}
+	for _, param := range strings.Split(query, "&") {
+		if param == "" {
+			continue
+		}
+		key, raw, _ := strings.Cut(param, "=")
+		key = strings.ToLower(key)
+		if key == "" {
+			return nil, fmt.Errorf("%w: parameter without a name", ErrInvalidURI)
+		}
+		if seen[key] {
+			return nil, fmt.Errorf("%w: parameter %s given twice", ErrInvalidURI, key)
+		}
+		seen[key] = true
+		value, err := url.PathUnescape(raw)
+		if err != nil {
+			return nil, fmt.Errorf("%w: parameter %s: %v", ErrInvalidURI, key, err)
+		}
+		switch {
+		case key == "amount":
+			if u.Amount, err = ParseAmount(value); err != nil {
+				return nil, fmt.Errorf("%w: %v", ErrInvalidURI, err)
+			}
+		case key == "label":
+			u.Label = value
+		case key == "message":
+			u.Message = value
+		case key == "lightning":
+			u.Lightning = value
+		case strings.HasPrefix(key, "req-"):
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedRequirement, key)
+		default:
+			u.Params[key] = value
+		}
+	}
+	if addr == "" {
+		if u.Lightning != "" {
+			return nil, ErrLightningOnly
+		}
+		return nil, fmt.Errorf("%w: missing address", ErrInvalidURI)
+	}
+	decoded, err := btcutil.DecodeAddress(addr, params)
+	if err != nil || !decoded.IsForNet(params) {
+		return nil, fmt.Errorf("%w: %q is not a %s address", ErrInvalidURI, addr, params.Name)
+	}
+	u.Address = decoded.EncodeAddress()
+	return u, nil
+}
+
+// String formats u as a URI, with the parameters that are set percent-encoded.
+func (u *URI) String() string {
+	var params []string
+	add := func(key, value string) {
+		if value != "" {
+			params = append(params, key+"="+escape(value))
+		}
+	}
+	if u.Amount > 0 {
+		params = append(params, "amount="+FormatAmount(u.Amount))
+	}
+	add("label", u.Label)
+	add("message", u.Message)
+	add("lightning", u.Lightning)
+	keys := make([]string, 0, len(u.Params))
+	for k := range u.Params {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		add(k, u.Params[k])
+	}
+	if len(params) == 0 {
+		return Scheme + ":" + u.Address
+	}
+	return Scheme + ":" + u.Address + "?" + strings.Join(params, "&")
+}
+
+// escape percent-encodes everything but the RFC 3986 unreserved characters. Spaces
+// become %20, as BIP21 does not give "+" any special meaning.
+func escape(s string) string {
+	return strings.ReplaceAll(url.QueryEscape(s), "+", "%20")
+}
+
+// ParseAmount parses a decimal amount of bitcoin, such as "0.0015", into satoshis.
+// Exponents, signs, more than eight decimals and amounts above the supply are rejected.
+func ParseAmount(s string) (btcutil.Amount, error) {
+	whole, frac, _ := strings.Cut(s, ".")
+	if whole == "" && frac == "" || !digits(whole) || !digits(frac) {
+		return 0, fmt.Errorf("invalid amount %q", s)
+	}
+	if len(frac) > 8 {
+		return 0, fmt.Errorf("amount %q has more than 8 decimals", s)
+	}
+	if len(strings.TrimLeft(whole, "0")) > 8 {
+		return 0, fmt.Errorf("amount %q exceeds the bitcoin supply", s)
+	}
+	sats, _ := strconv.ParseInt(strings.TrimLeft(whole, "0")+frac+strings.Repeat("0", 8-len(frac)), 10, 64)
+	amount := btcutil.Amount(sats)
+	if amount > btcutil.MaxSatoshi {
+		return 0, fmt.Errorf("amount %q exceeds the bitcoin supply", s)
+	}
+	if amount == 0 {
+		return 0, fmt.Errorf("amount must be positive")
+	}
+	return amount, nil
+}
+
+// FormatAmount formats an amount in bitcoin without trailing zeros, e.g. "0.0015".
+func FormatAmount(a btcutil.Amount) string {
+	s := fmt.Sprintf("%d.%08d", a/btcutil.SatoshiPerBitcoin, a%btcutil.SatoshiPerBitcoin)
+	return strings.TrimSuffix(strings.TrimRight(s, "0"), ".")
+}
+
+func digits(s string) bool {
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
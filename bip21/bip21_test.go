@@ -0,0 +1,162 @@
+package bip21
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	taproot = "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"
+	legacy  = "1BvBMSEYstWetqTFn5Au4m4GFg7xJaNVN2"
+	invoice = "lnbc20m1pvjluezpp5qqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqqqsyqcyq5rqwzqfqypqhp58yjmdan79s6qqdhdzgynm4zwqd5d7xmw5fk98klysy043l2ahrqsnp4q0n326hr8v9zprg8gsvezcch06gfaqqhde2aj730yg0durunfhv66"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name string
+		uri  string
+		want URI
+	}{
+		{"bare", "bitcoin:" + taproot, URI{Address: taproot}},
+		{"legacy address", "bitcoin:" + legacy, URI{Address: legacy}},
+		{"upper case from a QR code", "BITCOIN:" + strings.ToUpper(taproot), URI{Address: taproot}},
+		{"mixed case scheme", "Bitcoin:" + taproot, URI{Address: taproot}},
+		{"double slash", "bitcoin://" + taproot, URI{Address: taproot}},
+		{"surrounding spaces", "  bitcoin:" + taproot + "\n", URI{Address: taproot}},
+		{"amount", "bitcoin:" + taproot + "?amount=50", URI{Address: taproot, Amount: 50 * btcutil.SatoshiPerBitcoin}},
+		{"fractional amount", "bitcoin:" + taproot + "?amount=0.00015", URI{Address: taproot, Amount: 15000}},
+		{"one satoshi", "bitcoin:" + taproot + "?amount=.00000001", URI{Address: taproot, Amount: 1}},
+		{"trailing dot", "bitcoin:" + taproot + "?amount=1.", URI{Address: taproot, Amount: btcutil.SatoshiPerBitcoin}},
+		{"leading zeros", "bitcoin:" + taproot + "?amount=000000001.5", URI{Address: taproot, Amount: 150000000}},
+		{"whole supply", "bitcoin:" + taproot + "?amount=21000000", URI{Address: taproot, Amount: btcutil.MaxSatoshi}},
+		{
+			"label and message",
+			"bitcoin:" + taproot + "?label=Luke-Jr&message=Donation%20for%20project%20xyz",
+			URI{Address: taproot, Label: "Luke-Jr", Message: "Donation for project xyz"},
+		},
+		{"plus is literal", "bitcoin:" + taproot + "?label=a+b", URI{Address: taproot, Label: "a+b"}},
+		{"encoded reserved characters", "bitcoin:" + taproot + "?message=a%26b%3Dc%3F", URI{Address: taproot, Message: "a&b=c?"}},
+		{"utf-8", "bitcoin:" + taproot + "?label=caf%C3%A9", URI{Address: taproot, Label: "café"}},
+		{"upper case keys", "bitcoin:" + taproot + "?AMOUNT=1&Label=x", URI{Address: taproot, Amount: btcutil.SatoshiPerBitcoin, Label: "x"}},
+		{"empty parameters", "bitcoin:" + taproot + "?&amount=1&&", URI{Address: taproot, Amount: btcutil.SatoshiPerBitcoin}},
+		{"empty query", "bitcoin:" + taproot + "?", URI{Address: taproot}},
+		{"empty label", "bitcoin:" + taproot + "?label=", URI{Address: taproot}},
+		{
+			"unknown optional parameter",
+			"bitcoin:" + taproot + "?somethingyoudontunderstand=50&somethingelseyoudontget=999&flag",
+			URI{Address: taproot, Params: map[string]string{"somethingyoudontunderstand": "50", "somethingelseyoudontget": "999", "flag": ""}},
+		},
+		{
+			"payjoin endpoint",
+			"bitcoin:" + taproot + "?amount=0.01&pj=https://example.com/pj",
+			URI{Address: taproot, Amount: 1000000, Params: map[string]string{"pj": "https://example.com/pj"}},
+		},
+		{
+			"lightning fallback",
+			"bitcoin:" + strings.ToUpper(taproot) + "?amount=0.02&lightning=" + strings.ToUpper(invoice),
+			URI{Address: taproot, Amount: 2000000, Lightning: strings.ToUpper(invoice)},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse(tt.uri, &chaincfg.MainNetParams)
+			require.NoError(t, err)
+			if tt.want.Params == nil {
+				tt.want.Params = map[string]string{}
+			}
+			assert.Equal(t, &tt.want, got)
+		})
+	}
+}
+
+func TestParse_Rejects(t *testing.T) {
+	tests := []struct {
+		name string
+		uri  string
+		err  error
+	}{
+		{"bare address", taproot, ErrInvalidURI},
+		{"other scheme", "litecoin:" + taproot, ErrInvalidURI},
+		{"scheme only", "bitcoin:", ErrInvalidURI},
+		{"missing address", "bitcoin:?amount=1", ErrInvalidURI},
+		{"invalid address", "bitcoin:bc1qinvalid", ErrInvalidURI},
+		{"bad checksum", "bitcoin:" + taproot[:len(taproot)-1] + "q", ErrInvalidURI},
+		{"testnet address", "bitcoin:tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx", ErrInvalidURI},
+		{"required parameter", "bitcoin:" + taproot + "?req-somethingyoudontunderstand=50", ErrUnsupportedRequirement},
+		{"required parameter in upper case", "bitcoin:" + taproot + "?REQ-pop=x", ErrUnsupportedRequirement},
+		{"lightning only", "bitcoin:?lightning=" + invoice, ErrLightningOnly},
+		{"negative amount", "bitcoin:" + taproot + "?amount=-1", ErrInvalidURI},
+		{"zero amount", "bitcoin:" + taproot + "?amount=0", ErrInvalidURI},
+		{"empty amount", "bitcoin:" + taproot + "?amount=", ErrInvalidURI},
+		{"lone dot", "bitcoin:" + taproot + "?amount=.", ErrInvalidURI},
+		{"exponent", "bitcoin:" + taproot + "?amount=1e-3", ErrInvalidURI},
+		{"comma decimal", "bitcoin:" + taproot + "?amount=0,5", ErrInvalidURI},
+		{"two dots", "bitcoin:" + taproot + "?amount=1.2.3", ErrInvalidURI},
+		{"nine decimals", "bitcoin:" + taproot + "?amount=0.000000001", ErrInvalidURI},
+		{"above supply", "bitcoin:" + taproot + "?amount=21000000.00000001", ErrInvalidURI},
+		{"way above supply", "bitcoin:" + taproot + "?amount=999999999999", ErrInvalidURI},
+		{"duplicate amount", "bitcoin:" + taproot + "?amount=1&amount=2", ErrInvalidURI},
+		{"bad escape", "bitcoin:" + taproot + "?label=%zz", ErrInvalidURI},
+		{"nameless parameter", "bitcoin:" + taproot + "?=1", ErrInvalidURI},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.uri, &chaincfg.MainNetParams)
+			assert.ErrorIs(t, err, tt.err)
+		})
+	}
+}
+
+func TestString_RoundTrip(t *testing.T) {
+	u := &URI{
+		Address:   taproot,
+		Amount:    123456789,
+		Label:     "Bob & Alice",
+		Message:   "rent=100% paid?",
+		Lightning: invoice,
+		Params:    map[string]string{"pj": "https://example.com/pj?v=1", "b": "2"},
+	}
+	s := u.String()
+	assert.Equal(t, "bitcoin:"+taproot+"?amount=1.23456789&label=Bob%20%26%20Alice&message=rent%3D100%25%20paid%3F&lightning="+invoice+"&b=2&pj=https%3A%2F%2Fexample.com%2Fpj%3Fv%3D1", s)
+	got, err := Parse(s, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	assert.Equal(t, u, got)
+
+	assert.Equal(t, "bitcoin:"+taproot, (&URI{Address: taproot}).String())
+}
+
+func TestParse_OtherNetworks(t *testing.T) {
+	got, err := Parse("bitcoin:bcrt1qw508d6qejxtdg4y5r3zarvary0c5xw7kygt080?amount=1", &chaincfg.RegressionNetParams)
+	require.NoError(t, err)
+	assert.Equal(t, btcutil.Amount(btcutil.SatoshiPerBitcoin), got.Amount)
+	_, err = Parse("bitcoin:"+taproot, &chaincfg.RegressionNetParams)
+	assert.ErrorIs(t, err, ErrInvalidURI)
+}
+
+func TestAmount(t *testing.T) {
+	for s, want := range map[string]btcutil.Amount{
+		"1":          btcutil.SatoshiPerBitcoin,
+		"0.1":        10000000,
+		"0.00000001": 1,
+		"20999999.9": 2099999990000000,
+		"1.23456789": 123456789,
+	} {
+		got, err := ParseAmount(s)
+		require.NoError(t, err, s)
+		assert.Equal(t, want, got, s)
+		assert.Equal(t, s, FormatAmount(got))
+	}
+	assert.Equal(t, "0.5", FormatAmount(50000000))
+}
+
+func TestIsURI(t *testing.T) {
+	assert.True(t, IsURI("bitcoin:"+taproot))
+	assert.True(t, IsURI(" BITCOIN:x"))
+	assert.False(t, IsURI(taproot))
+	assert.False(t, IsURI("bitcoin"))
+}
@@ -0,0 +1,147 @@
+// Package bip329 reads and writes wallet labels in the JSON Lines format of BIP329
+// (https://github.com/bitcoin/bips/blob/master/bip-0329.mediawiki), shared with wallets
+// such as Sparrow and Bitcoin Core.
+package bip329
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Type is what a label refers to.
+type Type string
+
+const (
+	// Tx labels a transaction, by txid.
+	Tx Type = "tx"
+	// Addr labels an address.
+	Addr Type = "addr"
+	// Pubkey labels a public key, in hex.
+	Pubkey Type = "pubkey"
+	// Input labels a transaction input, by the outpoint it spends (txid:vout).
+	Input Type = "input"
+	// Output labels a transaction output, by its outpoint (txid:vout).
+	Output Type = "output"
+	// Xpub labels an extended public key.
+	Xpub Type = "xpub"
+)
+
+// ErrInvalidLabel is returned for records without a known type or a reference.
+var ErrInvalidLabel = errors.New("invalid label")
+
+// maxLine bounds a single record, labels included.
+const maxLine = 1 << 20
+
+// Label is a single BIP329 record.
+type Label struct {
+	Type   Type   `json:"type"`
+	Ref    string `json:"ref"`
+	Label  string `json:"label,omitempty"`
+	Origin string `json:"origin,omitempty"`
+	// Spendable is only meaningful for outputs: false marks an output that must not be
+	// spent. Nil leaves it spendable.
+	Spendable *bool `json:"spendable,omitempty"`
+}
+
+// Key identifies the labelled item, e.g. "addr:bc1p...".
+func (l Label) Key() string {
+	return string(l.Type) + ":" + l.Ref
+}
+
+// Known reports whether t is one of the types defined by BIP329.
+func (t Type) Known() bool {
+	switch t {
+	case Tx, Addr, Pubkey, Input, Output, Xpub:
+		return true
+	}
+	return false
+}
+
+// Validate checks that l has a known type and a reference.
+func (l Label) Validate() error {
+	if !l.Type.Known() {
+		return fmt.Errorf("%w: unknown type %q", ErrInvalidLabel, l.Type)
+	}
+	if strings.TrimSpace(l.Ref) == "" {
+		return fmt.Errorf("%w: %s label without a reference", ErrInvalidLabel, l.Type)
+	}
+	return nil
+}
+
+// Read parses labels, one JSON object per line. Blank lines are skipped. Records of a
+// type this wallet does not know, which later revisions of BIP329 may add, are skipped
+// too and counted in skipped. Spendable is dropped from records other than outputs.
+func Read(r io.Reader) (labels []Label, skipped int, err error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), maxLine)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		l, err := parse([]byte(text))
+		if err != nil {
+			return nil, 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		if err := l.Validate(); err != nil {
+			if !l.Type.Known() && l.Type != "" {
+				skipped++
+				continue
+			}
+			return nil, 0, fmt.Errorf("line %d: %w", line, err)
+		}
+		if l.Type != Output {
+			l.Spendable = nil
+		}
+		labels = append(labels, l)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+	return labels, skipped, nil
+}
+
+// parse decodes a record. Spendable is also accepted as a string, as in the example of
+// BIP329 itself.
+func parse(raw []byte) (Label, error) {
+	var record struct {
+		Label
+		Spendable json.RawMessage `json:"spendable"`
+	}
+	if err := json.Unmarshal(raw, &record); err != nil {
+		return Label{}, err
+	}
+	l := record.Label
+	if len(record.Spendable) == 0 || string(record.Spendable) == "null" {
+		return l, nil
+	}
+	var spendable bool
+	switch string(record.Spendable) {
+	case "true", `"true"`:
+		spendable = true
+	case "false", `"false"`:
+	default:
+		return Label{}, fmt.Errorf("%w: spendable must be true or false", ErrInvalidLabel)
+	}
+	l.Spendable = &spendable
+	return l, nil
+}
+
+// Write writes labels, one JSON object per line.
+func Write(w io.Writer, labels []Label) error {
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, l := range labels {
+		if err := l.Validate(); err != nil {
+			return err
+		}
+		if err := enc.Encode(l); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package bip329
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// example is the sample export of BIP329.
+const example = `{ "type": "tx", "ref": "f91d0a8a78462bc59398f2c5d7a84fcff491c26ba54c4833478b202796c8aafd", "label": "Transaction", "origin": "wpkh([d34db33f/84'/0'/0'])" }
+{ "type": "addr", "ref": "bc1q34aq5drpuwy3wgl9lhup9892qp6svr8ldzyy7c", "label": "Address" }
+{ "type": "pubkey", "ref": "0283409659355b6d1cc3c32decd5d561abaac86c37a353b52895a5e6c196d6f448", "label": "Public Key" }
+{ "type": "input", "ref": "f91d0a8a78462bc59398f2c5d7a84fcff491c26ba54c4833478b202796c8aafd:0", "label": "Input" }
+{ "type": "output", "ref": "f91d0a8a78462bc59398f2c5d7a84fcff491c26ba54c4833478b202796c8aafd:1", "label": "Output" , "spendable" : "false" }
+{ "type": "xpub", "ref": "xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8", "label": "Extended Public Key" }
+{ "type": "tx", "ref": "f546156d9044844e02b181026a1a407abfca62e7ea1159f87bbeaa77b4286c74", "label": "Account #1 Transaction", "origin": "wpkh([d34db33f/84'/0'/1'])" }`
+
+func TestRead_SpecExample(t *testing.T) {
+	labels, skipped, err := Read(strings.NewReader(example))
+	require.NoError(t, err)
+	assert.Zero(t, skipped)
+	require.Len(t, labels, 7)
+	assert.Equal(t, Label{
+		Type:   Tx,
+		Ref:    "f91d0a8a78462bc59398f2c5d7a84fcff491c26ba54c4833478b202796c8aafd",
+		Label:  "Transaction",
+		Origin: "wpkh([d34db33f/84'/0'/0'])",
+	}, labels[0])
+	assert.Equal(t, Output, labels[4].Type)
+	require.NotNil(t, labels[4].Spendable)
+	assert.False(t, *labels[4].Spendable)
+	assert.Equal(t, "xpub:xpub661MyMwAqRbcFtXgS5sYJABqqG9YLmC4Q1Rdap9gSE8NqtwybGhePY2gZ29ESFjqJoCu1Rupje8YtGqsefD265TMg7usUDFdp6W1EGMcet8", labels[5].Key())
+}
+
+func TestRead_Lenient(t *testing.T) {
+	in := `
+{"type":"addr","ref":"bc1qaddr","label":"a"}
+
+{"type":"future","ref":"x","label":"from a later BIP329"}
+{"type":"addr","ref":"bc1qother","spendable":true}
+`
+	labels, skipped, err := Read(strings.NewReader(in))
+	require.NoError(t, err)
+	assert.Equal(t, 1, skipped)
+	require.Len(t, labels, 2)
+	assert.Nil(t, labels[1].Spendable, "spendable only applies to outputs")
+}
+
+func TestRead_Rejects(t *testing.T) {
+	for name, in := range map[string]string{
+		"not json":      `type=addr`,
+		"missing type":  `{"ref":"bc1qaddr","label":"a"}`,
+		"missing ref":   `{"type":"addr","label":"a"}`,
+		"blank ref":     `{"type":"tx","ref":"  "}`,
+		"bad spendable": `{"type":"output","ref":"ab:0","spendable":"no"}`,
+		"second record": "{\"type\":\"tx\",\"ref\":\"ab\"}\n{",
+	} {
+		_, _, err := Read(strings.NewReader(in))
+		assert.Error(t, err, name)
+	}
+}
+
+func TestWrite_RoundTrip(t *testing.T) {
+	spendable := true
+	labels := []Label{
+		{Type: Addr, Ref: "bc1qaddr", Label: "Café <rent> & bills"},
+		{Type: Output, Ref: "ab:1", Spendable: &spendable},
+	}
+	var buf bytes.Buffer
+	require.NoError(t, Write(&buf, labels))
+	assert.Equal(t, `{"type":"addr","ref":"bc1qaddr","label":"Café <rent> & bills"}
+{"type":"output","ref":"ab:1","spendable":true}
+`, buf.String())
+	got, skipped, err := Read(&buf)
+	require.NoError(t, err)
+	assert.Zero(t, skipped)
+	assert.Equal(t, labels, got)
+
+	assert.ErrorIs(t, Write(&buf, []Label{{Type: "utxo", Ref: "x"}}), ErrInvalidLabel)
+}
@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/satelliondao/satellion/backup"
+	"github.com/satelliondao/satellion/config"
+	"github.com/satelliondao/satellion/enclave"
+	"github.com/satelliondao/satellion/wallet"
+	"github.com/satelliondao/satellion/walletdb"
+)
+
+func backupCmd(args []string) error {
+	fs := flag.NewFlagSet("backup", flag.ContinueOnError)
+	out := fs.String("out", backup.DefaultPath(), "path of the backup file to create")
+	seeds := fs.Bool("seeds", false, "include wallet mnemonics in the backup")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	return withWalletDB(cfg, func(repo *walletdb.WalletDB) error {
+		// The seeds of every wallet sharing the password are exported, so the password
+		// is asked even when `sat unlock` cached the key of the active wallet.
+		var walletPassword string
+		if *seeds {
+			var err error
+			if walletPassword, err = readPassword("Wallet password: "); err != nil {
+				return err
+			}
+		}
+		f, err := backup.Export(repo, cfg, *seeds, []byte(walletPassword))
+		if err != nil {
+			return err
+		}
+		password, err := readPassword("Backup password: ")
+		if err != nil {
+			return err
+		}
+		confirm, err := readPassword("Repeat password: ")
+		if err != nil {
+			return err
+		}
+		if password != confirm {
+			return fmt.Errorf("passwords do not match")
+		}
+		if err := backup.WriteFile(*out, f, password); err != nil {
+			return err
+		}
+		fmt.Printf("backed up %d wallet(s) to %s\n", len(f.Wallets), *out)
+		if missing := f.WithoutSeeds(); len(missing) > 0 {
+			fmt.Printf("seeds not included for %s: keep their mnemonics to be able to restore them\n", strings.Join(missing, ", "))
+		}
+		return nil
+	})
+}
+
+func restoreCmd(args []string) error {
+	fs := flag.NewFlagSet("restore", flag.ContinueOnError)
+	settings := fs.Bool("settings", false, "also replace the current settings with the ones in the backup, except hwi_path and the proxy settings")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: sat restore [-settings] <file>")
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	password, err := readPassword("Backup password: ")
+	if err != nil {
+		return err
+	}
+	f, err := backup.ReadFile(fs.Arg(0), password)
+	if err != nil {
+		return err
+	}
+	var walletPassword string
+	if f.HasSeeds() {
+		if walletPassword, err = readPassword("Password for restored wallets: "); err != nil {
+			return err
+		}
+	}
+	return withWalletDB(cfg, func(repo *walletdb.WalletDB) error {
+		report, err := backup.Restore(repo, f, cfg, []byte(walletPassword))
+		if err != nil {
+			return err
+		}
+		printReport(report)
+		if *settings && f.Settings != nil {
+			restored, kept := f.RestoredSettings(cfg)
+			if err := cfg.Save(restored); err != nil {
+				return fmt.Errorf("failed to save config: %w", err)
+			}
+			fmt.Println("settings restored")
+			if len(kept) > 0 {
+				fmt.Printf("kept the local %s: edit config.json to take the ones of the backup\n", strings.Join(kept, ", "))
+			}
+		}
+		return nil
+	})
+}
+
+func printReport(r *backup.Report) {
+	for _, name := range r.Added {
+		fmt.Printf("added   %s\n", name)
+	}
+	for _, name := range r.Merged {
+		fmt.Printf("merged  %s\n", name)
+	}
+	for from, to := range r.Renamed {
+		fmt.Printf("renamed %s to %s: a different wallet already uses the name\n", from, to)
+	}
+	for name, reason := range r.Skipped {
+		fmt.Printf("skipped %s: %s\n", name, reason)
+	}
+}
+
+func withWalletDB(cfg *config.Config, fn func(repo *walletdb.WalletDB) error) error {
+	wallet.LockParams = cfg.LockParams()
+	params, err := cfg.ChainParams()
+	if err != nil {
+		return err
+	}
+	db, err := walletdb.Open(walletdb.DefaultPath())
+	if errors.Is(err, walletdb.ErrInUse) {
+		return fmt.Errorf("%w: quit the app first", err)
+	}
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	repo := walletdb.New(db, params)
+	if err := repo.UseEnclave(cfg.Enclave, enclave.DefaultDir()); err != nil {
+		return err
+	}
+	return fn(repo)
+}
+
+// stdin is shared so that consecutive reads from a pipe do not lose buffered lines.
+var stdin = bufio.NewReader(os.Stdin)
+
+// readPassword prompts for a password without echo, or reads a line when stdin is not a terminal.
+func readPassword(prompt string) (string, error) {
+	fd := os.Stdin.Fd()
+	if !term.IsTerminal(fd) {
+		line, err := stdin.ReadString('\n')
+		if err != nil && line == "" {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		return strings.TrimRight(line, "\r\n"), nil
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	raw, err := term.ReadPassword(fd)
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	return string(raw), nil
+}
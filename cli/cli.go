@@ -0,0 +1,98 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+	"text/tabwriter"
+)
+
+// Command is a subcommand of the sat binary, e.g. `sat peers list`.
+type Command struct {
+	Name    string
+	Usage   string
+	Summary string
+	Run     func(args []string) error
+}
+
+var commands = []Command{
+	{
+		Name:    "peers",
+		Usage:   "peers [list [-wait 10s] | add <addr> | remove <addr> | ban <addr> | unban <addr> | connect-only on|off]",
+		Summary: "List and manage bitcoin peers while the app is not running; changes apply when it next starts",
+		Run:     peers,
+	},
+	{
+		Name:    "backup",
+		Usage:   "backup [-seeds] [-out file]",
+		Summary: "Write an encrypted backup of all wallets and settings",
+		Run:     backupCmd,
+	},
+	{
+		Name:    "hwi",
+		Usage:   "hwi [enumerate | import [-device fingerprint] <name> | display-address [-change] [-index n] | sign [-out file] <psbt>]",
+		Summary: "Use a hardware wallet through HWI",
+		Run:     hwiCmd,
+	},
+	{
+		Name:    "verify-address",
+		Usage:   "verify-address [-descriptor desc] [-change] [-index n] [address]",
+		Summary: "Re-derive an address from the wallet descriptor to cross-check it",
+		Run:     verifyAddressCmd,
+	},
+	{
+		Name:    "labels",
+		Usage:   "labels [list | set <type> <ref> [label] | import <file> | export [-out file]]",
+		Summary: "Manage wallet labels in the BIP329 format; -wallet picks another wallet than the active one",
+		Run:     labelsCmd,
+	},
+	{
+		Name:    "coins",
+		Usage:   "coins [list | freeze <txid:vout>... | unfreeze <txid:vout>...]",
+		Summary: "List the unspent coins of a wallet and freeze those that must never be spent",
+		Run:     coinsCmd,
+	},
+	{
+		Name:    "unlock",
+		Usage:   "unlock [-for 15m]",
+		Summary: "Cache the key of the wallet in the session keyring",
+		Run:     unlockCmd,
+	},
+	{
+		Name:    "lock",
+		Usage:   "lock",
+		Summary: "Forget the wallet key cached by unlock",
+		Run:     lockCmd,
+	},
+	{
+		Name:    "restore",
+		Usage:   "restore [-settings] <file>",
+		Summary: "Merge wallets from an encrypted backup",
+		Run:     restoreCmd,
+	},
+}
+
+// Run executes the subcommand named by the first argument.
+func Run(args []string) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage()
+		return nil
+	}
+	for _, c := range commands {
+		if c.Name == args[0] {
+			return c.Run(args[1:])
+		}
+	}
+	usage()
+	return fmt.Errorf("unknown command %q", args[0])
+}
+
+func usage() {
+	fmt.Println("Usage: sat [command]")
+	fmt.Println("Without a command, the interactive wallet is started.")
+	fmt.Println()
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %s\t%s\n", c.Usage, c.Summary)
+	}
+	w.Flush()
+}
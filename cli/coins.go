@@ -0,0 +1,91 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satelliondao/satellion/bip21"
+	"github.com/satelliondao/satellion/config"
+	"github.com/satelliondao/satellion/walletdb"
+)
+
+// coinsCmd lists the unspent outputs found by the last scan of a wallet and freezes
+// or unfreezes them. Frozen outputs are never picked by the coin selector.
+func coinsCmd(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	sub := "list"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("coins "+sub, flag.ContinueOnError)
+	name := fs.String("wallet", "", "wallet to use (default: the active wallet)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return withWalletDB(cfg, func(repo *walletdb.WalletDB) error {
+		if *name == "" {
+			if *name, err = repo.GetActiveWalletName(); err != nil {
+				return err
+			}
+		}
+		switch sub {
+		case "list":
+			return listCoins(repo, *name)
+		case "freeze", "unfreeze":
+			if fs.NArg() == 0 {
+				return fmt.Errorf("usage: sat coins %s [-wallet name] <txid:vout>...", sub)
+			}
+			for _, ref := range fs.Args() {
+				if _, err := wire.NewOutPointFromString(ref); err != nil {
+					return fmt.Errorf("%q is not an outpoint: %w", ref, err)
+				}
+				if err := repo.SetFrozen(*name, ref, sub == "freeze"); err != nil {
+					return err
+				}
+			}
+			done := "froze"
+			if sub == "unfreeze" {
+				done = "unfroze"
+			}
+			fmt.Printf("%s %d coin(s) of %s\n", done, fs.NArg(), *name)
+			return nil
+		}
+		return fmt.Errorf("unknown coins command %q", sub)
+	})
+}
+
+func listCoins(repo *walletdb.WalletDB, name string) error {
+	set, err := repo.Utxos(name)
+	if err != nil {
+		return err
+	}
+	if set.ScannedAt.IsZero() {
+		fmt.Printf("wallet %s was not scanned yet: synchronize it in the interactive wallet\n", name)
+		return nil
+	}
+	frozen, err := repo.Frozen(name)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "OUTPOINT\tBTC\tCONF\tADDRESS\tPATH\tSTATE")
+	for _, u := range set.Utxos {
+		state := ""
+		if frozen[u.Ref()] {
+			state = "frozen"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\t%s\t%s\n", u.Ref(), bip21.FormatAmount(u.Value),
+			u.Confirmations(set.Height), u.Address, u.Path(), state)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%d coin(s) as of block %d, scanned %s\n", len(set.Utxos), set.Height, set.ScannedAt.Format("2006-01-02 15:04"))
+	return nil
+}
@@ -0,0 +1,183 @@
+package cli
+
+import (
+	"bytes"
+	"encoding/base64"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/satelliondao/satellion/config"
+	"github.com/satelliondao/satellion/hwi"
+	"github.com/satelliondao/satellion/wallet"
+	"github.com/satelliondao/satellion/walletdb"
+)
+
+func hwiCmd(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	params, err := cfg.ChainParams()
+	if err != nil {
+		return err
+	}
+	client := hwi.New(cfg.HWIPath, params)
+	sub := "enumerate"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+	switch sub {
+	case "enumerate":
+		return listDevices(client)
+	case "import":
+		return importDevice(cfg, client, args)
+	case "display-address":
+		return displayAddress(cfg, client, args)
+	case "sign":
+		return signPSBT(cfg, client, args)
+	}
+	return fmt.Errorf("unknown hwi command %q", sub)
+}
+
+func listDevices(client *hwi.Client) error {
+	devices, err := client.Enumerate()
+	if err != nil {
+		return err
+	}
+	if len(devices) == 0 {
+		fmt.Println("no hardware wallet connected")
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "FINGERPRINT\tMODEL\tPATH\tSTATUS")
+	for _, d := range devices {
+		status := "ready"
+		switch {
+		case d.Error != "":
+			status = d.Error
+		case d.NeedsPinSent:
+			status = "locked: enter the PIN on the device"
+		case d.NeedsPassphraseSent:
+			status = "waiting for the passphrase"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", d.Fingerprint, d.Model, d.Path, status)
+	}
+	return w.Flush()
+}
+
+func importDevice(cfg *config.Config, client *hwi.Client, args []string) error {
+	fs := flag.NewFlagSet("hwi import", flag.ContinueOnError)
+	device := fs.String("device", "", "master fingerprint of the device, if several are connected")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: sat hwi import [-device fingerprint] <name>")
+	}
+	name := fs.Arg(0)
+	d, err := client.Device(*device)
+	if err != nil {
+		return err
+	}
+	w, err := client.WatchOnly(d.Fingerprint)
+	if err != nil {
+		return err
+	}
+	w.Name = name
+	w.CreatedAt = time.Now()
+	return withWalletDB(cfg, func(repo *walletdb.WalletDB) error {
+		existing, err := repo.GetAll()
+		if err != nil {
+			return err
+		}
+		for _, e := range existing {
+			if e.Name == name {
+				return fmt.Errorf("a wallet named %s already exists", name)
+			}
+		}
+		if err := repo.Save(w); err != nil {
+			return err
+		}
+		if err := repo.SetDefault(name); err != nil {
+			return err
+		}
+		fmt.Printf("imported %s %s as watch-only wallet %s\n", d.Model, w.Fingerprint, name)
+		return nil
+	})
+}
+
+func displayAddress(cfg *config.Config, client *hwi.Client, args []string) error {
+	fs := flag.NewFlagSet("hwi display-address", flag.ContinueOnError)
+	change := fs.Bool("change", false, "show a change address")
+	index := fs.Int("index", -1, "address index (default: the current receive address)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return withWalletDB(cfg, func(repo *walletdb.WalletDB) error {
+		w, err := repo.GetActiveWallet(nil)
+		if err != nil {
+			return err
+		}
+		if !w.WatchOnly() {
+			return fmt.Errorf("wallet %s is not a hardware wallet", w.Name)
+		}
+		var branch, i uint32
+		if *change {
+			branch, i = 1, w.NextChangeIndex
+		} else {
+			i = w.NextReceiveIndex
+		}
+		if *index >= 0 {
+			i = uint32(*index)
+		}
+		fmt.Println("confirm the address on the device...")
+		addr, err := client.VerifyAddress(w, branch, i)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("%s/%d/%d %s matches the device\n", wallet.AccountPath, branch, i, addr)
+		return nil
+	})
+}
+
+func signPSBT(cfg *config.Config, client *hwi.Client, args []string) error {
+	fs := flag.NewFlagSet("hwi sign", flag.ContinueOnError)
+	out := fs.String("out", "", "file to write the signed PSBT to (default: stdout)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		return fmt.Errorf("usage: sat hwi sign [-out file] <psbt file>")
+	}
+	raw, err := os.ReadFile(fs.Arg(0))
+	if err != nil {
+		return err
+	}
+	psbt := strings.TrimSpace(string(raw))
+	if bytes.HasPrefix(raw, []byte("psbt\xff")) {
+		psbt = base64.StdEncoding.EncodeToString(raw)
+	}
+	return withWalletDB(cfg, func(repo *walletdb.WalletDB) error {
+		w, err := repo.GetActiveWallet(nil)
+		if err != nil {
+			return err
+		}
+		if !w.WatchOnly() {
+			return fmt.Errorf("wallet %s is not a hardware wallet", w.Name)
+		}
+		fmt.Fprintln(os.Stderr, "confirm the transaction on the device...")
+		signed, err := client.SignPSBT(w.Fingerprint, psbt)
+		if err != nil {
+			return err
+		}
+		if *out == "" {
+			fmt.Println(signed)
+			return nil
+		}
+		return os.WriteFile(*out, []byte(signed+"\n"), 0o600)
+	})
+}
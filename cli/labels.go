@@ -0,0 +1,138 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+
+	"github.com/satelliondao/satellion/bip329"
+	"github.com/satelliondao/satellion/config"
+	"github.com/satelliondao/satellion/walletdb"
+)
+
+// labelsCmd lists, edits, imports and exports the labels of a wallet in the BIP329
+// format. Labels are not secret and need no password.
+func labelsCmd(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	sub := "list"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+	fs := flag.NewFlagSet("labels "+sub, flag.ContinueOnError)
+	name := fs.String("wallet", "", "wallet to use (default: the active wallet)")
+	out := fs.String("out", "", "file to export to (default: standard output)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return withWalletDB(cfg, func(repo *walletdb.WalletDB) error {
+		if *name == "" {
+			if *name, err = repo.GetActiveWalletName(); err != nil {
+				return err
+			}
+		}
+		switch sub {
+		case "list":
+			return listLabels(repo, *name)
+		case "export":
+			return exportLabels(repo, *name, *out)
+		case "import":
+			if fs.NArg() != 1 {
+				return fmt.Errorf("usage: sat labels import [-wallet name] <file | ->")
+			}
+			return importLabels(repo, *name, fs.Arg(0))
+		case "set":
+			if fs.NArg() < 2 || fs.NArg() > 3 {
+				return fmt.Errorf("usage: sat labels set [-wallet name] <type> <ref> [label]")
+			}
+			l, err := repo.Label(*name, bip329.Type(fs.Arg(0)), fs.Arg(1))
+			if err != nil {
+				return err
+			}
+			l.Label = fs.Arg(2)
+			return repo.SetLabels(*name, l)
+		}
+		return fmt.Errorf("unknown labels command %q", sub)
+	})
+}
+
+func listLabels(repo *walletdb.WalletDB, name string) error {
+	labels, err := repo.Labels(name)
+	if err != nil {
+		return err
+	}
+	if len(labels) == 0 {
+		fmt.Printf("wallet %s has no labels\n", name)
+		return nil
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "TYPE\tREF\tLABEL")
+	for _, l := range labels {
+		text := l.Label
+		if l.Spendable != nil && !*l.Spendable {
+			text += " [frozen]"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", l.Type, l.Ref, text)
+	}
+	return w.Flush()
+}
+
+func exportLabels(repo *walletdb.WalletDB, name, path string) error {
+	labels, err := repo.Labels(name)
+	if err != nil {
+		return err
+	}
+	if path == "" {
+		return bip329.Write(os.Stdout, labels)
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := bip329.Write(f, labels); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "exported %d label(s) of %s to %s\n", len(labels), name, path)
+	return nil
+}
+
+// importLabels adds the labels of a BIP329 file, "-" for standard input, replacing the
+// labels of the same items. Records without a label or spendable flag are ignored
+// rather than clearing a local label.
+func importLabels(repo *walletdb.WalletDB, name, path string) error {
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	labels, skipped, err := bip329.Read(in)
+	if err != nil {
+		return err
+	}
+	var set []bip329.Label
+	for _, l := range labels {
+		if l.Label != "" || l.Spendable != nil {
+			set = append(set, l)
+		}
+	}
+	if err := repo.SetLabels(name, set...); err != nil {
+		return err
+	}
+	fmt.Printf("imported %d label(s) into %s\n", len(set), name)
+	if skipped > 0 {
+		fmt.Printf("skipped %d label(s) of an unknown type\n", skipped)
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+
+	"github.com/satelliondao/satellion/config"
+	"github.com/satelliondao/satellion/neutrino"
+	"github.com/satelliondao/satellion/walletdb"
+)
+
+func peers(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	sub := "list"
+	if len(args) > 0 {
+		sub, args = args[0], args[1:]
+	}
+	switch sub {
+	case "list":
+		return listPeers(cfg, args)
+	case "add":
+		addr, err := peerArg(args)
+		if err != nil {
+			return err
+		}
+		if !cfg.AddPeer(addr) {
+			return fmt.Errorf("peer %s is already configured", addr)
+		}
+		return savePeers(cfg, "added %s", addr)
+	case "remove":
+		addr, err := peerArg(args)
+		if err != nil {
+			return err
+		}
+		if !cfg.RemovePeer(addr) {
+			return fmt.Errorf("peer %s is not configured", addr)
+		}
+		return savePeers(cfg, "removed %s", addr)
+	case "ban", "unban":
+		addr, err := peerArg(args)
+		if err != nil {
+			return err
+		}
+		return withChain(cfg, func(chain *neutrino.Chain) error {
+			if sub == "ban" {
+				return chain.BanPeer(addr)
+			}
+			return chain.UnbanPeer(addr)
+		})
+	case "connect-only":
+		if len(args) != 1 || (args[0] != "on" && args[0] != "off") {
+			return fmt.Errorf("usage: sat peers connect-only on|off")
+		}
+		cfg.ConnectOnly = args[0] == "on"
+		return savePeers(cfg, "connect only to configured peers: %s", args[0])
+	}
+	return fmt.Errorf("unknown peers command %q", sub)
+}
+
+func peerArg(args []string) (string, error) {
+	if len(args) != 1 {
+		return "", fmt.Errorf("expected a single peer address (host:port)")
+	}
+	return args[0], nil
+}
+
+// savePeers writes the peer settings to config.json. A running app reads them only when
+// it starts and writes its own back from the Peers page, so nothing is saved under it.
+func savePeers(cfg *config.Config, format string, args ...interface{}) error {
+	if err := checkAppStopped(cfg); err != nil {
+		return err
+	}
+	if err := cfg.Save(cfg); err != nil {
+		return fmt.Errorf("failed to save config: %w", err)
+	}
+	fmt.Printf(format+"\n", args...)
+	return nil
+}
+
+// checkAppStopped fails while a running app holds the chain database.
+func checkAppStopped(cfg *config.Config) error {
+	path := filepath.Join(cfg.ChainDataDir(), "neutrino.db")
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+	db, err := walletdb.Connect(path)
+	if errors.Is(err, walletdb.ErrInUse) {
+		return fmt.Errorf("%w: the running app would not see the change, quit it first or use its Peers page", err)
+	}
+	if err != nil {
+		return err
+	}
+	return db.Close()
+}
+
+// withChain starts a chain service of its own for fn. The chain database can only be
+// opened by one process, so this fails while the app is running.
+func withChain(cfg *config.Config, fn func(chain *neutrino.Chain) error) error {
+	chain, err := neutrino.NewChain(cfg)
+	if errors.Is(err, walletdb.ErrInUse) {
+		return fmt.Errorf("%w: quit the app first, or manage peers from its Peers page", err)
+	}
+	if err != nil {
+		return err
+	}
+	defer chain.Stop()
+	if err := chain.Start(); err != nil {
+		return err
+	}
+	return fn(chain)
+}
+
+func listPeers(cfg *config.Config, args []string) error {
+	fs := flag.NewFlagSet("peers list", flag.ContinueOnError)
+	wait := fs.Duration("wait", 10*time.Second, "how long to wait for peers to connect")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	return withChain(cfg, func(chain *neutrino.Chain) error {
+		deadline := time.Now().Add(*wait)
+		for time.Now().Before(deadline) && int(chain.ConnectedCount()) < cfg.MinPeers {
+			time.Sleep(500 * time.Millisecond)
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "ADDRESS\tUSER AGENT\tSERVICES\tHEIGHT\tLATENCY\tBANNED")
+		for _, p := range chain.Peers() {
+			fmt.Fprintf(w, "%s\t%s\t%s\t%d\t%s\t%t\n", p.Addr, p.UserAgent, p.Services, p.Height, p.Latency, p.Banned)
+		}
+		return w.Flush()
+	})
+}
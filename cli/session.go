@@ -0,0 +1,127 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/satelliondao/satellion/config"
+	"github.com/satelliondao/satellion/enclave"
+	"github.com/satelliondao/satellion/secret"
+	"github.com/satelliondao/satellion/service"
+	"github.com/satelliondao/satellion/wallet"
+	"github.com/satelliondao/satellion/walletdb"
+)
+
+func unlockCmd(args []string) error {
+	fs := flag.NewFlagSet("unlock", flag.ContinueOnError)
+	ttl := fs.Duration("for", 0, "how long the wallet stays unlocked (default session_keyring_minutes)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	cache, err := sessionCache(cfg)
+	if err != nil {
+		return err
+	}
+	if cache == nil {
+		return fmt.Errorf("session keyring is disabled: set session_keyring to \"session\" or \"user\" in the config")
+	}
+	if *ttl <= 0 {
+		*ttl = cfg.SessionTimeout()
+	}
+	return withWalletDB(cfg, func(repo *walletdb.WalletDB) error {
+		name, err := repo.GetActiveWalletName()
+		if err != nil {
+			return err
+		}
+		password, err := readPassword("Wallet password: ")
+		if err != nil {
+			return err
+		}
+		if err := service.NewWalletService(repo).Unlock(password); err != nil {
+			return err
+		}
+		key, err := repo.SessionKey(name, []byte(password))
+		if err != nil {
+			return err
+		}
+		defer key.Wipe()
+		if err := cache.Put(sessionKey(name), key.Bytes(), *ttl); err != nil {
+			return err
+		}
+		fmt.Printf("%s unlocked for %s\n", name, *ttl)
+		return nil
+	})
+}
+
+func lockCmd(args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	cache, err := sessionCache(cfg)
+	if err != nil || cache == nil {
+		return err
+	}
+	return withWalletDB(cfg, func(repo *walletdb.WalletDB) error {
+		name, err := repo.GetActiveWalletName()
+		if err != nil {
+			return err
+		}
+		return cache.Forget(sessionKey(name))
+	})
+}
+
+// activeWallet returns the active wallet unlocked with the key cached by `sat unlock`, or
+// with the password it prompts for. A cached key that no longer opens the wallet, as after
+// its password changed, is ignored.
+func activeWallet(cfg *config.Config, repo *walletdb.WalletDB) (*wallet.Wallet, error) {
+	name, err := repo.GetActiveWalletName()
+	if err != nil {
+		return nil, err
+	}
+	cache, err := sessionCache(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cache != nil {
+		cached, err := cache.Get(sessionKey(name))
+		var notFound *enclave.NotFoundError
+		switch {
+		case err == nil:
+			w, err := repo.GetWithSessionKey(name, cached)
+			secret.Wipe(cached)
+			if !errors.Is(err, walletdb.ErrInvalidPassword) {
+				return w, err
+			}
+		case !errors.As(err, &notFound):
+			return nil, err
+		}
+	}
+	password, err := readPassword("Wallet password: ")
+	if err != nil {
+		return nil, err
+	}
+	return repo.Get(name, []byte(password))
+}
+
+// sessionCache returns the keyring configured for cached keys, or nil when caching
+// is disabled.
+func sessionCache(cfg *config.Config) (enclave.SessionCache, error) {
+	if cfg.SessionKeyring == "" {
+		return nil, nil
+	}
+	keyring, err := enclave.NewKernelKeyring(cfg.SessionKeyring)
+	if err != nil {
+		return nil, err
+	}
+	return keyring, nil
+}
+
+func sessionKey(walletName string) string {
+	return "wallet:" + walletName
+}
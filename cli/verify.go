@@ -0,0 +1,122 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+
+	"github.com/satelliondao/satellion/config"
+	"github.com/satelliondao/satellion/wallet"
+	"github.com/satelliondao/satellion/walletdb"
+)
+
+// verifyAddressCmd re-derives an address from the public descriptor of the wallet,
+// independently of the private key path the wallet uses, so that an address shown
+// elsewhere can be cross-checked.
+func verifyAddressCmd(args []string) error {
+	fs := flag.NewFlagSet("verify-address", flag.ContinueOnError)
+	descriptor := fs.String("descriptor", "", "descriptor to derive from instead of the active wallet")
+	change := fs.Bool("change", false, "derive a change address")
+	index := fs.Int("index", -1, "address index (default: the current address of the active wallet, or 0)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() > 1 {
+		return fmt.Errorf("usage: sat verify-address [-descriptor desc] [-change] [-index n] [address]")
+	}
+	var branch uint32
+	if *change {
+		branch = 1
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load config: %w", err)
+	}
+	if *descriptor != "" {
+		params, err := cfg.ChainParams()
+		if err != nil {
+			return err
+		}
+		d, err := wallet.ParseDescriptor(*descriptor, params)
+		if err != nil {
+			return err
+		}
+		if d.Change != branch {
+			return fmt.Errorf("the descriptor is for /%d/*: drop or add -change", d.Change)
+		}
+		return printVerified(d, nil, uint32(max(*index, 0)), fs.Arg(0))
+	}
+	return withWalletDB(cfg, func(repo *walletdb.WalletDB) error {
+		name, err := repo.GetActiveWalletName()
+		if err != nil {
+			return err
+		}
+		all, err := repo.GetAll()
+		if err != nil {
+			return err
+		}
+		var w *wallet.Wallet
+		for _, listed := range all {
+			if listed.Name == name && listed.WatchOnly() {
+				w = &listed
+			}
+		}
+		if w == nil {
+			if w, err = activeWallet(cfg, repo); err != nil {
+				return err
+			}
+		}
+		defer w.Wipe()
+		desc, err := w.Descriptor(branch)
+		if err != nil {
+			return err
+		}
+		d, err := wallet.ParseDescriptor(desc, repo.Params())
+		if err != nil {
+			return err
+		}
+		i := w.NextReceiveIndex
+		if *change {
+			i = w.NextChangeIndex
+		}
+		if *index >= 0 {
+			i = uint32(*index)
+		}
+		fmt.Printf("descriptor  %s\n", desc)
+		if err := printVerified(d, w, i, fs.Arg(0)); err != nil {
+			return err
+		}
+		if w.WatchOnly() {
+			fmt.Println("run sat hwi display-address to also check it on the device")
+		}
+		return nil
+	})
+}
+
+// printVerified prints the address of d at index. It fails when the address differs
+// from the one derived by w through its private keys, or from expected.
+func printVerified(d *wallet.Descriptor, w *wallet.Wallet, index uint32, expected string) error {
+	addr, err := d.Address(index)
+	if err != nil {
+		return err
+	}
+	got := addr.Address.String()
+	fmt.Printf("path        %s\n", wallet.AddressPath(d.Change, index))
+	fmt.Printf("fingerprint %s\n", d.Fingerprint)
+	fmt.Printf("address     %s\n", got)
+	if w != nil {
+		derived, err := w.DeriveTaprootAddress(d.Change, index)
+		if err != nil {
+			return err
+		}
+		if derived.Address.String() != got {
+			return fmt.Errorf("MISMATCH: the wallet derives %s", derived.Address.String())
+		}
+	}
+	if expected != "" {
+		if expected != got {
+			return fmt.Errorf("MISMATCH: %s is not the address at this index", expected)
+		}
+		fmt.Println("match")
+	}
+	return nil
+}
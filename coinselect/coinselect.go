@@ -0,0 +1,251 @@
+// Package coinselect chooses the wallet outputs that fund a payment. Every way of
+// sending goes through Select, so frozen outputs are never spent.
+package coinselect
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satelliondao/satellion/wallet"
+)
+
+var (
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	// ErrFrozen is returned when a hand-picked output is frozen.
+	ErrFrozen = errors.New("output is frozen")
+	// ErrPending is returned when a hand-picked output is spent by an unconfirmed
+	// transaction.
+	ErrPending = errors.New("output is spent by an unconfirmed transaction")
+	// ErrUnknownOutput is returned when a hand-picked output is not a wallet output.
+	ErrUnknownOutput = errors.New("output is not unspent in this wallet")
+)
+
+// Virtual sizes of the parts of a transaction spending taproot key path outputs to
+// taproot outputs, rounded up.
+const (
+	// TxOverheadVSize counts the version, locktime, input and output counts and the
+	// segwit marker.
+	TxOverheadVSize = 11
+	// InputVSize is a key path input with a default sighash schnorr signature.
+	InputVSize = 58
+	// OutputVSize is a P2TR output.
+	OutputVSize = 43
+)
+
+// DustLimit is the smallest P2TR output relayed by default by Bitcoin Core. Change below
+// it goes to fees instead.
+const DustLimit btcutil.Amount = 330
+
+// Dust returns the smallest output paying script that Bitcoin Core relays by default:
+// one worth less than spending it at 3 sat/vB. DustLimit is the dust of P2TR outputs.
+func Dust(script []byte) btcutil.Amount {
+	// Serialized output: value, script length and script.
+	size := 8 + wire.VarIntSerializeSize(uint64(len(script))) + len(script)
+	if txscript.IsWitnessProgram(script) {
+		// Outpoint, empty script, sequence and a witness of 107 weight units.
+		size += 32 + 4 + 1 + 107/4 + 4
+	} else {
+		size += 32 + 4 + 1 + 107 + 4
+	}
+	return btcutil.Amount(size) * 3
+}
+
+// DefaultFeeRate is the fee rate in sat/vB used when none is given.
+const DefaultFeeRate btcutil.Amount = 2
+
+// IncrementalRelayFeeRate is the fee rate in sat/vB a replacement pays on top of the fee
+// of the transactions it replaces, following the default policy of Bitcoin Core.
+const IncrementalRelayFeeRate btcutil.Amount = 1
+
+// Request describes a payment to fund.
+type Request struct {
+	// Utxos are the unspent outputs of the wallet.
+	Utxos []wallet.Utxo
+	// Frozen holds the outputs that must not be spent, keyed by txid:vout.
+	Frozen map[string]bool
+	// Pending holds the outputs spent by unconfirmed transactions, keyed by txid:vout.
+	Pending map[string]bool
+	// Picked are hand-picked inputs. When set, exactly these outputs are spent.
+	Picked []wire.OutPoint
+	// Required are spent before any other output, whether frozen or pending. They are the
+	// inputs of a transaction being replaced.
+	Required []wallet.Utxo
+	// Replaces is the fee of the transaction being replaced. BIP125 replacements pay
+	// it, and their own relay at IncrementalRelayFeeRate.
+	Replaces btcutil.Amount
+	// Amount is the total paid to the recipients.
+	Amount btcutil.Amount
+	// Outputs is the number of recipients, 1 when zero.
+	Outputs int
+	// FeeRate is in sat/vB, DefaultFeeRate when zero.
+	FeeRate btcutil.Amount
+}
+
+// Selection is the funding of a payment.
+type Selection struct {
+	Inputs []wallet.Utxo
+	Fee    btcutil.Amount
+	// Change is the amount returned to the wallet, 0 when there is no change output.
+	Change btcutil.Amount
+}
+
+// Spendable returns the outputs of utxos in none of the excluded sets, keyed by
+// txid:vout.
+func Spendable(utxos []wallet.Utxo, excluded ...map[string]bool) []wallet.Utxo {
+	var list []wallet.Utxo
+next:
+	for _, u := range utxos {
+		for _, set := range excluded {
+			if set[u.Ref()] {
+				continue next
+			}
+		}
+		list = append(list, u)
+	}
+	return list
+}
+
+// VSize returns the virtual size of a transaction with inputs inputs and outputs outputs.
+func VSize(inputs, outputs int) int64 {
+	return int64(TxOverheadVSize + inputs*InputVSize + outputs*OutputVSize)
+}
+
+// Select funds r. Required and hand-picked outputs are all spent; otherwise the largest
+// spendable outputs are added to the required ones until they pay the amount and the fee.
+func Select(r Request) (*Selection, error) {
+	if r.Amount <= 0 {
+		return nil, fmt.Errorf("amount must be positive")
+	}
+	if r.Outputs <= 0 {
+		r.Outputs = 1
+	}
+	if r.FeeRate <= 0 {
+		r.FeeRate = DefaultFeeRate
+	}
+	required := append([]wallet.Utxo{}, r.Required...)
+	if len(r.Picked) > 0 {
+		inputs, err := picked(r)
+		if err != nil {
+			return nil, err
+		}
+		return fund(r, append(required, inputs...))
+	}
+	candidates := Spendable(r.Utxos, r.Frozen, r.Pending, refs(r.Required))
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].Value > candidates[j].Value })
+	// Required outputs may pay on their own; otherwise at least one more is needed.
+	first := 1
+	if len(required) > 0 {
+		first = 0
+	}
+	for n := first; n <= len(candidates); n++ {
+		if s, err := fund(r, append(required, candidates[:n]...)); err == nil {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %s spendable, %s needed before fees",
+		ErrInsufficientFunds, total(candidates)+total(required), r.Amount)
+}
+
+// SelectAll spends every hand-picked output of r, or else every spendable one, without
+// change. r.Amount is paid to the recipients with fixed amounts and r.Outputs counts
+// them and the recipient of everything else, whose amount is returned: the inputs less
+// r.Amount and the fee.
+func SelectAll(r Request) (*Selection, btcutil.Amount, error) {
+	if r.Amount < 0 {
+		return nil, 0, fmt.Errorf("amount must not be negative")
+	}
+	if r.Outputs <= 0 {
+		r.Outputs = 1
+	}
+	if r.FeeRate <= 0 {
+		r.FeeRate = DefaultFeeRate
+	}
+	inputs := Spendable(r.Utxos, r.Frozen, r.Pending)
+	if len(r.Picked) > 0 {
+		var err error
+		if inputs, err = picked(r); err != nil {
+			return nil, 0, err
+		}
+	}
+	if len(inputs) == 0 {
+		return nil, 0, fmt.Errorf("%w: no spendable outputs", ErrInsufficientFunds)
+	}
+	in := total(inputs)
+	fee := r.fee(VSize(len(inputs), r.Outputs))
+	rest := in - r.Amount - fee
+	if rest < DustLimit {
+		return nil, 0, fmt.Errorf("%w: inputs of %s leave %s after paying %s and a fee of %s",
+			ErrInsufficientFunds, in, max(rest, 0), r.Amount, fee)
+	}
+	return &Selection{Inputs: inputs, Fee: fee}, rest, nil
+}
+
+// refs returns the outpoints of utxos as txid:vout.
+func refs(utxos []wallet.Utxo) map[string]bool {
+	set := make(map[string]bool, len(utxos))
+	for _, u := range utxos {
+		set[u.Ref()] = true
+	}
+	return set
+}
+
+// picked returns the hand-picked outputs of r.
+func picked(r Request) ([]wallet.Utxo, error) {
+	byOutPoint := make(map[wire.OutPoint]wallet.Utxo, len(r.Utxos))
+	for _, u := range r.Utxos {
+		byOutPoint[u.OutPoint] = u
+	}
+	inputs := make([]wallet.Utxo, 0, len(r.Picked))
+	for _, op := range r.Picked {
+		u, ok := byOutPoint[op]
+		if !ok {
+			return nil, fmt.Errorf("%w: %s", ErrUnknownOutput, op)
+		}
+		if r.Frozen[u.Ref()] {
+			return nil, fmt.Errorf("%w: %s", ErrFrozen, op)
+		}
+		if r.Pending[u.Ref()] {
+			return nil, fmt.Errorf("%w: %s", ErrPending, op)
+		}
+		inputs = append(inputs, u)
+	}
+	return inputs, nil
+}
+
+// fund returns the selection spending inputs for r, with a change output when the
+// change is above the dust limit.
+func fund(r Request, inputs []wallet.Utxo) (*Selection, error) {
+	in := total(inputs)
+	fee := r.fee(VSize(len(inputs), r.Outputs))
+	if in < r.Amount+fee {
+		return nil, fmt.Errorf("%w: inputs of %s do not pay %s and a fee of %s", ErrInsufficientFunds, in, r.Amount, fee)
+	}
+	withChange := r.fee(VSize(len(inputs), r.Outputs+1))
+	change := in - r.Amount - withChange
+	if change < DustLimit {
+		return &Selection{Inputs: inputs, Fee: in - r.Amount}, nil
+	}
+	return &Selection{Inputs: inputs, Fee: withChange, Change: change}, nil
+}
+
+// fee returns the fee of a transaction of vsize vbytes at the fee rate of r, and at
+// least the fee of a replacement of r.Replaces.
+func (r Request) fee(vsize int64) btcutil.Amount {
+	fee := btcutil.Amount(vsize) * r.FeeRate
+	if r.Replaces > 0 {
+		fee = max(fee, r.Replaces+btcutil.Amount(vsize)*IncrementalRelayFeeRate)
+	}
+	return fee
+}
+
+func total(utxos []wallet.Utxo) btcutil.Amount {
+	var sum btcutil.Amount
+	for _, u := range utxos {
+		sum += u.Value
+	}
+	return sum
+}
@@ -0,0 +1,115 @@
+package coinselect
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satelliondao/satellion/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func utxo(index uint32, value btcutil.Amount) wallet.Utxo {
+	return wallet.Utxo{OutPoint: wire.OutPoint{Index: index}, Value: value}
+}
+
+var utxos = []wallet.Utxo{utxo(0, 10_000), utxo(1, 50_000), utxo(2, 20_000)}
+
+func TestSelect_LargestFirst(t *testing.T) {
+	s, err := Select(Request{Utxos: utxos, Amount: 30_000, FeeRate: 1})
+	require.NoError(t, err)
+	require.Len(t, s.Inputs, 1)
+	assert.Equal(t, btcutil.Amount(50_000), s.Inputs[0].Value)
+	assert.Equal(t, btcutil.Amount(VSize(1, 2)), s.Fee)
+	assert.Equal(t, 50_000-30_000-s.Fee, s.Change)
+
+	s, err = Select(Request{Utxos: utxos, Amount: 60_000, FeeRate: 1})
+	require.NoError(t, err)
+	assert.Len(t, s.Inputs, 2)
+}
+
+func TestSelect_SkipsFrozen(t *testing.T) {
+	frozen := map[string]bool{utxos[1].Ref(): true}
+	s, err := Select(Request{Utxos: utxos, Frozen: frozen, Amount: 25_000, FeeRate: 1})
+	require.NoError(t, err)
+	for _, in := range s.Inputs {
+		assert.NotEqual(t, utxos[1].OutPoint, in.OutPoint)
+	}
+	_, err = Select(Request{Utxos: utxos, Frozen: frozen, Amount: 40_000, FeeRate: 1})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	assert.Len(t, Spendable(utxos, frozen), 2)
+}
+
+func TestSelect_Picked(t *testing.T) {
+	s, err := Select(Request{Utxos: utxos, Picked: []wire.OutPoint{utxos[0].OutPoint, utxos[2].OutPoint}, Amount: 1_000, FeeRate: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []wallet.Utxo{utxos[0], utxos[2]}, s.Inputs, "every picked output is spent")
+
+	_, err = Select(Request{Utxos: utxos, Picked: []wire.OutPoint{utxos[0].OutPoint}, Amount: 20_000, FeeRate: 1})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	_, err = Select(Request{Utxos: utxos, Frozen: map[string]bool{utxos[0].Ref(): true}, Picked: []wire.OutPoint{utxos[0].OutPoint}, Amount: 1_000})
+	assert.ErrorIs(t, err, ErrFrozen)
+	_, err = Select(Request{Utxos: utxos, Picked: []wire.OutPoint{{Index: 9}}, Amount: 1_000})
+	assert.ErrorIs(t, err, ErrUnknownOutput)
+}
+
+func TestSelect_DustChangeGoesToFee(t *testing.T) {
+	fee := btcutil.Amount(VSize(1, 1)) * 2
+	s, err := Select(Request{Utxos: utxos[:1], Amount: 10_000 - fee - 100})
+	require.NoError(t, err)
+	assert.Zero(t, s.Change)
+	assert.Equal(t, fee+100, s.Fee)
+
+	_, err = Select(Request{Utxos: utxos, Amount: 0})
+	assert.Error(t, err)
+}
+
+func TestSelect_Replacement(t *testing.T) {
+	orig := []wallet.Utxo{utxo(5, 10_000)}
+	pending := map[string]bool{orig[0].Ref(): true}
+	// The replaced fee of 1000 sats plus 1 sat/vB of relay beats 2 sat/vB here.
+	s, err := Select(Request{Utxos: utxos, Pending: pending, Required: orig, Replaces: 1_000, Amount: 5_000, FeeRate: 2})
+	require.NoError(t, err)
+	assert.Equal(t, orig, s.Inputs)
+	assert.Equal(t, 1_000+btcutil.Amount(VSize(1, 2)), s.Fee)
+
+	// Paying more than the required input brings in the largest other output.
+	s, err = Select(Request{Utxos: utxos, Pending: pending, Required: orig, Replaces: 1_000, Amount: 9_500, FeeRate: 2})
+	require.NoError(t, err)
+	assert.Equal(t, []wallet.Utxo{orig[0], utxos[1]}, s.Inputs)
+
+	_, err = Select(Request{Utxos: utxos, Pending: map[string]bool{utxos[0].Ref(): true}, Picked: []wire.OutPoint{utxos[0].OutPoint}, Amount: 1_000})
+	assert.ErrorIs(t, err, ErrPending)
+}
+
+func TestSelectAll(t *testing.T) {
+	frozen := map[string]bool{utxos[0].Ref(): true}
+	s, rest, err := SelectAll(Request{Utxos: utxos, Frozen: frozen, FeeRate: 1})
+	require.NoError(t, err)
+	assert.Len(t, s.Inputs, 2, "frozen outputs stay")
+	assert.Zero(t, s.Change)
+	assert.Equal(t, btcutil.Amount(VSize(2, 1)), s.Fee)
+	assert.Equal(t, 70_000-s.Fee, rest)
+
+	// A batch with a fixed output sends the rest of the picked output to the other one.
+	s, rest, err = SelectAll(Request{Utxos: utxos, Picked: []wire.OutPoint{utxos[2].OutPoint}, Amount: 5_000, Outputs: 2, FeeRate: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []wallet.Utxo{utxos[2]}, s.Inputs)
+	assert.Equal(t, 20_000-5_000-btcutil.Amount(VSize(1, 2)), rest)
+
+	_, _, err = SelectAll(Request{Utxos: utxos[:1], Amount: 9_800, Outputs: 2, FeeRate: 1})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+	_, _, err = SelectAll(Request{Utxos: utxos, Frozen: refs(utxos)})
+	assert.ErrorIs(t, err, ErrInsufficientFunds)
+}
+
+func TestDust(t *testing.T) {
+	p2tr := append([]byte{txscript.OP_1, txscript.OP_DATA_32}, make([]byte, 32)...)
+	p2wpkh := append([]byte{txscript.OP_0, txscript.OP_DATA_20}, make([]byte, 20)...)
+	p2pkh := append(append([]byte{txscript.OP_DUP, txscript.OP_HASH160, txscript.OP_DATA_20}, make([]byte, 20)...), txscript.OP_EQUALVERIFY, txscript.OP_CHECKSIG)
+	assert.Equal(t, DustLimit, Dust(p2tr))
+	assert.Equal(t, btcutil.Amount(294), Dust(p2wpkh))
+	assert.Equal(t, btcutil.Amount(546), Dust(p2pkh))
+}
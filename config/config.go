@@ -2,8 +2,13 @@ package config
 
 import (
 	"encoding/json"
+	"fmt"
 	"os"
 	"path/filepath"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/satelliondao/satellion/wallet"
 )
 
 type Config struct {
@@ -15,6 +20,173 @@ type Config struct {
 	// SyncTimeoutMinutes is the maximum age in minutes for a block to be considered current.
 	// If omitted or zero in the config file, it defaults to 30 minutes.
 	SyncTimeoutMinutes int `json:"sync_timeout_minutes"`
+	// Network is the bitcoin network to sync: mainnet, testnet, signet or regtest.
+	// If omitted, it defaults to mainnet.
+	Network string `json:"network,omitempty"`
+	// DataDir is the directory where chain data is stored.
+	// If omitted, it defaults to ~/.satellion.
+	DataDir string `json:"data_dir,omitempty"`
+	// Proxy is the address (host:port) of a SOCKS5 proxy used for peer connections,
+	// e.g. 127.0.0.1:9050 for a local Tor daemon. If omitted, peers are reached over clearnet.
+	Proxy string `json:"proxy,omitempty"`
+	// ProxyStreamIsolation uses random proxy credentials for every connection,
+	// so that Tor builds a separate circuit for each peer.
+	ProxyStreamIsolation bool `json:"proxy_stream_isolation,omitempty"`
+	// ProxyOnly forbids falling back to clearnet when the proxy fails to connect or resolve.
+	ProxyOnly bool `json:"proxy_only,omitempty"`
+	// OnionPeers is a list of Tor hidden service peers (host.onion:port).
+	// They are only used when a proxy is configured.
+	OnionPeers []string `json:"onion_peers,omitempty"`
+	// ConnectOnly restricts connections to the configured peers, e.g. for users
+	// running their own full node. Peers are not discovered through DNS seeds or gossip.
+	ConnectOnly bool `json:"connect_only,omitempty"`
+	// FilterHeaderQuorum is the number of peers that must serve the same filter header
+	// as ours before compact filters are trusted. If omitted, 2 peers are required. Zero
+	// disables the cross-peer check. With ConnectOnly it is capped at the number of
+	// configured peers, so that a single node of the user's own is enough.
+	FilterHeaderQuorum *int `json:"filter_header_quorum,omitempty"`
+	// UnlockKDF tunes the argon2id cost of new wallet locks. Existing locks keep the
+	// parameters they were created with. If omitted, RFC 9106 recommended values are used,
+	// also for every field left at zero.
+	UnlockKDF *wallet.KDFParams `json:"unlock_kdf,omitempty"`
+	// IdleLockMinutes locks the wallet after this many minutes without a key press. If
+	// omitted or zero, 5 minutes is used. A negative value disables auto-lock.
+	IdleLockMinutes int `json:"idle_lock_minutes"`
+	// SessionKeyring caches the key derived from the wallet password in the Linux kernel
+	// keyring after `sat unlock`, so that CLI commands do not prompt for the password again:
+	// "session" or "user". The password itself is never cached. If omitted, nothing is.
+	SessionKeyring string `json:"session_keyring,omitempty"`
+	// SessionKeyringMinutes is how long a cached key stays valid. If omitted or zero,
+	// 15 minutes is used.
+	SessionKeyringMinutes int `json:"session_keyring_minutes,omitempty"`
+	// Enclave selects where the encrypted seeds of newly sealed wallets are stored: "file"
+	// for a password-encrypted store in ~/.satellion/enclave. If omitted, seeds are stored
+	// in wallets.db. The keyring and memory backends are refused, as they lose the seeds on
+	// logout or exit.
+	Enclave string `json:"enclave,omitempty"`
+	// HWIPath is the HWI-compatible command used to talk to hardware wallets. If omitted,
+	// hwi is looked up in PATH.
+	HWIPath string `json:"hwi_path,omitempty"`
+}
+
+// DefaultFilterHeaderQuorum is the number of peers that confirm filter headers when the
+// config does not say.
+const DefaultFilterHeaderQuorum = 2
+
+// HeaderQuorum returns the number of peers that must confirm our filter headers, 0 when
+// the cross-peer check is disabled.
+func (c *Config) HeaderQuorum() int {
+	quorum := DefaultFilterHeaderQuorum
+	if c.FilterHeaderQuorum != nil {
+		quorum = max(*c.FilterHeaderQuorum, 0)
+	}
+	if c.ConnectOnly && quorum > 0 {
+		// No other peer can ever confirm the headers.
+		quorum = max(min(quorum, len(c.PeerAddresses())), 1)
+	}
+	return quorum
+}
+
+// PeerAddresses returns the configured peers to connect to: the onion peers are only
+// reachable through the proxy.
+func (c *Config) PeerAddresses() []string {
+	if c.Proxy == "" {
+		return c.Peers
+	}
+	return append(append([]string{}, c.Peers...), c.OnionPeers...)
+}
+
+// SessionTimeout returns how long a key cached by `sat unlock` stays valid.
+func (c *Config) SessionTimeout() time.Duration {
+	if c.SessionKeyringMinutes <= 0 {
+		return 15 * time.Minute
+	}
+	return time.Duration(c.SessionKeyringMinutes) * time.Minute
+}
+
+// IdleLockTimeout returns how long the app may stay idle before locking the wallet.
+// Zero means auto-lock is disabled.
+func (c *Config) IdleLockTimeout() time.Duration {
+	switch {
+	case c.IdleLockMinutes < 0:
+		return 0
+	case c.IdleLockMinutes == 0:
+		return 5 * time.Minute
+	}
+	return time.Duration(c.IdleLockMinutes) * time.Minute
+}
+
+// LockParams returns the argon2id parameters for new wallet locks. argon2 rejects a
+// zero cost, so zero fields take the default value.
+func (c *Config) LockParams() wallet.KDFParams {
+	params := wallet.DefaultKDFParams
+	if c.UnlockKDF == nil {
+		return params
+	}
+	if c.UnlockKDF.Time > 0 {
+		params.Time = c.UnlockKDF.Time
+	}
+	if c.UnlockKDF.MemoryKiB > 0 {
+		params.MemoryKiB = c.UnlockKDF.MemoryKiB
+	}
+	if c.UnlockKDF.Threads > 0 {
+		params.Threads = c.UnlockKDF.Threads
+	}
+	return params
+}
+
+// AddPeer appends addr to the configured peers unless it is already there.
+func (c *Config) AddPeer(addr string) bool {
+	for _, p := range c.Peers {
+		if p == addr {
+			return false
+		}
+	}
+	c.Peers = append(c.Peers, addr)
+	return true
+}
+
+// RemovePeer removes addr from the configured peers.
+func (c *Config) RemovePeer(addr string) bool {
+	for i, p := range c.Peers {
+		if p == addr {
+			c.Peers = append(c.Peers[:i], c.Peers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// ChainParams returns the chain parameters of the configured network.
+func (c *Config) ChainParams() (*chaincfg.Params, error) {
+	switch c.Network {
+	case "", "mainnet":
+		return &chaincfg.MainNetParams, nil
+	case "testnet":
+		return &chaincfg.TestNet3Params, nil
+	case "signet":
+		return &chaincfg.SigNetParams, nil
+	case "regtest":
+		return &chaincfg.RegressionNetParams, nil
+	}
+	return nil, fmt.Errorf("unknown network %q", c.Network)
+}
+
+// ChainDataDir returns the directory where chain data of the configured network is stored.
+func (c *Config) ChainDataDir() string {
+	base := c.DataDir
+	if base == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			homeDir = "."
+		}
+		base = filepath.Join(homeDir, ".satellion")
+	}
+	network := c.Network
+	if network == "" {
+		network = "mainnet"
+	}
+	return filepath.Join(base, "neutrino", network)
 }
 
 func getStoragePath() string {
@@ -26,6 +198,7 @@ func getStoragePath() string {
 }
 
 func defaultConfig() *Config {
+	quorum := DefaultFilterHeaderQuorum
 	return &Config{
 		Peers: []string{
 			"seed.bitcoin.sipa.be:8333",
@@ -34,6 +207,7 @@ func defaultConfig() *Config {
 		},
 		MinPeers:           3,
 		SyncTimeoutMinutes: 30,
+		FilterHeaderQuorum: &quorum,
 	}
 }
 
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/satelliondao/satellion/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHeaderQuorum(t *testing.T) {
+	for _, tc := range []struct {
+		json   string
+		quorum int
+	}{
+		{`{}`, DefaultFilterHeaderQuorum},
+		{`{"filter_header_quorum": 3}`, 3},
+		{`{"filter_header_quorum": 0}`, 0},
+		{`{"filter_header_quorum": -1}`, 0},
+		{`{"connect_only": true, "peers": ["10.0.0.2:8333"]}`, 1},
+		{`{"connect_only": true, "peers": ["10.0.0.2:8333", "10.0.0.3:8333", "10.0.0.4:8333"]}`, 2},
+		{`{"connect_only": true, "filter_header_quorum": 0, "peers": ["10.0.0.2:8333"]}`, 0},
+		{`{"connect_only": true, "peers": ["10.0.0.2:8333"], "proxy": "127.0.0.1:9050", "onion_peers": ["x.onion:8333"]}`, 2},
+		{`{"peers": ["10.0.0.2:8333"]}`, DefaultFilterHeaderQuorum},
+	} {
+		var c Config
+		require.NoError(t, json.Unmarshal([]byte(tc.json), &c))
+		assert.Equal(t, tc.quorum, c.HeaderQuorum(), tc.json)
+	}
+	assert.Equal(t, DefaultFilterHeaderQuorum, defaultConfig().HeaderQuorum())
+}
+
+func TestLockParams(t *testing.T) {
+	for _, tc := range []struct {
+		json   string
+		params wallet.KDFParams
+	}{
+		{`{}`, wallet.DefaultKDFParams},
+		{`{"unlock_kdf": {}}`, wallet.DefaultKDFParams},
+		{`{"unlock_kdf": {"time": 1}}`, wallet.KDFParams{Time: 1, MemoryKiB: 64 * 1024, Threads: 4}},
+		{`{"unlock_kdf": {"time": 2, "memory_kib": 1024, "threads": 1}}`, wallet.KDFParams{Time: 2, MemoryKiB: 1024, Threads: 1}},
+	} {
+		var c Config
+		require.NoError(t, json.Unmarshal([]byte(tc.json), &c))
+		assert.Equal(t, tc.params, c.LockParams(), tc.json)
+	}
+}
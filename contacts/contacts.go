@@ -0,0 +1,142 @@
+// Package contacts describes saved payees and spots addresses that only look like one of
+// them, the signature of clipboard hijacking malware.
+package contacts
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/satelliondao/satellion/wallet"
+)
+
+// ErrInvalidContact is returned for contacts without a name, or without exactly one of
+// an address and a descriptor.
+var ErrInvalidContact = errors.New("invalid contact")
+
+// Contact is a saved payee. It is paid either at a fixed Address or at fresh addresses
+// derived from a Descriptor, the next one being at NextIndex.
+type Contact struct {
+	Name       string    `json:"name"`
+	Address    string    `json:"address,omitempty"`
+	Descriptor string    `json:"descriptor,omitempty"`
+	Notes      string    `json:"notes,omitempty"`
+	NextIndex  uint32    `json:"next_index,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Validate checks c for the network of params and writes its address in canonical form,
+// as upper-case addresses from QR codes are valid too. Descriptors are BIP86 taproot
+// descriptors, as written by wallet.Descriptor.
+func (c *Contact) Validate(params *chaincfg.Params) error {
+	if strings.TrimSpace(c.Name) == "" {
+		return fmt.Errorf("%w: name is empty", ErrInvalidContact)
+	}
+	switch {
+	case c.Address != "" && c.Descriptor != "":
+		return fmt.Errorf("%w: give an address or a descriptor, not both", ErrInvalidContact)
+	case c.Address != "":
+		addr, err := btcutil.DecodeAddress(c.Address, params)
+		if err != nil || !addr.IsForNet(params) {
+			return fmt.Errorf("%w: %q is not a %s address", ErrInvalidContact, c.Address, params.Name)
+		}
+		c.Address = addr.EncodeAddress()
+	case c.Descriptor != "":
+		if _, err := wallet.ParseDescriptor(c.Descriptor, params); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidContact, err)
+		}
+	default:
+		return fmt.Errorf("%w: address or descriptor is missing", ErrInvalidContact)
+	}
+	return nil
+}
+
+// PaymentAddress returns the address to pay c at: its address, or the address of its
+// descriptor at NextIndex on the network of params.
+func (c *Contact) PaymentAddress(params *chaincfg.Params) (string, error) {
+	if c.Descriptor == "" {
+		return c.Address, nil
+	}
+	d, err := wallet.ParseDescriptor(c.Descriptor, params)
+	if err != nil {
+		return "", err
+	}
+	addr, err := d.Address(c.NextIndex)
+	if err != nil {
+		return "", err
+	}
+	return addr.Address.String(), nil
+}
+
+// Lookalike returns the contact whose address address resembles without being equal
+// to it, or nil. Clipboard hijackers swap a copied address for one of theirs with the
+// same first and last characters, which is all most people check. Contacts paid through
+// a descriptor have no fixed address to compare with.
+func Lookalike(address string, list []Contact) *Contact {
+	a := strings.ToLower(strings.TrimSpace(address))
+	for i := range list {
+		b := strings.ToLower(list[i].Address)
+		if b == "" || a == b {
+			continue
+		}
+		if similar(a, b) {
+			return &list[i]
+		}
+	}
+	return nil
+}
+
+// Find returns the contact paid at address, or nil.
+func Find(address string, list []Contact) *Contact {
+	for i := range list {
+		if list[i].Address != "" && strings.EqualFold(list[i].Address, strings.TrimSpace(address)) {
+			return &list[i]
+		}
+	}
+	return nil
+}
+
+// maxEdits is the edit distance up to which two addresses are taken for one another.
+const maxEdits = 4
+
+// vanityPrefix and vanitySuffix are how many leading and trailing characters an attacker
+// can cheaply match with a vanity address generator. The prefix counts the "bc1q" or
+// "bc1p" every segwit address starts with.
+const (
+	vanityPrefix = 7
+	vanitySuffix = 4
+)
+
+// similar reports whether a and b differ by a few edits or share the start and end
+// a vanity generator can match.
+func similar(a, b string) bool {
+	if len(a) >= vanityPrefix+vanitySuffix && len(b) >= vanityPrefix+vanitySuffix &&
+		a[:vanityPrefix] == b[:vanityPrefix] && a[len(a)-vanitySuffix:] == b[len(b)-vanitySuffix:] {
+		return true
+	}
+	return distance(a, b) <= maxEdits
+}
+
+// distance returns the Levenshtein distance between a and b.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,81 @@
+package contacts
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	alice = "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"
+	// descriptor is the BIP86 test vector account, whose first receive address is
+	// bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr.
+	descriptor = "tr([73c5da0a/86'/0'/0']xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ/0/*)"
+)
+
+func TestValidate(t *testing.T) {
+	valid := []Contact{
+		{Name: "alice", Address: alice},
+		{Name: "bob", Descriptor: descriptor},
+	}
+	for _, c := range valid {
+		assert.NoError(t, c.Validate(&chaincfg.MainNetParams), c.Name)
+	}
+	invalid := []Contact{
+		{Address: alice},
+		{Name: "  ", Address: alice},
+		{Name: "nothing"},
+		{Name: "both", Address: alice, Descriptor: descriptor},
+		{Name: "typo", Address: alice[:len(alice)-1] + "q"},
+		{Name: "testnet", Address: "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"},
+		{Name: "bad descriptor", Descriptor: "wpkh(xpub)"},
+	}
+	for _, c := range invalid {
+		assert.ErrorIs(t, c.Validate(&chaincfg.MainNetParams), ErrInvalidContact, c.Name)
+	}
+}
+
+func TestPaymentAddress(t *testing.T) {
+	c := Contact{Name: "bob", Descriptor: descriptor}
+	addr, err := c.PaymentAddress(&chaincfg.MainNetParams)
+	require.NoError(t, err)
+	assert.Equal(t, alice, addr)
+	c.NextIndex = 1
+	next, err := c.PaymentAddress(&chaincfg.MainNetParams)
+	require.NoError(t, err)
+	assert.Equal(t, "bc1p4qhjn9zdvkux4e44uhx8tc55attvtyu358kutcqkudyccelu0was9fqzwh", next)
+
+	fixed := Contact{Name: "alice", Address: alice}
+	addr, err = fixed.PaymentAddress(&chaincfg.MainNetParams)
+	require.NoError(t, err)
+	assert.Equal(t, alice, addr)
+}
+
+func TestLookalike(t *testing.T) {
+	list := []Contact{
+		{Name: "bob", Descriptor: descriptor},
+		{Name: "alice", Address: alice},
+	}
+	// Same first and last characters, as a vanity generator would produce.
+	vanity := alice[:7] + "zzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzzz" + alice[len(alice)-4:]
+	assert.Equal(t, "alice", Lookalike(vanity, list).Name)
+	// A couple of characters swapped.
+	swapped := alice[:20] + "qq" + alice[22:]
+	assert.Equal(t, "alice", Lookalike(swapped, list).Name)
+
+	assert.Nil(t, Lookalike(alice, list), "the contact's own address")
+	assert.Nil(t, Lookalike(" "+alice+" ", list))
+	assert.Nil(t, Lookalike("bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", list))
+
+	assert.Equal(t, "alice", Find(alice, list).Name)
+	assert.Nil(t, Find(swapped, list))
+}
+
+func TestDistance(t *testing.T) {
+	assert.Equal(t, 0, distance("abc", "abc"))
+	assert.Equal(t, 3, distance("kitten", "sitting"))
+	assert.Equal(t, 3, distance("", "abc"))
+	assert.Equal(t, 1, distance("abcd", "abd"))
+}
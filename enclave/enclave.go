@@ -0,0 +1,62 @@
+package enclave
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Enclave stores named secrets. Backends differ in where the secrets live and how they
+// are protected; see Open.
+type Enclave interface {
+	Save(key string, data []byte) error
+	// Load returns the data stored under key, or a *NotFoundError.
+	Load(key string) ([]byte, error)
+	// Delete removes key, returning a *NotFoundError when it is not stored.
+	Delete(key string) error
+	// List returns the stored keys in lexical order.
+	List() ([]string, error)
+	Exists(key string) bool
+}
+
+// Backends accepted by Open.
+const (
+	BackendFile    = "file"
+	BackendMemory  = "memory"
+	BackendKeyring = "keyring"
+)
+
+// Open returns the enclave backend named by backend. The file backend lives in dir and is
+// encrypted with a key derived from password; the other backends ignore both.
+func Open(backend, dir string, password []byte) (Enclave, error) {
+	switch backend {
+	case BackendFile:
+		return NewFileStore(dir, password)
+	case BackendMemory:
+		return NewMemoryStore(), nil
+	case BackendKeyring:
+		keyring, err := NewKernelKeyring("user")
+		if err != nil {
+			return nil, err
+		}
+		return NewKeyringStore(keyring), nil
+	}
+	return nil, fmt.Errorf("unknown enclave backend %q", backend)
+}
+
+// DefaultDir returns ~/.satellion/enclave, where file stores are kept.
+func DefaultDir() string {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		homeDir = "."
+	}
+	return filepath.Join(homeDir, ".satellion", "enclave")
+}
+
+type NotFoundError struct {
+	Key string
+}
+
+func (e *NotFoundError) Error() string {
+	return fmt.Sprintf("key '%s' not found in storage", e.Key)
+}
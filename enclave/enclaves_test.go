@@ -2,39 +2,48 @@ package enclave
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/satelliondao/satellion/wallet"
 )
 
-func createTestEnclave(key string) *Enclave {
+func init() {
+	// Keeps the tests fast; real stores use the configured unlock KDF.
+	wallet.LockParams = wallet.KDFParams{Time: 1, MemoryKiB: 1024, Threads: 1}
+}
+
+func createTestEnclave(key string) *FileStore {
 	tempDir, err := os.MkdirTemp("", "satellion-test-*")
 	if err != nil {
 		panic(err)
 	}
-	
-	enclave := &Enclave{
-		decryptionKey: key,
-		storagePath:   tempDir,
+	enclave, err := NewFileStore(tempDir, []byte(key))
+	if err != nil {
+		panic(err)
 	}
-	os.MkdirAll(tempDir, 0700)
 	return enclave
 }
 
-func cleanupTestEnclave(enclave *Enclave) {
-	os.RemoveAll(enclave.storagePath)
+func cleanupTestEnclave(enclave *FileStore) {
+	os.RemoveAll(enclave.dir)
 }
 
 func TestNewEnclave(t *testing.T) {
 	enclave := createTestEnclave("test-key-123")
 	defer cleanupTestEnclave(enclave)
 	
-	// Check if storage directory was created
-	if _, err := os.Stat(enclave.storagePath); os.IsNotExist(err) {
-		t.Errorf("Storage directory was not created: %s", enclave.storagePath)
+	// Check if storage directory and index were created
+	if _, err := os.Stat(filepath.Join(enclave.dir, indexFile)); os.IsNotExist(err) {
+		t.Errorf("Index was not created in %s", enclave.dir)
 	}
 	
-	// Check if decryption key was set
-	if enclave.decryptionKey != "test-key-123" {
-		t.Errorf("Expected decryption key 'test-key-123', got '%s'", enclave.decryptionKey)
+	// Check that the store reopens with its password only
+	if _, err := NewFileStore(enclave.dir, []byte("test-key-123")); err != nil {
+		t.Errorf("Failed to reopen store: %v", err)
+	}
+	if _, err := NewFileStore(enclave.dir, []byte("wrong")); err != ErrWrongPassword {
+		t.Errorf("Expected ErrWrongPassword, got: %v", err)
 	}
 }
 
@@ -44,7 +53,7 @@ func TestEncryptDecrypt(t *testing.T) {
 	
 	data := []byte("This is a test message that should be encrypted and decrypted")
 	
-	encryptedData, err := enclave.encrypt(data)
+	encryptedData, err := enclave.encrypt(data, nil)
 	if err != nil {
 		t.Fatalf("Failed to encrypt data: %v", err)
 	}
@@ -53,7 +62,7 @@ func TestEncryptDecrypt(t *testing.T) {
 		t.Error("Encrypted data should be different from original data")
 	}
 	
-	decryptedData, err := enclave.decrypt(encryptedData)
+	decryptedData, err := enclave.decrypt(encryptedData, nil)
 	if err != nil {
 		t.Fatalf("Failed to decrypt data: %v", err)
 	}
@@ -182,8 +191,8 @@ func TestList(t *testing.T) {
 		t.Fatalf("Failed to list keys after saving: %v", err)
 	}
 	
-	if len(keys) != 2 {
-		t.Errorf("Expected 2 keys, got %d", len(keys))
+	if len(keys) != 2 || keys[0] != "key1" || keys[1] != "key2" {
+		t.Errorf("Expected keys [key1 key2], got %v", keys)
 	}
 }
 
@@ -219,19 +228,19 @@ func TestEncryptDecryptWithDifferentKeys(t *testing.T) {
 	originalData := []byte("Test data for different keys")
 	
 	// Encrypt with key 1
-	encryptedData, err := enclave1.encrypt(originalData)
+	encryptedData, err := enclave1.encrypt(originalData, nil)
 	if err != nil {
 		t.Fatalf("Failed to encrypt with key 1: %v", err)
 	}
 	
 	// Try to decrypt with key 2 (should fail)
-	_, err = enclave2.decrypt(encryptedData)
+	_, err = enclave2.decrypt(encryptedData, nil)
 	if err == nil {
 		t.Error("Expected error when decrypting with wrong key")
 	}
 	
 	// Decrypt with correct key 1
-	decryptedData, err := enclave1.decrypt(encryptedData)
+	decryptedData, err := enclave1.decrypt(encryptedData, nil)
 	if err != nil {
 		t.Fatalf("Failed to decrypt with correct key: %v", err)
 	}
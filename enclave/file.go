@@ -0,0 +1,303 @@
+package enclave
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/satelliondao/satellion/secret"
+	"github.com/satelliondao/satellion/wallet"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+// ErrWrongPassword is returned when the index of a file store cannot be decrypted.
+var ErrWrongPassword = errors.New("wrong enclave password or corrupted index")
+
+// ErrStoreMissing is returned when an existing file store is opened in a directory that
+// has none.
+var ErrStoreMissing = errors.New("enclave store is missing")
+
+const indexFile = "index.json"
+
+// indexAD binds the index ciphertext to its purpose, so a data file cannot be swapped in.
+var indexAD = []byte("satellion enclave index")
+
+// indexHeader is stored in clear next to the encrypted index of key names.
+type indexHeader struct {
+	KDF        wallet.KDFParams `json:"kdf"`
+	Salt       []byte           `json:"salt"`
+	Nonce      []byte           `json:"nonce"`
+	Ciphertext []byte           `json:"ciphertext"`
+}
+
+// FileStore keeps one encrypted file per key in a directory. The key names are kept in
+// an encrypted, authenticated index, and every file is authenticated with its name, so
+// files cannot be renamed or swapped without the password. The encryption key is derived
+// from the password with argon2id.
+type FileStore struct {
+	dir   string
+	key   []byte
+	index map[string]string
+	kdf   wallet.KDFParams
+	salt  []byte
+}
+
+// NewFileStore opens the store in dir, creating it when it has no index yet.
+func NewFileStore(dir string, password []byte) (*FileStore, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	_, err := os.Stat(filepath.Join(dir, indexFile))
+	if err == nil {
+		return UnlockFileStore(dir, password)
+	}
+	if !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("failed to read enclave index: %w", err)
+	}
+	f := &FileStore{dir: dir, index: map[string]string{}, kdf: wallet.LockParams}
+	f.salt = make([]byte, 16)
+	if _, err := rand.Read(f.salt); err != nil {
+		return nil, err
+	}
+	f.key = deriveKey(password, f.kdf, f.salt)
+	return f, f.writeIndex()
+}
+
+// UnlockFileStore opens the existing store in dir with password. Unlike NewFileStore, it
+// never creates one: it returns ErrStoreMissing when dir has no store.
+func UnlockFileStore(dir string, password []byte) (*FileStore, error) {
+	h, err := readIndexHeader(dir)
+	if err != nil {
+		return nil, err
+	}
+	f := &FileStore{dir: dir, index: map[string]string{}, kdf: h.KDF, salt: h.Salt}
+	f.key = deriveKey(password, f.kdf, f.salt)
+	if err := f.readIndex(h); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// OpenFileStore opens the existing store in dir with the key returned by Key, without
+// the password.
+func OpenFileStore(dir string, key []byte) (*FileStore, error) {
+	h, err := readIndexHeader(dir)
+	if err != nil {
+		return nil, err
+	}
+	f := &FileStore{dir: dir, index: map[string]string{}, kdf: h.KDF, salt: h.Salt}
+	f.key = append([]byte(nil), key...)
+	if err := f.readIndex(h); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Key returns a copy of the key the store is encrypted with. It opens the store like
+// the password does: wipe it once it is no longer needed.
+func (f *FileStore) Key() []byte {
+	return append([]byte(nil), f.key...)
+}
+
+// readIndexHeader reads the clear header of the index of the store in dir.
+func readIndexHeader(dir string) (*indexHeader, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, indexFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf("%w: %s", ErrStoreMissing, dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read enclave index: %w", err)
+	}
+	return parseIndex(raw)
+}
+
+func parseIndex(raw []byte) (*indexHeader, error) {
+	var h indexHeader
+	if err := json.Unmarshal(raw, &h); err != nil {
+		return nil, fmt.Errorf("invalid enclave index: %w", err)
+	}
+	if h.KDF.Time == 0 || h.KDF.MemoryKiB == 0 || h.KDF.Threads == 0 || len(h.Salt) == 0 {
+		return nil, fmt.Errorf("invalid enclave index parameters")
+	}
+	return &h, nil
+}
+
+// readIndex decrypts the key names of the store with its key.
+func (f *FileStore) readIndex(h *indexHeader) error {
+	plaintext, err := f.decrypt(append(h.Nonce, h.Ciphertext...), indexAD)
+	if err != nil {
+		return ErrWrongPassword
+	}
+	defer secret.Wipe(plaintext)
+	if err := json.Unmarshal(plaintext, &f.index); err != nil {
+		return fmt.Errorf("invalid enclave index: %w", err)
+	}
+	return nil
+}
+
+func deriveKey(password []byte, kdf wallet.KDFParams, salt []byte) []byte {
+	return argon2.IDKey(password, salt, kdf.Time, kdf.MemoryKiB, kdf.Threads, chacha20poly1305.KeySize)
+}
+
+// encrypt returns the nonce followed by the ciphertext of data, authenticated with ad.
+func (f *FileStore) encrypt(data, ad []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(f.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	nonce := make([]byte, aead.NonceSize(), aead.NonceSize()+len(data)+aead.Overhead())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, data, ad), nil
+}
+
+func (f *FileStore) decrypt(encryptedData, ad []byte) ([]byte, error) {
+	aead, err := chacha20poly1305.NewX(f.key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	if len(encryptedData) < aead.NonceSize() {
+		return nil, fmt.Errorf("encrypted data too short")
+	}
+	nonce, ciphertext := encryptedData[:aead.NonceSize()], encryptedData[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, ad)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+func (f *FileStore) writeIndex() error {
+	plaintext, err := json.Marshal(f.index)
+	if err != nil {
+		return err
+	}
+	defer secret.Wipe(plaintext)
+	sealed, err := f.encrypt(plaintext, indexAD)
+	if err != nil {
+		return err
+	}
+	n := chacha20poly1305.NonceSizeX
+	raw, err := json.Marshal(indexHeader{KDF: f.kdf, Salt: f.salt, Nonce: sealed[:n], Ciphertext: sealed[n:]})
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(f.dir, indexFile), raw)
+}
+
+func (f *FileStore) Save(key string, data []byte) error {
+	encryptedData, err := f.encrypt(data, []byte(key))
+	if err != nil {
+		return fmt.Errorf("failed to encrypt data: %w", err)
+	}
+	id, ok := f.index[key]
+	if !ok {
+		b := make([]byte, 16)
+		if _, err := rand.Read(b); err != nil {
+			return err
+		}
+		id = hex.EncodeToString(b)
+	}
+	if err := writeFileAtomic(f.filePath(id), encryptedData); err != nil {
+		return fmt.Errorf("failed to write encrypted file: %w", err)
+	}
+	if ok {
+		return nil
+	}
+	f.index[key] = id
+	if err := f.writeIndex(); err != nil {
+		delete(f.index, key)
+		os.Remove(f.filePath(id))
+		return err
+	}
+	return nil
+}
+
+func (f *FileStore) Load(key string) ([]byte, error) {
+	id, ok := f.index[key]
+	if !ok {
+		return nil, &NotFoundError{Key: key}
+	}
+	encryptedData, err := os.ReadFile(f.filePath(id))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, &NotFoundError{Key: key}
+		}
+		return nil, fmt.Errorf("failed to read encrypted file: %w", err)
+	}
+	decryptedData, err := f.decrypt(encryptedData, []byte(key))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt data: %w", err)
+	}
+	return decryptedData, nil
+}
+
+func (f *FileStore) Delete(key string) error {
+	id, ok := f.index[key]
+	if !ok {
+		return &NotFoundError{Key: key}
+	}
+	delete(f.index, key)
+	if err := f.writeIndex(); err != nil {
+		f.index[key] = id
+		return err
+	}
+	if err := os.Remove(f.filePath(id)); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to delete encrypted file: %w", err)
+	}
+	return nil
+}
+
+func (f *FileStore) List() ([]string, error) {
+	keys := make([]string, 0, len(f.index))
+	for k := range f.index {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (f *FileStore) Exists(key string) bool {
+	_, ok := f.index[key]
+	return ok
+}
+
+func (f *FileStore) Dir() string {
+	return f.dir
+}
+
+func (f *FileStore) filePath(id string) string {
+	return filepath.Join(f.dir, id+".enc")
+}
+
+// writeFileAtomic replaces path with data, so that a crash leaves either version.
+func writeFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".tmp-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := tmp.Chmod(0o600); err != nil {
+		tmp.Close()
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,125 @@
+package enclave
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+// Key permissions: everything for the possessor, and for the user keyring also view, read
+// and search for other processes of the same user, so that other sessions find the key.
+const (
+	possessorAll = 0x3f000000
+	userRead     = 0x000b0000
+)
+
+// KernelKeyring caches secrets in the Linux kernel keyring. Keys never touch the disk and
+// are discarded by the kernel when their timeout expires or the keyring goes away.
+type KernelKeyring struct {
+	ring int
+	perm int
+}
+
+// NewKernelKeyring uses the session keyring, shared by the processes of a login session,
+// or the user keyring, shared by every session of the user.
+func NewKernelKeyring(scope string) (*KernelKeyring, error) {
+	switch scope {
+	case "session":
+		return &KernelKeyring{ring: unix.KEY_SPEC_SESSION_KEYRING, perm: possessorAll}, nil
+	case "user":
+		return &KernelKeyring{ring: unix.KEY_SPEC_USER_KEYRING, perm: possessorAll | userRead}, nil
+	}
+	return nil, fmt.Errorf("unknown keyring %q: use session or user", scope)
+}
+
+func (k *KernelKeyring) Put(name string, secret []byte, ttl time.Duration) error {
+	id, err := unix.AddKey("user", description(name), secret, k.ring)
+	if err != nil {
+		return fmt.Errorf("failed to add key: %w", err)
+	}
+	if _, err := unix.KeyctlInt(unix.KEYCTL_SETPERM, id, k.perm, 0, 0); err != nil {
+		k.revoke(id)
+		return fmt.Errorf("failed to restrict key: %w", err)
+	}
+	// A zero timeout also clears the expiry of a key being replaced.
+	timeout := 0
+	if ttl > 0 {
+		timeout = timeoutSeconds(ttl)
+	}
+	if _, err := unix.KeyctlInt(unix.KEYCTL_SET_TIMEOUT, id, timeout, 0, 0); err != nil {
+		k.revoke(id)
+		return fmt.Errorf("failed to set key timeout: %w", err)
+	}
+	return nil
+}
+
+func (k *KernelKeyring) Get(name string) ([]byte, error) {
+	id, err := k.search(name)
+	if err != nil {
+		return nil, err
+	}
+	size, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, nil, 0)
+	if err != nil {
+		return nil, k.readErr(name, err)
+	}
+	buf := make([]byte, size)
+	n, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, buf, 0)
+	if err != nil {
+		return nil, k.readErr(name, err)
+	}
+	return buf[:n], nil
+}
+
+func (k *KernelKeyring) Forget(name string) error {
+	id, err := k.search(name)
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if _, err := unix.KeyctlInt(unix.KEYCTL_INVALIDATE, id, 0, 0, 0); err != nil {
+		return k.revoke(id)
+	}
+	return nil
+}
+
+func (k *KernelKeyring) search(name string) (int, error) {
+	id, err := unix.KeyctlSearch(k.ring, "user", description(name), 0)
+	if err != nil {
+		return 0, k.readErr(name, err)
+	}
+	return id, nil
+}
+
+func (k *KernelKeyring) readErr(name string, err error) error {
+	if errors.Is(err, unix.ENOKEY) || errors.Is(err, unix.EKEYEXPIRED) || errors.Is(err, unix.EKEYREVOKED) {
+		return &NotFoundError{Key: name}
+	}
+	return fmt.Errorf("failed to read key: %w", err)
+}
+
+func (k *KernelKeyring) revoke(id int) error {
+	_, err := unix.KeyctlInt(unix.KEYCTL_REVOKE, id, 0, 0, 0)
+	return err
+}
+
+func description(name string) string {
+	return "satellion:" + name
+}
+
+// timeoutSeconds rounds ttl up, as a zero timeout would keep the key forever.
+func timeoutSeconds(ttl time.Duration) int {
+	s := math.Ceil(ttl.Seconds())
+	if s < 1 {
+		return 1
+	}
+	if s > math.MaxInt32 {
+		return math.MaxInt32
+	}
+	return int(s)
+}
@@ -0,0 +1,50 @@
+package enclave
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+)
+
+var _ SessionCache = (*KernelKeyring)(nil)
+
+func TestKernelKeyring(t *testing.T) {
+	k, err := NewKernelKeyring("session")
+	require.NoError(t, err)
+	name := fmt.Sprintf("test-%d", time.Now().UnixNano())
+	if err := k.Put(name, []byte("secret"), time.Minute); errors.Is(err, unix.ENOSYS) || errors.Is(err, unix.EPERM) {
+		t.Skipf("kernel keyring not available: %v", err)
+	} else {
+		require.NoError(t, err)
+	}
+	t.Cleanup(func() { k.Forget(name) })
+
+	got, err := k.Get(name)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("secret"), got)
+
+	require.NoError(t, k.Put(name, []byte("replaced"), time.Minute))
+	got, err = k.Get(name)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("replaced"), got)
+
+	require.NoError(t, k.Forget(name))
+	_, err = k.Get(name)
+	var notFound *NotFoundError
+	assert.ErrorAs(t, err, &notFound)
+	assert.NoError(t, k.Forget(name))
+
+	_, err = NewKernelKeyring("system")
+	assert.Error(t, err)
+}
+
+func TestTimeoutSeconds(t *testing.T) {
+	assert.Equal(t, 1, timeoutSeconds(0))
+	assert.Equal(t, 2, timeoutSeconds(1500*time.Millisecond))
+	assert.Equal(t, 900, timeoutSeconds(15*time.Minute))
+}
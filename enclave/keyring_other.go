@@ -0,0 +1,27 @@
+//go:build !linux
+
+package enclave
+
+import (
+	"fmt"
+	"time"
+)
+
+// KernelKeyring is only available on Linux.
+type KernelKeyring struct{}
+
+func NewKernelKeyring(scope string) (*KernelKeyring, error) {
+	return nil, fmt.Errorf("kernel keyring: %w", ErrUnsupported)
+}
+
+func (k *KernelKeyring) Put(name string, secret []byte, ttl time.Duration) error {
+	return ErrUnsupported
+}
+
+func (k *KernelKeyring) Get(name string) ([]byte, error) {
+	return nil, ErrUnsupported
+}
+
+func (k *KernelKeyring) Forget(name string) error {
+	return ErrUnsupported
+}
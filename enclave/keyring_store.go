@@ -0,0 +1,96 @@
+package enclave
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+)
+
+// keyringIndex is the keyring entry listing the keys saved by a KeyringStore, as the
+// kernel cannot enumerate them by prefix.
+const keyringIndex = "enclave-index"
+
+// KeyringStore keeps secrets in an OS keyring without expiry. The Linux kernel keyring
+// is cleared when the user logs out of every session, so only cache data there that can
+// be recovered. The kernel accepts values of 1 to 32767 bytes.
+type KeyringStore struct {
+	keyring SessionCache
+}
+
+func NewKeyringStore(keyring SessionCache) *KeyringStore {
+	return &KeyringStore{keyring: keyring}
+}
+
+func (k *KeyringStore) Save(key string, data []byte) error {
+	if err := k.keyring.Put(keyringKey(key), data, 0); err != nil {
+		return err
+	}
+	keys, err := k.List()
+	if err != nil {
+		return err
+	}
+	i := sort.SearchStrings(keys, key)
+	if i < len(keys) && keys[i] == key {
+		return nil
+	}
+	keys = append(keys[:i], append([]string{key}, keys[i:]...)...)
+	return k.saveIndex(keys)
+}
+
+func (k *KeyringStore) Load(key string) ([]byte, error) {
+	data, err := k.keyring.Get(keyringKey(key))
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, &NotFoundError{Key: key}
+	}
+	return data, err
+}
+
+func (k *KeyringStore) Delete(key string) error {
+	keys, err := k.List()
+	if err != nil {
+		return err
+	}
+	i := sort.SearchStrings(keys, key)
+	if i == len(keys) || keys[i] != key {
+		return &NotFoundError{Key: key}
+	}
+	if err := k.keyring.Forget(keyringKey(key)); err != nil {
+		return err
+	}
+	return k.saveIndex(append(keys[:i], keys[i+1:]...))
+}
+
+func (k *KeyringStore) List() ([]string, error) {
+	raw, err := k.keyring.Get(keyringIndex)
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var keys []string
+	if err := json.Unmarshal(raw, &keys); err != nil {
+		return nil, err
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (k *KeyringStore) Exists(key string) bool {
+	_, err := k.Load(key)
+	return err == nil
+}
+
+func (k *KeyringStore) saveIndex(keys []string) error {
+	raw, err := json.Marshal(keys)
+	if err != nil {
+		return err
+	}
+	return k.keyring.Put(keyringIndex, raw, 0)
+}
+
+func keyringKey(key string) string {
+	return "enclave/" + key
+}
@@ -0,0 +1,61 @@
+package enclave
+
+import (
+	"sort"
+	"sync"
+)
+
+// MemoryStore keeps secrets in process memory. It is meant for tests.
+type MemoryStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{data: map[string][]byte{}}
+}
+
+func (m *MemoryStore) Save(key string, data []byte) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.data[key] = append([]byte{}, data...)
+	return nil
+}
+
+func (m *MemoryStore) Load(key string) ([]byte, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.data[key]
+	if !ok {
+		return nil, &NotFoundError{Key: key}
+	}
+	return append([]byte{}, data...), nil
+}
+
+func (m *MemoryStore) Delete(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.data[key]; !ok {
+		return &NotFoundError{Key: key}
+	}
+	delete(m.data, key)
+	return nil
+}
+
+func (m *MemoryStore) List() ([]string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keys := make([]string, 0, len(m.data))
+	for k := range m.data {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys, nil
+}
+
+func (m *MemoryStore) Exists(key string) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	_, ok := m.data[key]
+	return ok
+}
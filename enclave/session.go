@@ -0,0 +1,20 @@
+package enclave
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrUnsupported is returned when a backend is not available on this platform.
+var ErrUnsupported = errors.New("not supported on this platform")
+
+// SessionCache keeps a secret outside the process for a limited time, so that later
+// invocations of the CLI can reuse it without prompting again.
+type SessionCache interface {
+	// Put stores secret under name until ttl elapses, or indefinitely when ttl is zero.
+	Put(name string, secret []byte, ttl time.Duration) error
+	// Get returns the secret stored under name, or a *NotFoundError once it expired.
+	Get(name string) ([]byte, error)
+	// Forget removes the secret stored under name. Missing secrets are not an error.
+	Forget(name string) error
+}
@@ -0,0 +1,120 @@
+package enclave
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testBackend checks the behaviour every Enclave backend must share.
+func testBackend(t *testing.T, e Enclave) {
+	keys, err := e.List()
+	require.NoError(t, err)
+	assert.Empty(t, keys)
+
+	require.NoError(t, e.Save("b", []byte("two")))
+	require.NoError(t, e.Save("a", []byte("one")))
+	require.NoError(t, e.Save("b", []byte("two, again")))
+	keys, err = e.List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, keys)
+	got, err := e.Load("b")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("two, again"), got)
+	assert.True(t, e.Exists("a"))
+
+	require.NoError(t, e.Delete("a"))
+	assert.False(t, e.Exists("a"))
+	var notFound *NotFoundError
+	_, err = e.Load("a")
+	assert.ErrorAs(t, err, &notFound)
+	assert.ErrorAs(t, e.Delete("a"), &notFound)
+	keys, err = e.List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, keys)
+}
+
+func TestMemoryStore(t *testing.T) {
+	testBackend(t, NewMemoryStore())
+}
+
+func TestFileStore(t *testing.T) {
+	dir := t.TempDir()
+	f, err := NewFileStore(dir, []byte("password"))
+	require.NoError(t, err)
+	testBackend(t, f)
+
+	reopened, err := NewFileStore(dir, []byte("password"))
+	require.NoError(t, err)
+	keys, err := reopened.List()
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, keys, "key names are recovered from the index")
+
+	_, err = UnlockFileStore(dir, []byte("wrong"))
+	assert.ErrorIs(t, err, ErrWrongPassword)
+	missing := filepath.Join(dir, "missing")
+	_, err = UnlockFileStore(missing, []byte("password"))
+	assert.ErrorIs(t, err, ErrStoreMissing)
+	assert.NoDirExists(t, missing)
+}
+
+func TestFileStore_DetectsSwappedFiles(t *testing.T) {
+	f, err := NewFileStore(t.TempDir(), []byte("password"))
+	require.NoError(t, err)
+	require.NoError(t, f.Save("a", []byte("one")))
+	require.NoError(t, f.Save("b", []byte("two")))
+
+	a, b := f.filePath(f.index["a"]), f.filePath(f.index["b"])
+	tmp := filepath.Join(f.dir, "swap")
+	require.NoError(t, os.Rename(a, tmp))
+	require.NoError(t, os.Rename(b, a))
+	require.NoError(t, os.Rename(tmp, b))
+	_, err = f.Load("a")
+	assert.Error(t, err)
+}
+
+func TestKeyringStore(t *testing.T) {
+	keyring, err := NewKernelKeyring("session")
+	if err != nil {
+		t.Skip(err)
+	}
+	// Prefixes the names so that the test does not touch real entries.
+	prefixed := &prefixCache{SessionCache: keyring, prefix: fmt.Sprintf("test-%d/", time.Now().UnixNano())}
+	if err := prefixed.Put("probe", []byte("x"), time.Minute); err != nil {
+		t.Skipf("kernel keyring not available: %v", err)
+	}
+	t.Cleanup(func() {
+		prefixed.Forget("probe")
+		prefixed.Forget(keyringIndex)
+		prefixed.Forget(keyringKey("b"))
+	})
+	testBackend(t, NewKeyringStore(prefixed))
+}
+
+type prefixCache struct {
+	SessionCache
+	prefix string
+}
+
+func (p *prefixCache) Put(name string, secret []byte, ttl time.Duration) error {
+	return p.SessionCache.Put(p.prefix+name, secret, ttl)
+}
+
+func (p *prefixCache) Get(name string) ([]byte, error) {
+	data, err := p.SessionCache.Get(p.prefix + name)
+	var notFound *NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, &NotFoundError{Key: name}
+	}
+	return data, err
+}
+
+func (p *prefixCache) Forget(name string) error {
+	return p.SessionCache.Forget(p.prefix + name)
+}
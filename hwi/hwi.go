@@ -0,0 +1,214 @@
+// Package hwi drives hardware wallets through an HWI-compatible command line tool
+// (https://github.com/bitcoin-core/HWI). Private keys never leave the device: Satellion
+// keeps a watch-only wallet and hands PSBTs to the device for signing.
+package hwi
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/satelliondao/satellion/wallet"
+)
+
+// DefaultTimeout bounds commands that do not wait for the user, such as enumerate.
+const DefaultTimeout = 30 * time.Second
+
+// confirmTimeout bounds commands that wait for a confirmation on the device.
+const confirmTimeout = 5 * time.Minute
+
+// ErrAddressMismatch is returned when the device shows another address than the one
+// derived by Satellion.
+var ErrAddressMismatch = errors.New("address on the device does not match")
+
+// Client runs the HWI command at Path for the network of Params.
+type Client struct {
+	Path   string
+	Params *chaincfg.Params
+}
+
+// New returns a client for path, or "hwi" looked up in PATH when path is empty.
+func New(path string, params *chaincfg.Params) *Client {
+	if path == "" {
+		path = "hwi"
+	}
+	return &Client{Path: path, Params: params}
+}
+
+// Device is a hardware wallet reported by enumerate.
+type Device struct {
+	Type                string `json:"type"`
+	Model               string `json:"model"`
+	Path                string `json:"path"`
+	Fingerprint         string `json:"fingerprint"`
+	NeedsPinSent        bool   `json:"needs_pin_sent"`
+	NeedsPassphraseSent bool   `json:"needs_passphrase_sent"`
+	Error               string `json:"error,omitempty"`
+}
+
+// Error is an error reported by HWI.
+type Error struct {
+	Message string `json:"error"`
+	Code    int    `json:"code"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("hwi: %s (code %d)", e.Message, e.Code)
+}
+
+// Enumerate lists the connected devices.
+func (c *Client) Enumerate() ([]Device, error) {
+	var devices []Device
+	if err := c.run(DefaultTimeout, &devices, "enumerate"); err != nil {
+		return nil, err
+	}
+	return devices, nil
+}
+
+// Device returns the connected device with the given master fingerprint, or the only
+// connected device when fingerprint is empty.
+func (c *Client) Device(fingerprint string) (*Device, error) {
+	devices, err := c.Enumerate()
+	if err != nil {
+		return nil, err
+	}
+	var ready []Device
+	for _, d := range devices {
+		if d.Error != "" || d.Fingerprint == "" {
+			continue
+		}
+		if strings.EqualFold(d.Fingerprint, fingerprint) {
+			return &d, nil
+		}
+		ready = append(ready, d)
+	}
+	switch {
+	case fingerprint != "":
+		return nil, fmt.Errorf("no device with fingerprint %s is connected", fingerprint)
+	case len(ready) == 0:
+		return nil, fmt.Errorf("no unlocked hardware wallet found")
+	case len(ready) > 1:
+		return nil, fmt.Errorf("%d hardware wallets connected: choose one by fingerprint", len(ready))
+	}
+	return &ready[0], nil
+}
+
+// AccountXpub returns the BIP86 account key of the device.
+func (c *Client) AccountXpub(fingerprint string) (string, error) {
+	var out struct {
+		Xpub string `json:"xpub"`
+	}
+	if err := c.run(DefaultTimeout, &out, "-f", fingerprint, "getxpub", hwiPath(wallet.AccountPath)); err != nil {
+		return "", err
+	}
+	if out.Xpub == "" {
+		return "", fmt.Errorf("hwi returned no xpub")
+	}
+	return out.Xpub, nil
+}
+
+// WatchOnly creates the watch-only wallet of the device with the given fingerprint.
+func (c *Client) WatchOnly(fingerprint string) (*wallet.Wallet, error) {
+	xpub, err := c.AccountXpub(fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	return wallet.NewWatchOnly(xpub, strings.ToLower(fingerprint), c.Params)
+}
+
+// DisplayAddress shows the taproot address at change/index on the device and returns it.
+func (c *Client) DisplayAddress(fingerprint string, change, index uint32) (string, error) {
+	var out struct {
+		Address string `json:"address"`
+	}
+	path := hwiPath(fmt.Sprintf("%s/%d/%d", wallet.AccountPath, change, index))
+	if err := c.run(confirmTimeout, &out, "-f", fingerprint, "displayaddress", "--path", path, "--addr-type", "tap"); err != nil {
+		return "", err
+	}
+	return out.Address, nil
+}
+
+// VerifyAddress shows the address at change/index of w on its device and checks that
+// it matches the one derived from the account key.
+func (c *Client) VerifyAddress(w *wallet.Wallet, change, index uint32) (string, error) {
+	want, err := w.DeriveTaprootAddress(change, index)
+	if err != nil {
+		return "", err
+	}
+	shown, err := c.DisplayAddress(w.Fingerprint, change, index)
+	if err != nil {
+		return "", err
+	}
+	if shown != want.Address.String() {
+		return shown, fmt.Errorf("%w: device shows %s, expected %s", ErrAddressMismatch, shown, want.Address.String())
+	}
+	return shown, nil
+}
+
+// SignPSBT asks the device to sign a base64 encoded PSBT and returns the signed PSBT.
+func (c *Client) SignPSBT(fingerprint, psbt string) (string, error) {
+	var out struct {
+		PSBT   string `json:"psbt"`
+		Signed bool   `json:"signed"`
+	}
+	if err := c.run(confirmTimeout, &out, "-f", fingerprint, "signtx", psbt); err != nil {
+		return "", err
+	}
+	if !out.Signed {
+		return "", fmt.Errorf("the device did not sign any input")
+	}
+	return out.PSBT, nil
+}
+
+// run executes HWI with args and decodes its JSON output into out.
+func (c *Client) run(timeout time.Duration, out interface{}, args ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	cmd := exec.CommandContext(ctx, c.Path, append([]string{"--chain", Chain(c.Params)}, args...)...)
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	runErr := cmd.Run()
+	if ctx.Err() != nil {
+		return fmt.Errorf("hwi timed out after %s", timeout)
+	}
+	var hwiErr Error
+	if json.Unmarshal(stdout.Bytes(), &hwiErr) == nil && hwiErr.Message != "" {
+		return &hwiErr
+	}
+	if runErr != nil {
+		msg := strings.TrimSpace(stderr.String())
+		if msg == "" {
+			msg = runErr.Error()
+		}
+		return fmt.Errorf("hwi %s: %s", args[len(args)-1], msg)
+	}
+	if err := json.Unmarshal(stdout.Bytes(), out); err != nil {
+		return fmt.Errorf("unexpected hwi output: %w", err)
+	}
+	return nil
+}
+
+// Chain returns the HWI --chain value for params.
+func Chain(params *chaincfg.Params) string {
+	switch params.Net {
+	case chaincfg.TestNet3Params.Net:
+		return "test"
+	case chaincfg.SigNetParams.Net:
+		return "signet"
+	case chaincfg.RegressionNetParams.Net:
+		return "regtest"
+	}
+	return "main"
+}
+
+// hwiPath writes hardened steps with h, which HWI accepts on every platform.
+func hwiPath(path string) string {
+	return strings.ReplaceAll(path, "'", "h")
+}
@@ -0,0 +1,66 @@
+package hwi
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func fakeClient(t *testing.T) *Client {
+	path, err := filepath.Abs("testdata/fake-hwi")
+	require.NoError(t, err)
+	return New(path, &chaincfg.MainNetParams)
+}
+
+func TestEnumerateAndWatchOnly(t *testing.T) {
+	c := fakeClient(t)
+	d, err := c.Device("")
+	require.NoError(t, err)
+	assert.Equal(t, "73c5da0a", d.Fingerprint)
+	_, err = c.Device("deadbeef")
+	assert.Error(t, err)
+
+	w, err := c.WatchOnly(d.Fingerprint)
+	require.NoError(t, err)
+	assert.True(t, w.WatchOnly())
+	addr, err := w.ReceiveAddress()
+	require.NoError(t, err)
+	assert.Equal(t, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr", addr.Address.String())
+}
+
+func TestVerifyAddress(t *testing.T) {
+	c := fakeClient(t)
+	w, err := c.WatchOnly("73c5da0a")
+	require.NoError(t, err)
+	shown, err := c.VerifyAddress(w, 0, 0)
+	require.NoError(t, err)
+	assert.Equal(t, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr", shown)
+
+	var hwiErr *Error
+	_, err = c.VerifyAddress(w, 0, 1)
+	assert.ErrorAs(t, err, &hwiErr)
+	assert.Equal(t, -7, hwiErr.Code)
+
+	t.Setenv("FAKE_HWI_ADDRESS", "bc1pattacker")
+	_, err = c.VerifyAddress(w, 0, 0)
+	assert.ErrorIs(t, err, ErrAddressMismatch)
+}
+
+func TestSignPSBT(t *testing.T) {
+	c := fakeClient(t)
+	signed, err := c.SignPSBT("73c5da0a", "cHNidP8B")
+	require.NoError(t, err)
+	assert.Equal(t, "signed:cHNidP8B", signed)
+
+	t.Setenv("FAKE_HWI_UNSIGNED", "1")
+	_, err = c.SignPSBT("73c5da0a", "cHNidP8B")
+	assert.Error(t, err)
+}
+
+func TestMissingCommand(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "hwi"), &chaincfg.MainNetParams).Enumerate()
+	assert.Error(t, err)
+}
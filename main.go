@@ -1,15 +1,26 @@
 package main
 
 import (
+	"fmt"
 	"log"
+	"os"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/satelliondao/satellion/cli"
+	"github.com/satelliondao/satellion/secret"
+	"github.com/satelliondao/satellion/ui/backup"
+	"github.com/satelliondao/satellion/ui/coins"
+	"github.com/satelliondao/satellion/ui/contacts"
+	"github.com/satelliondao/satellion/ui/cpfp"
 	"github.com/satelliondao/satellion/ui/framework"
+	"github.com/satelliondao/satellion/ui/history"
 	"github.com/satelliondao/satellion/ui/home"
 	"github.com/satelliondao/satellion/ui/page"
 	"github.com/satelliondao/satellion/ui/passphrase"
+	"github.com/satelliondao/satellion/ui/peers"
 	"github.com/satelliondao/satellion/ui/receive"
 	"github.com/satelliondao/satellion/ui/send"
+	"github.com/satelliondao/satellion/ui/sweep"
 	"github.com/satelliondao/satellion/ui/sync"
 	"github.com/satelliondao/satellion/ui/verify_mnemonic"
 	"github.com/satelliondao/satellion/ui/wallet_create"
@@ -20,6 +31,16 @@ import (
 )
 
 func main() {
+	if err := secret.Harden(); err != nil {
+		fmt.Fprintf(os.Stderr, "warning: could not disable core dumps: %v\n", err)
+	}
+	if len(os.Args) > 1 {
+		if err := cli.Run(os.Args[1:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
 	ctx, err := framework.NewContext()
 	if err != nil {
 		log.Fatalf("Failed to initialize app context: %v", err)
@@ -37,12 +58,20 @@ func main() {
 		page.UnlockWallet:   wallet_unlock.New,
 		page.Receive:        receive.New,
 		page.Send:           send.New,
+		page.Peers:          peers.New,
+		page.Backup:         backup.New,
+		page.Contacts:       contacts.New,
+		page.Coins:          coins.New,
+		page.History:        history.New,
+		page.Cpfp:           cpfp.New,
+		page.Sweep:          sweep.New,
 	}
 	walletCount, err := ctx.WalletRepo.WalletCount()
 	if err != nil {
 		panic(err)
 	}
-	app := framework.NewApp(ctx, pages, startPage(walletCount))
+	app := framework.NewApp(ctx, pages, startPage(walletCount)).
+		WithAutoLock(page.UnlockWallet, ctx.Config.IdleLockTimeout())
 	_, _ = tea.NewProgram(app, tea.WithAltScreen()).Run()
 }
 
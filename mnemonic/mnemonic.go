@@ -9,6 +9,7 @@ import (
 	"golang.org/x/crypto/pbkdf2"
 
 	"github.com/satelliondao/satellion/mnemonic/wordlist"
+	"github.com/satelliondao/satellion/secret"
 )
 
 const WordCount = 12
@@ -50,6 +51,41 @@ func (m *Mnemonic) Bytes() []byte {
 	return []byte(m.String())
 }
 
+// Phrase returns the space separated words in a wipeable buffer.
+func (m *Mnemonic) Phrase() *secret.Bytes {
+	n := len(m.Words)
+	for _, w := range m.Words {
+		n += len(w)
+	}
+	b := make([]byte, 0, n)
+	for i, w := range m.Words {
+		if i > 0 {
+			b = append(b, ' ')
+		}
+		b = append(b, w...)
+	}
+	s := secret.New(b)
+	secret.Wipe(b)
+	return s
+}
+
+// Seed derives the BIP39 seed. The caller should wipe it once the master key is derived.
 func (m *Mnemonic) Seed(passphrase string) []byte {
-	return pbkdf2.Key([]byte(m.String()), []byte("mnemonic"+passphrase), 2048, 64, sha512.New)
+	phrase := m.Phrase()
+	defer phrase.Wipe()
+	b := make([]byte, 0, len("mnemonic")+len(passphrase))
+	b = append(append(b, "mnemonic"...), passphrase...)
+	salt := secret.New(b)
+	secret.Wipe(b)
+	defer salt.Wipe()
+	return pbkdf2.Key(phrase.Bytes(), salt.Bytes(), 2048, 64, sha512.New)
+}
+
+// Wipe drops the words. They are shared with the wordlist or are immutable strings, so
+// the references are cleared rather than the characters.
+func (m *Mnemonic) Wipe() {
+	for i := range m.Words {
+		m.Words[i] = ""
+	}
+	m.Words = nil
 }
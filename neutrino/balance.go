@@ -5,6 +5,7 @@ import (
 	"log"
 	"time"
 
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/btcutil/gcs/builder"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
@@ -12,11 +13,18 @@ import (
 	"github.com/satelliondao/satellion/wallet"
 )
 
-const MaxIndexFurtherLookup = 20
-
 type BalanceInfo struct {
 	Balance   uint64
 	UtxoCount uint64
+	// Utxos are the unspent outputs of the wallet, oldest first.
+	Utxos []wallet.Utxo
+	// Height is the height of the best block the wallet was scanned up to.
+	Height int32
+	// Confirmed maps the txids of the transactions spending or paying wallet outputs to
+	// the height of their block.
+	Confirmed map[string]int32
+	// Addresses are the addresses scanned for.
+	Addresses []*wallet.Address
 }
 
 type BalanceService struct {
@@ -32,68 +40,116 @@ func (s *BalanceService) SetProgressCallback(callback func(current, total int64,
 	s.onProgress = callback
 }
 
-func (s *BalanceService) ScanLedger(wallet *wallet.Wallet) (*BalanceInfo, error) {
-	if wallet.CreatedAt.IsZero() {
+// AddressSpace is the public part of a wallet that a ledger scan needs. It is taken from
+// the wallet up front so that the scan itself never touches the wallet keys, which an
+// auto-lock may wipe while the scan runs.
+type AddressSpace struct {
+	Addresses []*wallet.Address
+	Scripts   [][]byte
+	CreatedAt time.Time
+}
+
+// AddressSpaceOf derives the addresses of w and the scripts paying them.
+func (s *BalanceService) AddressSpaceOf(w *wallet.Wallet) (*AddressSpace, error) {
+	if w.CreatedAt.IsZero() {
 		return nil, fmt.Errorf("wallet creation time not set")
 	}
-	block, err := s.chain.BestBlock()
+	addresses, err := s.DeriveAddressSpace(w)
 	if err != nil {
-		return nil, fmt.Errorf("failed to get best block: %w", err)
+		return nil, fmt.Errorf("failed to generate addresses: %w", err)
 	}
-	startHeight, err := s.findBlockHeightFromTime(wallet.CreatedAt, block.Height)
+	scripts, err := s.addressesToScripts(addresses)
 	if err != nil {
-		return nil, fmt.Errorf("failed to find start height: %w", err)
+		return nil, fmt.Errorf("failed to convert addresses to scripts: %w", err)
 	}
-	blockCount := int64(block.Height) - startHeight + 1
-	addresses, err := s.DeriveAddressSpace(wallet)
+	return &AddressSpace{Addresses: addresses, Scripts: scripts, CreatedAt: w.CreatedAt}, nil
+}
+
+func (s *BalanceService) ScanLedger(w *wallet.Wallet) (*BalanceInfo, error) {
+	space, err := s.AddressSpaceOf(w)
 	if err != nil {
-		return nil, fmt.Errorf("failed to generate addresses: %w", err)
+		return nil, err
 	}
-	scripts, err := s.addressesToScripts(addresses)
+	return s.ScanAddressSpace(space)
+}
+
+// ScanAddressSpace scans the blocks from the creation of the wallet to the best block
+// for the outputs paying space and the transactions spending them.
+func (s *BalanceService) ScanAddressSpace(space *AddressSpace) (*BalanceInfo, error) {
+	block, err := s.chain.BestBlock()
 	if err != nil {
-		return nil, fmt.Errorf("failed to convert addresses to scripts: %w", err)
+		return nil, fmt.Errorf("failed to get best block: %w", err)
+	}
+	startHeight, err := s.findBlockHeightFromTime(space.CreatedAt, block.Height)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find start height: %w", err)
 	}
-	var totalBalance uint64
-	var totalUtxos uint64
+	blockCount := int64(block.Height) - startHeight + 1
+	l := newLedger(space.Addresses, space.Scripts)
 	processed := int64(0)
 	for height := startHeight; height <= int64(block.Height); height++ {
-		res, err := s.scanBlock(height, scripts, processed, blockCount)
-		if err != nil {
+		if err := s.scanBlock(height, l, processed, blockCount); err != nil {
 			return nil, fmt.Errorf("failed to scan block %d: %w", height, err)
 		}
-		totalBalance += res.Balance
-		totalUtxos += res.Utxos
 		processed++
 	}
-	return &BalanceInfo{
-		Balance:   totalBalance,
-		UtxoCount: totalUtxos,
-	}, nil
+	info := &BalanceInfo{Utxos: l.sorted(), Height: block.Height, Confirmed: l.confirmed, Addresses: space.Addresses}
+	for _, u := range info.Utxos {
+		info.Balance += uint64(u.Value)
+	}
+	info.UtxoCount = uint64(len(info.Utxos))
+	return info, nil
 }
 
-type ScanBlockResult struct {
-	Balance uint64
-	Utxos   uint64
+// ScanScripts returns the unspent outputs paying addresses, which need not belong to a
+// wallet, found in the blocks from startHeight to the best block, and the height of the
+// best block.
+func (s *BalanceService) ScanScripts(addresses []btcutil.Address, startHeight int64) ([]wallet.Utxo, int32, error) {
+	block, err := s.chain.BestBlock()
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get best block: %w", err)
+	}
+	if startHeight < 0 || startHeight > int64(block.Height) {
+		return nil, 0, fmt.Errorf("start height %d is not between 0 and the best block %d", startHeight, block.Height)
+	}
+	scripts := make([][]byte, len(addresses))
+	for i, addr := range addresses {
+		if scripts[i], err = txscript.PayToAddrScript(addr); err != nil {
+			return nil, 0, fmt.Errorf("failed to create script for address %s: %w", addr, err)
+		}
+	}
+	l := newScriptLedger(addresses, scripts)
+	blockCount := int64(block.Height) - startHeight + 1
+	processed := int64(0)
+	for height := startHeight; height <= int64(block.Height); height++ {
+		if err := s.scanBlock(height, l, processed, blockCount); err != nil {
+			return nil, 0, fmt.Errorf("failed to scan block %d: %w", height, err)
+		}
+		processed++
+	}
+	return l.sorted(), block.Height, nil
 }
 
-func (s *BalanceService) scanBlock(height int64, scripts [][]byte, processed int64, blockCount int64) (*ScanBlockResult, error) {
+// scanBlock downloads the block at height when its compact filter matches a wallet
+// script and applies it to l. Filters match the scripts of spent outputs too, so
+// the blocks spending wallet outputs are downloaded as well.
+func (s *BalanceService) scanBlock(height int64, l *ledger, processed int64, blockCount int64) error {
 	blockHash, err := s.chain.GetBlockHash(height)
-	res := &ScanBlockResult{
-		Balance: 0,
-		Utxos:   0,
-	}
 	if err != nil {
 		log.Printf("Warning: failed to get block hash for height %d: %v", height, err)
-		return res, err
+		return err
 	}
-	matches, err := s.scanBlockForAddresses(blockHash, scripts)
+	matches, err := s.scanBlockForAddresses(blockHash, l.scripts)
 	if err != nil {
 		log.Printf("Warning: failed to scan block %d: %v", height, err)
-		return res, err
+		return err
 	}
 	if matches > 0 {
-		res.Utxos = uint64(matches)
-		res.Balance = uint64(matches * 1000)
+		block, err := s.chain.GetBlock(*blockHash)
+		if err != nil {
+			return fmt.Errorf("failed to get block: %w", err)
+		}
+		l.apply(block.MsgBlock(), int32(height))
 	}
 	processed++
 	if processed%1000 == 0 || processed == blockCount {
@@ -102,7 +158,7 @@ func (s *BalanceService) scanBlock(height int64, scripts [][]byte, processed int
 			s.onProgress(processed, blockCount, progress)
 		}
 	}
-	return res, nil
+	return nil
 }
 
 func (s *BalanceService) findBlockHeightFromTime(createdAt time.Time, bestHeight int32) (int64, error) {
@@ -129,15 +185,11 @@ func (s *BalanceService) findBlockHeightFromTime(createdAt time.Time, bestHeight
 	return left, nil
 }
 
+// DeriveAddressSpace derives the receive and change addresses of w up to wallet.GapLimit
+// past the last one handed out, the same addresses spend.Incoming recognises.
 func (s *BalanceService) DeriveAddressSpace(w *wallet.Wallet) ([]*wallet.Address, error) {
 	var addresses []*wallet.Address
-	maxIndex := w.NextReceiveIndex
-	if w.NextChangeIndex > maxIndex {
-		maxIndex = w.NextChangeIndex
-	}
-	if maxIndex == 0 {
-		maxIndex = MaxIndexFurtherLookup
-	}
+	maxIndex := max(w.NextReceiveIndex, w.NextChangeIndex) + wallet.GapLimit
 	for i := uint32(0); i <= maxIndex; i++ {
 		receiveAddr, err := w.DeriveTaprootAddress(0, i)
 		if err != nil {
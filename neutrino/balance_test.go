@@ -4,11 +4,16 @@ import (
 	"testing"
 	"time"
 
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/btcutil/gcs"
+	"github.com/btcsuite/btcd/btcutil/gcs/builder"
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	"github.com/lightninglabs/neutrino/headerfs"
 	"github.com/satelliondao/satellion/mnemonic"
+	"github.com/satelliondao/satellion/ports"
 	"github.com/satelliondao/satellion/wallet"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/mock"
@@ -36,7 +41,7 @@ func TestNewBalanceService(t *testing.T) {
 
 func TestScanWalletBalance_WalletCreatedAtZero(t *testing.T) {
 	_, scanner := setupTest()
-	w := wallet.New(&seed, passphrase, "test")
+	w := wallet.New(&seed, passphrase, "test", &chaincfg.MainNetParams)
 	w.CreatedAt = time.Time{} // Zero time
 	balance, err := scanner.ScanLedger(w)
 	assert.Error(t, err)
@@ -46,9 +51,9 @@ func TestScanWalletBalance_WalletCreatedAtZero(t *testing.T) {
 
 func TestScanWalletBalance_ChainError(t *testing.T) {
 	chain, scanner := setupTest()
-	w := wallet.New(&seed, passphrase, "test")
+	w := wallet.New(&seed, passphrase, "test", &chaincfg.MainNetParams)
 	w.CreatedAt = time.Now().Add(-24 * time.Hour)
-	chain.On("BestBlock").Return((*headerfs.BlockStamp)(nil), assert.AnError)
+	chain.On("BestBlock").Return((*ports.BlockInfo)(nil), assert.AnError)
 	balance, err := scanner.ScanLedger(w)
 	assert.Error(t, err)
 	assert.Nil(t, balance)
@@ -57,14 +62,14 @@ func TestScanWalletBalance_ChainError(t *testing.T) {
 
 func TestScanWalletBalance_Success(t *testing.T) {
 	chain, scanner := setupTest()
-	w := wallet.New(&seed, passphrase, "test")
+	w := wallet.New(&seed, passphrase, "test", &chaincfg.MainNetParams)
 	w.CreatedAt = time.Now().Add(-24 * time.Hour)
 	bestBlock := &headerfs.BlockStamp{
 		Height:    100,
 		Hash:      chainhash.Hash{},
 		Timestamp: time.Now(),
 	}
-	chain.On("BestBlock").Return(bestBlock, nil)
+	chain.On("BestBlock").Return(&ports.BlockInfo{BlockStamp: bestBlock}, nil)
 	blockHash := &chainhash.Hash{}
 	blockHeader := &wire.BlockHeader{
 		Timestamp: w.CreatedAt.Add(time.Hour),
@@ -80,6 +85,60 @@ func TestScanWalletBalance_Success(t *testing.T) {
 	assert.Equal(t, uint64(0), balance.Balance)
 }
 
+func TestScanWalletBalance_DownloadsMatchingBlocks(t *testing.T) {
+	chain, scanner := setupTest()
+	w := wallet.New(&seed, passphrase, "test", &chaincfg.MainNetParams)
+	w.CreatedAt = time.Now().Add(-24 * time.Hour)
+	chain.On("BestBlock").Return(&ports.BlockInfo{BlockStamp: &headerfs.BlockStamp{Height: 0}}, nil)
+	blockHash := &chainhash.Hash{}
+	chain.On("GetBlockHash", int64(0)).Return(blockHash, nil)
+	addr, err := w.ReceiveAddress()
+	assert.NoError(t, err)
+	script, err := addr.DeriveTaprootScriptPubKey()
+	assert.NoError(t, err)
+	key := builder.DeriveKey(blockHash)
+	filter, err := gcs.BuildGCSFilter(builder.DefaultP, builder.DefaultM, key, [][]byte{script})
+	assert.NoError(t, err)
+	chain.On("GetCFilter", *blockHash).Return(filter, nil)
+	tx := payment(wire.OutPoint{}, wire.NewTxOut(21_000, script))
+	block := btcutil.NewBlock(&wire.MsgBlock{Transactions: []*wire.MsgTx{tx}})
+	chain.On("GetBlock", *blockHash).Return(block, nil)
+
+	balance, err := scanner.ScanLedger(w)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(21_000), balance.Balance)
+	assert.Equal(t, uint64(1), balance.UtxoCount)
+	assert.Equal(t, addr.Address.String(), balance.Utxos[0].Address)
+}
+
+func TestScanScripts(t *testing.T) {
+	chain, scanner := setupTest()
+	chain.On("BestBlock").Return(&ports.BlockInfo{BlockStamp: &headerfs.BlockStamp{Height: 5}}, nil)
+	blockHash := &chainhash.Hash{5}
+	chain.On("GetBlockHash", int64(5)).Return(blockHash, nil)
+	// BIP173 test vector P2WPKH address, outside any wallet.
+	addr, err := btcutil.DecodeAddress("bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", &chaincfg.MainNetParams)
+	assert.NoError(t, err)
+	script, err := txscript.PayToAddrScript(addr)
+	assert.NoError(t, err)
+	filter, err := gcs.BuildGCSFilter(builder.DefaultP, builder.DefaultM, builder.DeriveKey(blockHash), [][]byte{script})
+	assert.NoError(t, err)
+	chain.On("GetCFilter", *blockHash).Return(filter, nil)
+	block := btcutil.NewBlock(&wire.MsgBlock{Transactions: []*wire.MsgTx{payment(wire.OutPoint{}, wire.NewTxOut(7_000, script))}})
+	chain.On("GetBlock", *blockHash).Return(block, nil)
+
+	utxos, height, err := scanner.ScanScripts([]btcutil.Address{addr}, 5)
+	assert.NoError(t, err)
+	assert.Equal(t, int32(5), height)
+	if assert.Len(t, utxos, 1) {
+		assert.Equal(t, btcutil.Amount(7_000), utxos[0].Value)
+		assert.Equal(t, addr.EncodeAddress(), utxos[0].Address)
+		assert.Equal(t, int32(5), utxos[0].Height)
+	}
+	_, _, err = scanner.ScanScripts([]btcutil.Address{addr}, 6)
+	assert.Error(t, err, "the start height is above the best block")
+}
+
 func TestFindBlockHeightFromTime_BinarySearch(t *testing.T) {
 	chain, scanner := setupTest()
 	targetTime := time.Now()
@@ -98,12 +157,12 @@ func TestFindBlockHeightFromTime_BinarySearch(t *testing.T) {
 
 func TestGenerateAllAddresses(t *testing.T) {
 	_, scanner := setupTest()
-	w := wallet.New(&seed, passphrase, "test")
+	w := wallet.New(&seed, passphrase, "test", &chaincfg.MainNetParams)
 	w.NextReceiveIndex = 3
 	w.NextChangeIndex = 3
 	addresses, err := scanner.DeriveAddressSpace(w)
 	assert.NoError(t, err)
-	expectedCount := (3 + 1) * 2 // (max receive index + 1) * 2 (receive + change)
+	expectedCount := (3 + wallet.GapLimit + 1) * 2 // (max index + gap limit + 1) * 2 (receive + change)
 	assert.Equal(t, expectedCount, len(addresses))
 	receiveCount := 0
 	changeCount := 0
@@ -114,12 +173,15 @@ func TestGenerateAllAddresses(t *testing.T) {
 			receiveCount++
 		}
 	}
-	assert.Equal(t, 4, receiveCount)
-	assert.Equal(t, 4, changeCount)
+	assert.Equal(t, 3+wallet.GapLimit+1, receiveCount)
+	assert.Equal(t, 3+wallet.GapLimit+1, changeCount)
+	last, err := w.DeriveTaprootAddress(1, 3+wallet.GapLimit)
+	assert.NoError(t, err)
+	assert.Equal(t, last.Address.String(), addresses[len(addresses)-1].Address.String(), "the gap limit past the last index, as spend.Incoming")
 }
 
 func TestGenerateAllAddresses_ZeroIndices(t *testing.T) {
-	w := wallet.New(&seed, passphrase, "test")
+	w := wallet.New(&seed, passphrase, "test", &chaincfg.MainNetParams)
 	chain := &MockChainService{}
 	scanner := NewBalance(chain)
 	addresses, err := scanner.DeriveAddressSpace(w)
@@ -128,8 +190,22 @@ func TestGenerateAllAddresses_ZeroIndices(t *testing.T) {
 	assert.Equal(t, expectedCount, len(addresses))
 }
 
+func TestAddressSpaceOf_OutlivesWipe(t *testing.T) {
+	chain, scanner := setupTest()
+	w := wallet.New(&seed, passphrase, "test", &chaincfg.MainNetParams)
+	w.CreatedAt = time.Now().Add(-24 * time.Hour)
+	space, err := scanner.AddressSpaceOf(w)
+	assert.NoError(t, err)
+	assert.Equal(t, len(space.Addresses), len(space.Scripts))
+	assert.Equal(t, w.CreatedAt, space.CreatedAt)
+	w.Wipe()
+	chain.On("BestBlock").Return((*ports.BlockInfo)(nil), assert.AnError)
+	_, err = scanner.ScanAddressSpace(space)
+	assert.ErrorContains(t, err, "failed to get best block")
+}
+
 func TestAddressesToScripts(t *testing.T) {
-	w := wallet.New(&seed, passphrase, "test")
+	w := wallet.New(&seed, passphrase, "test", &chaincfg.MainNetParams)
 	chain := &MockChainService{}
 	scanner := NewBalance(chain)
 	addresses, err := scanner.DeriveAddressSpace(w)
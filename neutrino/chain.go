@@ -5,11 +5,13 @@ import (
 	"os"
 	"os/signal"
 	"path/filepath"
+	"sync"
+	"sync/atomic"
 	"syscall"
 	"time"
 
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/btcutil/gcs"
-	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
 	bdb "github.com/btcsuite/btcwallet/walletdb"
@@ -23,12 +25,21 @@ type Chain struct {
 	neutrino *neutrino.ChainService
 	config   *config.Config
 	db       bdb.DB
+	proxy    *proxyRouter
+	started  atomic.Bool
+	verifier *filterVerifier
+	mempool  *Mempool
+	// relay is created with the chain and only run by Start, so that Stop can read it
+	// from any goroutine.
+	relay   *relay
+	relayed sync.Once
 }
 
 var _ ports.Chain = (*Chain)(nil)
 
 func NewChain(config *config.Config) (*Chain, error) {
-	var s = &Chain{config: config}
+	var s = &Chain{config: config, mempool: NewMempool()}
+	s.relay = newRelay(s, s.mempool)
 	if s.config == nil {
 		loaded, err := config.Load()
 		if err != nil {
@@ -36,11 +47,12 @@ func NewChain(config *config.Config) (*Chain, error) {
 		}
 		s.config = loaded
 	}
-	home, err := os.UserHomeDir()
+	s.verifier = newFilterVerifier(s.config.HeaderQuorum())
+	params, err := s.config.ChainParams()
 	if err != nil {
-		home = "."
+		return nil, err
 	}
-	dataDir := filepath.Join(home, ".satellion", "neutrino", "mainnet")
+	dataDir := s.config.ChainDataDir()
 	if err := os.MkdirAll(dataDir, 0o755); err != nil {
 		return nil, fmt.Errorf("failed to create data dir: %w", err)
 	}
@@ -51,12 +63,26 @@ func NewChain(config *config.Config) (*Chain, error) {
 		return nil, fmt.Errorf("failed to open neutrino db: %w", err)
 	}
 
-	s.neutrino, err = neutrino.NewChainService(neutrino.Config{
+	s.proxy, err = newProxyRouter(s.config)
+	if err != nil {
+		return nil, err
+	}
+	cfg := neutrino.Config{
 		DataDir:     dataDir,
 		Database:    s.db,
-		ChainParams: chaincfg.MainNetParams,
-		AddPeers:    s.config.Peers,
-	})
+		ChainParams: *params,
+	}
+	peers := s.config.PeerAddresses()
+	if s.proxy != nil {
+		cfg.Dialer = s.proxy.Dial
+		cfg.NameResolver = s.proxy.LookupIP
+	}
+	if s.config.ConnectOnly {
+		cfg.ConnectPeers = peers
+	} else {
+		cfg.AddPeers = peers
+	}
+	s.neutrino, err = neutrino.NewChainService(cfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create chain service: %w", err)
 	}
@@ -68,14 +94,19 @@ func (s *Chain) BestBlock() (*ports.BlockInfo, error) {
 	if err != nil {
 		return nil, err
 	}
-	peers := int(s.neutrino.ConnectedCount())
+	peers := int(s.ConnectedCount())
 	return &ports.BlockInfo{
 		BlockStamp: stamp,
 		Peers:      peers,
 	}, nil
 }
 
+// GetCFilter returns the regular compact filter of a block once its filter header
+// has been confirmed by the configured number of peers.
 func (s *Chain) GetCFilter(hash chainhash.Hash) (*gcs.Filter, error) {
+	if err := s.ensureFilterTrusted(hash); err != nil {
+		return nil, err
+	}
 	return s.neutrino.GetCFilter(hash, wire.GCSFilterRegular)
 }
 
@@ -87,14 +118,50 @@ func (s *Chain) GetBlockHeader(hash *chainhash.Hash) (*wire.BlockHeader, error)
 	return s.neutrino.GetBlockHeader(hash)
 }
 
+// SendTransaction broadcasts tx to the connected peers. It fails when a peer rejects it.
+func (s *Chain) SendTransaction(tx *wire.MsgTx) error {
+	return s.neutrino.SendTransaction(tx)
+}
+
+// GetBlock downloads a block from a peer. neutrino checks it against its compact filter.
+func (s *Chain) GetBlock(hash chainhash.Hash) (*btcutil.Block, error) {
+	return s.neutrino.GetBlock(hash)
+}
+
+// Proxy reports whether peer connections are routed through the configured proxy.
+func (s *Chain) Proxy() ProxyStatus {
+	return s.proxy.Status()
+}
+
 func (s *Chain) ConnectedCount() int32 {
+	if !s.started.Load() {
+		return 0
+	}
 	return s.neutrino.ConnectedCount()
 }
 
-func (s *Chain) Syncronize() error {
+// Start connects to peers in the background, and to MempoolPeers of them to learn
+// about unconfirmed transactions. It is safe to call more than once.
+func (s *Chain) Start() error {
 	if err := s.neutrino.Start(); err != nil {
 		return err
 	}
+	s.started.Store(true)
+	s.relayed.Do(func() {
+		go s.relay.run()
+	})
+	return nil
+}
+
+// Mempool returns the unconfirmed transactions of the watched wallet relayed by peers.
+func (s *Chain) Mempool() *Mempool {
+	return s.mempool
+}
+
+func (s *Chain) Syncronize() error {
+	if err := s.Start(); err != nil {
+		return err
+	}
 
 	sigCh := make(chan os.Signal, 1)
 	signal.Notify(sigCh, os.Interrupt, syscall.SIGTERM)
@@ -119,6 +186,9 @@ func (s *Chain) IsSynced() bool {
 }
 
 func (s *Chain) Stop() {
+	if s.relay != nil {
+		s.relay.stop()
+	}
 	if s.neutrino != nil {
 		s.neutrino.Stop()
 	}
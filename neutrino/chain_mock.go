@@ -1,6 +1,7 @@
 package neutrino
 
 import (
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/btcutil/gcs"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
@@ -34,3 +35,13 @@ func (m *MockChainService) GetCFilter(hash chainhash.Hash) (*gcs.Filter, error)
 	args := m.Called(hash)
 	return args.Get(0).(*gcs.Filter), args.Error(1)
 }
+
+func (m *MockChainService) GetBlock(hash chainhash.Hash) (*btcutil.Block, error) {
+	args := m.Called(hash)
+	return args.Get(0).(*btcutil.Block), args.Error(1)
+}
+
+func (m *MockChainService) SendTransaction(tx *wire.MsgTx) error {
+	args := m.Called(tx)
+	return args.Error(0)
+}
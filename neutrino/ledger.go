@@ -0,0 +1,95 @@
+package neutrino
+
+import (
+	"bytes"
+	"sort"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satelliondao/satellion/wallet"
+)
+
+// ledger tracks the unspent outputs of a wallet while blocks are applied in order.
+type ledger struct {
+	scripts [][]byte
+	// owned maps the scripts to the address fields of the outputs paying them.
+	owned map[string]wallet.Utxo
+	utxos map[wire.OutPoint]wallet.Utxo
+	// confirmed maps the txids of the transactions spending or paying wallet outputs
+	// to the height of their block.
+	confirmed map[string]int32
+}
+
+// newLedger returns an empty ledger of the addresses paid by scripts, in the same order.
+func newLedger(addresses []*wallet.Address, scripts [][]byte) *ledger {
+	l := &ledger{
+		scripts:   scripts,
+		owned:     make(map[string]wallet.Utxo, len(scripts)),
+		utxos:     make(map[wire.OutPoint]wallet.Utxo),
+		confirmed: make(map[string]int32),
+	}
+	for i, script := range scripts {
+		a := addresses[i]
+		l.owned[string(script)] = wallet.Utxo{Address: a.Address.String(), Change: a.Change, Index: a.DeriviationIndex}
+	}
+	return l
+}
+
+// newScriptLedger returns an empty ledger of addresses outside the wallet, paid by
+// scripts in the same order.
+func newScriptLedger(addresses []btcutil.Address, scripts [][]byte) *ledger {
+	l := &ledger{
+		scripts:   scripts,
+		owned:     make(map[string]wallet.Utxo, len(scripts)),
+		utxos:     make(map[wire.OutPoint]wallet.Utxo),
+		confirmed: make(map[string]int32),
+	}
+	for i, script := range scripts {
+		l.owned[string(script)] = wallet.Utxo{Address: addresses[i].EncodeAddress()}
+	}
+	return l
+}
+
+// apply removes the outputs spent by block and adds the outputs it pays to the wallet.
+// Transactions are applied in block order, so an output created and spent in the same
+// block is never listed.
+func (l *ledger) apply(block *wire.MsgBlock, height int32) {
+	for _, tx := range block.Transactions {
+		hash := tx.TxHash()
+		for _, in := range tx.TxIn {
+			if _, ok := l.utxos[in.PreviousOutPoint]; ok {
+				delete(l.utxos, in.PreviousOutPoint)
+				l.confirmed[hash.String()] = height
+			}
+		}
+		for i, out := range tx.TxOut {
+			u, ok := l.owned[string(out.PkScript)]
+			if !ok {
+				continue
+			}
+			l.confirmed[hash.String()] = height
+			u.OutPoint = wire.OutPoint{Hash: hash, Index: uint32(i)}
+			u.Value, u.PkScript, u.Height = btcutil.Amount(out.Value), out.PkScript, height
+			l.utxos[u.OutPoint] = u
+		}
+	}
+}
+
+// sorted returns the unspent outputs by height, then by outpoint.
+func (l *ledger) sorted() []wallet.Utxo {
+	list := make([]wallet.Utxo, 0, len(l.utxos))
+	for _, u := range l.utxos {
+		list = append(list, u)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		a, b := list[i], list[j]
+		if a.Height != b.Height {
+			return a.Height < b.Height
+		}
+		if c := bytes.Compare(a.OutPoint.Hash[:], b.OutPoint.Hash[:]); c != 0 {
+			return c < 0
+		}
+		return a.OutPoint.Index < b.OutPoint.Index
+	})
+	return list
+}
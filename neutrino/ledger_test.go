@@ -0,0 +1,62 @@
+package neutrino
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satelliondao/satellion/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testLedger(t *testing.T) (*ledger, [][]byte) {
+	w := wallet.New(&seed, passphrase, "test", &chaincfg.MainNetParams)
+	scanner := NewBalance(&MockChainService{})
+	addresses, err := scanner.DeriveAddressSpace(w)
+	require.NoError(t, err)
+	scripts, err := scanner.addressesToScripts(addresses)
+	require.NoError(t, err)
+	return newLedger(addresses, scripts), scripts
+}
+
+func payment(prev wire.OutPoint, outs ...*wire.TxOut) *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&prev, nil, nil))
+	for _, out := range outs {
+		tx.AddTxOut(out)
+	}
+	return tx
+}
+
+func TestLedger_ReceiveAndSpend(t *testing.T) {
+	l, scripts := testLedger(t)
+	foreign := []byte{0x51, 0x20, 0x01}
+	// scripts alternate receive and change addresses.
+	receive := payment(wire.OutPoint{Index: 7}, wire.NewTxOut(60_000, scripts[2]), wire.NewTxOut(1_000, foreign))
+	l.apply(&wire.MsgBlock{Transactions: []*wire.MsgTx{receive}}, 100)
+
+	utxos := l.sorted()
+	require.Len(t, utxos, 1)
+	assert.Equal(t, wire.OutPoint{Hash: receive.TxHash(), Index: 0}, utxos[0].OutPoint)
+	assert.Equal(t, btcutil.Amount(60_000), utxos[0].Value)
+	assert.Equal(t, int32(100), utxos[0].Height)
+	assert.False(t, utxos[0].Change)
+	assert.Equal(t, uint32(1), utxos[0].Index)
+	assert.Equal(t, "m/86'/0'/0'/0/1", utxos[0].Path())
+	assert.Equal(t, int32(6), utxos[0].Confirmations(105))
+
+	// Spending the output with change back to the wallet, and spending the change in the
+	// same block, leaves nothing.
+	spend := payment(utxos[0].OutPoint, wire.NewTxOut(30_000, foreign), wire.NewTxOut(29_000, scripts[1]))
+	change := payment(wire.OutPoint{Hash: spend.TxHash(), Index: 1}, wire.NewTxOut(28_000, scripts[3]))
+	l.apply(&wire.MsgBlock{Transactions: []*wire.MsgTx{spend}}, 101)
+	require.Len(t, l.sorted(), 1)
+	assert.True(t, l.sorted()[0].Change)
+	assert.Equal(t, map[string]int32{receive.TxHash().String(): 100, spend.TxHash().String(): 101}, l.confirmed)
+	l.apply(&wire.MsgBlock{Transactions: []*wire.MsgTx{change}}, 102)
+	utxos = l.sorted()
+	require.Len(t, utxos, 1)
+	assert.Equal(t, "m/86'/0'/0'/1/1", utxos[0].Path())
+}
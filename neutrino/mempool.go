@@ -0,0 +1,278 @@
+package neutrino
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satelliondao/satellion/wallet"
+)
+
+// MempoolStatus is the state of an unconfirmed transaction of the wallet.
+type MempoolStatus string
+
+const (
+	MempoolPending MempoolStatus = "pending"
+	// MempoolEvicted transactions are no longer served by any relay peer: their fee
+	// was too low or they expired. They may still be mined or broadcast again.
+	MempoolEvicted MempoolStatus = "evicted"
+	// MempoolDoubleSpent transactions had an input spent by another transaction, or
+	// spend an output of such a transaction. They will never confirm.
+	MempoolDoubleSpent MempoolStatus = "double-spent"
+)
+
+// flaggedRetention is how long evicted and double-spent entries stay listed.
+const flaggedRetention = 24 * time.Hour
+
+// MempoolEntry is an unconfirmed transaction paying the wallet or spending its coins.
+type MempoolEntry struct {
+	Txid string
+	// Received is paid to the wallet, Spent is the value of the wallet coins it spends.
+	Received btcutil.Amount
+	Spent    btcutil.Amount
+	// Outputs are the unconfirmed outputs paying the wallet, with a zero height.
+	Outputs []wallet.Utxo
+	Status  MempoolStatus
+	// ConflictsWith is the txid of the transaction that double spent this one, empty
+	// when it is unknown or was mined.
+	ConflictsWith string
+	SeenAt        time.Time
+	FlaggedAt     time.Time
+}
+
+// Net returns what the transaction adds to the balance of the wallet, negative when it
+// pays someone else.
+func (e MempoolEntry) Net() btcutil.Amount {
+	return e.Received - e.Spent
+}
+
+type mempoolEntry struct {
+	MempoolEntry
+	inputs []wire.OutPoint
+}
+
+// Mempool tracks the unconfirmed transactions relayed by peers that concern the watched
+// wallet: those paying its scripts or spending its outpoints. It is safe for concurrent
+// use.
+type Mempool struct {
+	mu      sync.Mutex
+	wname   string
+	owned   map[string]wallet.Utxo
+	coins   map[wire.OutPoint]wallet.Utxo
+	entries map[string]*mempoolEntry
+	// spentBy maps every input of the entries to the txid spending it, so that
+	// conflicting transactions are spotted even when they do not pay the wallet.
+	spentBy map[wire.OutPoint]string
+}
+
+func NewMempool() *Mempool {
+	return &Mempool{
+		owned:   make(map[string]wallet.Utxo),
+		coins:   make(map[wire.OutPoint]wallet.Utxo),
+		entries: make(map[string]*mempoolEntry),
+		spentBy: make(map[wire.OutPoint]string),
+	}
+}
+
+// Watch sets the wallet to track: its addresses and confirmed unspent outputs. The
+// entries of a previously watched wallet are dropped.
+func (m *Mempool) Watch(wname string, addresses []*wallet.Address, unspent []wallet.Utxo) error {
+	owned := make(map[string]wallet.Utxo, len(addresses))
+	for _, a := range addresses {
+		script, err := a.DeriveTaprootScriptPubKey()
+		if err != nil {
+			return err
+		}
+		owned[string(script)] = wallet.Utxo{Address: a.Address.String(), Change: a.Change, Index: a.DeriviationIndex}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if wname != m.wname {
+		m.wname = wname
+		m.entries = make(map[string]*mempoolEntry)
+		m.spentBy = make(map[wire.OutPoint]string)
+	}
+	m.owned = owned
+	m.setCoins(unspent)
+	return nil
+}
+
+// setCoins replaces the coins of the wallet with unspent and the outputs of the entries.
+func (m *Mempool) setCoins(unspent []wallet.Utxo) {
+	m.coins = make(map[wire.OutPoint]wallet.Utxo, len(unspent))
+	for _, u := range unspent {
+		m.coins[u.OutPoint] = u
+	}
+	for _, e := range m.entries {
+		for _, u := range e.Outputs {
+			m.coins[u.OutPoint] = u
+		}
+	}
+}
+
+// Add records tx, announced by a peer at now, when it concerns the wallet, and flags
+// the entries it double spends. It reports whether the entries changed.
+func (m *Mempool) Add(tx *wire.MsgTx, now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	txid := tx.TxHash().String()
+	if e, ok := m.entries[txid]; ok {
+		if e.Status != MempoolEvicted {
+			return false
+		}
+		e.Status, e.FlaggedAt = MempoolPending, time.Time{}
+		return true
+	}
+	changed := false
+	e := &mempoolEntry{MempoolEntry: MempoolEntry{Txid: txid, Status: MempoolPending, SeenAt: now}}
+	for _, in := range tx.TxIn {
+		op := in.PreviousOutPoint
+		if other, ok := m.spentBy[op]; ok && other != txid {
+			m.flag(other, txid, now)
+			changed = true
+		}
+		if u, ok := m.coins[op]; ok {
+			e.Spent += u.Value
+		}
+		e.inputs = append(e.inputs, op)
+	}
+	hash := tx.TxHash()
+	for i, out := range tx.TxOut {
+		u, ok := m.owned[string(out.PkScript)]
+		if !ok {
+			continue
+		}
+		u.OutPoint = wire.OutPoint{Hash: hash, Index: uint32(i)}
+		u.Value, u.PkScript = btcutil.Amount(out.Value), out.PkScript
+		e.Outputs = append(e.Outputs, u)
+		e.Received += u.Value
+	}
+	if e.Spent == 0 && e.Received == 0 {
+		return changed
+	}
+	m.entries[txid] = e
+	for _, op := range e.inputs {
+		m.spentBy[op] = txid
+	}
+	for _, u := range e.Outputs {
+		m.coins[u.OutPoint] = u
+	}
+	return true
+}
+
+// flag marks the entry txid, and the entries spending its outputs, double spent by
+// the transaction by, or by a block when by is empty.
+func (m *Mempool) flag(txid, by string, now time.Time) {
+	e, ok := m.entries[txid]
+	if !ok || e.Status == MempoolDoubleSpent {
+		return
+	}
+	e.Status, e.ConflictsWith, e.FlaggedAt = MempoolDoubleSpent, by, now
+	for _, u := range e.Outputs {
+		if child, ok := m.spentBy[u.OutPoint]; ok {
+			m.flag(child, txid, now)
+		}
+	}
+}
+
+// Evict flags the pending entry txid as evicted from the mempools of the peers.
+func (m *Mempool) Evict(txid string, now time.Time) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[txid]
+	if !ok || e.Status != MempoolPending {
+		return false
+	}
+	e.Status, e.FlaggedAt = MempoolEvicted, now
+	return true
+}
+
+// Reconcile updates the entries with a scan of the blocks: mined entries, in confirmed,
+// are dropped, and pending ones spending a wallet coin missing from unspent were
+// double spent by a block. Flagged entries are dropped after a day.
+func (m *Mempool) Reconcile(confirmed map[string]int32, unspent []wallet.Utxo, now time.Time) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	available := make(map[wire.OutPoint]bool, len(unspent))
+	for _, u := range unspent {
+		available[u.OutPoint] = true
+	}
+	for txid, e := range m.entries {
+		_, mined := confirmed[txid]
+		if mined || e.Status != MempoolPending && now.Sub(e.FlaggedAt) > flaggedRetention {
+			m.drop(txid)
+		}
+	}
+	for txid, e := range m.entries {
+		for _, op := range e.inputs {
+			// Unconfirmed coins of other entries are not in a scan.
+			if u, ok := m.coins[op]; ok && u.Height != 0 && !available[op] {
+				m.flag(txid, "", now)
+				break
+			}
+		}
+	}
+	m.setCoins(unspent)
+}
+
+func (m *Mempool) drop(txid string) {
+	e := m.entries[txid]
+	delete(m.entries, txid)
+	for _, op := range e.inputs {
+		if m.spentBy[op] == txid {
+			delete(m.spentBy, op)
+		}
+	}
+}
+
+// Entries returns the tracked transactions, newest first.
+func (m *Mempool) Entries() []MempoolEntry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	list := make([]MempoolEntry, 0, len(m.entries))
+	for _, e := range m.entries {
+		list = append(list, e.MempoolEntry)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if !list[i].SeenAt.Equal(list[j].SeenAt) {
+			return list[i].SeenAt.After(list[j].SeenAt)
+		}
+		return list[i].Txid < list[j].Txid
+	})
+	return list
+}
+
+// Pending returns the unconfirmed amounts on their way to the wallet and leaving it,
+// counting only pending entries.
+func (m *Mempool) Pending() (incoming, outgoing btcutil.Amount) {
+	for _, e := range m.Entries() {
+		if e.Status != MempoolPending {
+			continue
+		}
+		if net := e.Net(); net > 0 {
+			incoming += net
+		} else {
+			outgoing -= net
+		}
+	}
+	return incoming, outgoing
+}
+
+// pendingBefore returns the hashes of the pending entries first seen before t.
+func (m *Mempool) pendingBefore(t time.Time) []chainhash.Hash {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var list []chainhash.Hash
+	for txid, e := range m.entries {
+		if e.Status == MempoolPending && e.SeenAt.Before(t) {
+			hash, err := chainhash.NewHashFromStr(txid)
+			if err == nil {
+				list = append(list, *hash)
+			}
+		}
+	}
+	return list
+}
@@ -0,0 +1,124 @@
+package neutrino
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satelliondao/satellion/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func watchedMempool(t *testing.T) (*Mempool, []*wallet.Address, [][]byte, wallet.Utxo) {
+	w := wallet.New(&seed, passphrase, "test", &chaincfg.MainNetParams)
+	var addresses []*wallet.Address
+	var scripts [][]byte
+	for i := uint32(0); i < 3; i++ {
+		addr, err := w.DeriveTaprootAddress(0, i)
+		require.NoError(t, err)
+		script, err := addr.DeriveTaprootScriptPubKey()
+		require.NoError(t, err)
+		addresses, scripts = append(addresses, addr), append(scripts, script)
+	}
+	coin := wallet.Utxo{OutPoint: wire.OutPoint{Hash: [32]byte{1}}, Value: 50_000, PkScript: scripts[0], Height: 100}
+	pool := NewMempool()
+	require.NoError(t, pool.Watch("test", addresses, []wallet.Utxo{coin}))
+	return pool, addresses, scripts, coin
+}
+
+func spending(inputs []wire.OutPoint, outputs ...*wire.TxOut) *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	for i := range inputs {
+		tx.AddTxIn(wire.NewTxIn(&inputs[i], nil, nil))
+	}
+	for _, out := range outputs {
+		tx.AddTxOut(out)
+	}
+	return tx
+}
+
+var elsewhere = []byte{0x51}
+
+func TestMempool_IncomingAndOutgoing(t *testing.T) {
+	pool, _, scripts, coin := watchedMempool(t)
+	now := time.Now()
+
+	assert.False(t, pool.Add(spending([]wire.OutPoint{{Index: 7}}, wire.NewTxOut(1_000, elsewhere)), now), "unrelated")
+	incoming := spending([]wire.OutPoint{{Hash: [32]byte{9}}}, wire.NewTxOut(20_000, scripts[1]))
+	require.True(t, pool.Add(incoming, now))
+	assert.False(t, pool.Add(incoming, now), "announced twice")
+	payment := spending([]wire.OutPoint{coin.OutPoint}, wire.NewTxOut(30_000, elsewhere), wire.NewTxOut(19_000, scripts[2]))
+	require.True(t, pool.Add(payment, now.Add(time.Second)))
+
+	in, out := pool.Pending()
+	assert.Equal(t, btcutil.Amount(20_000), in)
+	assert.Equal(t, btcutil.Amount(31_000), out)
+	entries := pool.Entries()
+	require.Len(t, entries, 2)
+	assert.Equal(t, payment.TxHash().String(), entries[0].Txid, "newest first")
+	require.Len(t, entries[1].Outputs, 1)
+	assert.Zero(t, entries[1].Outputs[0].Height)
+
+	// A child spending the unconfirmed payment counts it as spent.
+	child := spending([]wire.OutPoint{{Hash: incoming.TxHash()}}, wire.NewTxOut(19_500, elsewhere))
+	require.True(t, pool.Add(child, now.Add(2*time.Second)))
+	in, out = pool.Pending()
+	assert.Equal(t, btcutil.Amount(20_000), in)
+	assert.Equal(t, btcutil.Amount(31_000+20_000), out)
+
+	// Once mined, entries leave the mempool.
+	pool.Reconcile(map[string]int32{incoming.TxHash().String(): 101}, []wallet.Utxo{coin, {OutPoint: wire.OutPoint{Hash: incoming.TxHash()}, Value: 20_000, Height: 101}}, now)
+	assert.Len(t, pool.Entries(), 2)
+	in, _ = pool.Pending()
+	assert.Zero(t, in)
+}
+
+func TestMempool_DoubleSpendAndEviction(t *testing.T) {
+	pool, _, scripts, coin := watchedMempool(t)
+	now := time.Now()
+	parent := wire.OutPoint{Hash: [32]byte{9}}
+	incoming := spending([]wire.OutPoint{parent}, wire.NewTxOut(20_000, scripts[1]))
+	require.True(t, pool.Add(incoming, now))
+	child := spending([]wire.OutPoint{{Hash: incoming.TxHash()}}, wire.NewTxOut(19_500, elsewhere))
+	require.True(t, pool.Add(child, now))
+
+	// The sender spends the same input elsewhere: the payment and its child are dead.
+	theft := spending([]wire.OutPoint{parent}, wire.NewTxOut(20_000, elsewhere))
+	require.True(t, pool.Add(theft, now))
+	for _, e := range pool.Entries() {
+		assert.Equal(t, MempoolDoubleSpent, e.Status, e.Txid)
+	}
+	in, out := pool.Pending()
+	assert.Zero(t, in)
+	assert.Zero(t, out)
+
+	payment := spending([]wire.OutPoint{coin.OutPoint}, wire.NewTxOut(30_000, elsewhere))
+	require.True(t, pool.Add(payment, now))
+	require.True(t, pool.Evict(payment.TxHash().String(), now))
+	assert.False(t, pool.Evict(payment.TxHash().String(), now))
+	assert.True(t, pool.Add(payment, now), "announced again after its eviction")
+
+	// A block spends the coin in another transaction.
+	pool.Reconcile(map[string]int32{}, nil, now)
+	statuses := make(map[string]MempoolStatus)
+	for _, e := range pool.Entries() {
+		statuses[e.Txid] = e.Status
+	}
+	assert.Equal(t, MempoolDoubleSpent, statuses[payment.TxHash().String()])
+
+	// Flagged entries are dropped after a day.
+	pool.Reconcile(map[string]int32{}, nil, now.Add(25*time.Hour))
+	assert.Empty(t, pool.Entries())
+}
+
+func TestMempool_WatchAnotherWallet(t *testing.T) {
+	pool, addresses, scripts, _ := watchedMempool(t)
+	require.True(t, pool.Add(spending([]wire.OutPoint{{Index: 1}}, wire.NewTxOut(20_000, scripts[1])), time.Now()))
+	require.NoError(t, pool.Watch("test", addresses, nil))
+	assert.Len(t, pool.Entries(), 1)
+	require.NoError(t, pool.Watch("other", addresses, nil))
+	assert.Empty(t, pool.Entries())
+}
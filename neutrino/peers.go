@@ -0,0 +1,84 @@
+package neutrino
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/neutrino/banman"
+)
+
+// PeerInfo describes a known peer of the chain service.
+type PeerInfo struct {
+	Addr      string
+	UserAgent string
+	Services  wire.ServiceFlag
+	Height    int32
+	Latency   time.Duration
+	Connected bool
+	Banned    bool
+}
+
+// Peers returns the connected peers followed by the configured peers that are
+// currently banned.
+func (s *Chain) Peers() []PeerInfo {
+	if !s.started.Load() {
+		return nil
+	}
+	var peers []PeerInfo
+	seen := make(map[string]bool)
+	for _, sp := range s.neutrino.Peers() {
+		seen[sp.Addr()] = true
+		peers = append(peers, PeerInfo{
+			Addr:      sp.Addr(),
+			UserAgent: sp.UserAgent(),
+			Services:  sp.Services(),
+			Height:    sp.LastBlock(),
+			Latency:   time.Duration(sp.LastPingMicros()) * time.Microsecond,
+			Connected: true,
+			Banned:    s.neutrino.IsBanned(sp.Addr()),
+		})
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].Addr < peers[j].Addr })
+	for _, addr := range s.config.Peers {
+		if !seen[addr] && s.neutrino.IsBanned(addr) {
+			peers = append(peers, PeerInfo{Addr: addr, Banned: true})
+		}
+	}
+	return peers
+}
+
+// AddPeer connects to addr and keeps it as a persistent peer.
+func (s *Chain) AddPeer(addr string) error {
+	if !s.started.Load() {
+		return fmt.Errorf("chain service is not running")
+	}
+	return s.neutrino.ConnectNode(addr, true)
+}
+
+// RemovePeer drops addr from the persistent peers and disconnects it.
+func (s *Chain) RemovePeer(addr string) error {
+	if !s.started.Load() {
+		return fmt.Errorf("chain service is not running")
+	}
+	if err := s.neutrino.RemoveNodeByAddr(addr); err != nil {
+		return s.neutrino.DisconnectNodeByAddr(addr)
+	}
+	return nil
+}
+
+// BanPeer disconnects addr and refuses to connect to it for neutrino.BanDuration.
+// Neutrino has no reason code for manual bans, so the peer is recorded as having
+// exceeded its ban threshold.
+func (s *Chain) BanPeer(addr string) error {
+	return s.neutrino.BanPeer(addr, banman.ExceededBanThreshold)
+}
+
+// UnbanPeer lifts the ban on addr and reconnects to it.
+func (s *Chain) UnbanPeer(addr string) error {
+	if !s.started.Load() {
+		return fmt.Errorf("chain service is not running")
+	}
+	return s.neutrino.UnbanPeer(addr, false)
+}
@@ -0,0 +1,91 @@
+package neutrino
+
+import (
+	"fmt"
+	"net"
+	"sync/atomic"
+
+	"github.com/btcsuite/btcd/connmgr"
+	"github.com/btcsuite/go-socks/socks"
+	"github.com/satelliondao/satellion/config"
+)
+
+// ProxyStatus describes how peer connections are routed.
+type ProxyStatus struct {
+	// Address of the SOCKS5 proxy, empty when connecting over clearnet.
+	Address string
+	// StreamIsolation is set when every connection uses its own proxy credentials.
+	StreamIsolation bool
+	// Only is set when connections never fall back to clearnet.
+	Only bool
+	// Fallbacks is the number of connections and lookups that bypassed the proxy.
+	Fallbacks int64
+}
+
+// Enabled reports whether a proxy is configured.
+func (s ProxyStatus) Enabled() bool {
+	return s.Address != ""
+}
+
+// proxyRouter routes neutrino connections and name lookups through a SOCKS5 proxy.
+type proxyRouter struct {
+	proxy     *socks.Proxy
+	only      bool
+	fallbacks atomic.Int64
+}
+
+func newProxyRouter(cfg *config.Config) (*proxyRouter, error) {
+	if cfg.Proxy == "" {
+		if cfg.ProxyOnly {
+			return nil, fmt.Errorf("proxy_only is set but no proxy is configured")
+		}
+		return nil, nil
+	}
+	if _, _, err := net.SplitHostPort(cfg.Proxy); err != nil {
+		return nil, fmt.Errorf("invalid proxy address %q: %w", cfg.Proxy, err)
+	}
+	return &proxyRouter{
+		proxy: &socks.Proxy{
+			Addr:         cfg.Proxy,
+			TorIsolation: cfg.ProxyStreamIsolation,
+		},
+		only: cfg.ProxyOnly,
+	}, nil
+}
+
+// Dial connects to addr through the proxy. Unless the router is proxy-only, it falls
+// back to a direct connection for clearnet addresses the proxy failed to reach.
+func (r *proxyRouter) Dial(addr net.Addr) (net.Conn, error) {
+	conn, err := r.proxy.Dial("tcp", addr.String())
+	if err == nil || r.only || addr.Network() == "onion" {
+		return conn, err
+	}
+	r.fallbacks.Add(1)
+	return net.Dial(addr.Network(), addr.String())
+}
+
+// LookupIP resolves host using the Tor SOCKS resolve extension so DNS queries
+// do not leak outside the proxy.
+func (r *proxyRouter) LookupIP(host string) ([]net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		return []net.IP{ip}, nil
+	}
+	ips, err := connmgr.TorLookupIP(host, r.proxy.Addr)
+	if err == nil || r.only {
+		return ips, err
+	}
+	r.fallbacks.Add(1)
+	return net.LookupIP(host)
+}
+
+func (r *proxyRouter) Status() ProxyStatus {
+	if r == nil {
+		return ProxyStatus{}
+	}
+	return ProxyStatus{
+		Address:         r.proxy.Addr,
+		StreamIsolation: r.proxy.TorIsolation,
+		Only:            r.only,
+		Fallbacks:       r.fallbacks.Load(),
+	}
+}
@@ -0,0 +1,85 @@
+package neutrino
+
+import (
+	"net"
+	"testing"
+
+	"github.com/satelliondao/satellion/config"
+	"github.com/stretchr/testify/assert"
+)
+
+// closedAddr returns a local address nothing listens on.
+func closedAddr(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+	return addr
+}
+
+func listenPeer(t *testing.T) *net.TCPAddr {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	return l.Addr().(*net.TCPAddr)
+}
+
+type testOnionAddr string
+
+func (a testOnionAddr) Network() string { return "onion" }
+func (a testOnionAddr) String() string  { return string(a) }
+
+func TestNewProxyRouter_Disabled(t *testing.T) {
+	router, err := newProxyRouter(&config.Config{})
+	assert.NoError(t, err)
+	assert.Nil(t, router)
+	assert.False(t, router.Status().Enabled())
+}
+
+func TestNewProxyRouter_ProxyOnlyRequiresProxy(t *testing.T) {
+	_, err := newProxyRouter(&config.Config{ProxyOnly: true})
+	assert.Error(t, err)
+}
+
+func TestProxyRouter_FallsBackToClearnet(t *testing.T) {
+	router, err := newProxyRouter(&config.Config{Proxy: closedAddr(t), ProxyStreamIsolation: true})
+	assert.NoError(t, err)
+	conn, err := router.Dial(listenPeer(t))
+	assert.NoError(t, err)
+	conn.Close()
+	status := router.Status()
+	assert.True(t, status.Enabled())
+	assert.True(t, status.StreamIsolation)
+	assert.Equal(t, int64(1), status.Fallbacks)
+}
+
+func TestProxyRouter_ProxyOnlyNeverFallsBack(t *testing.T) {
+	router, err := newProxyRouter(&config.Config{Proxy: closedAddr(t), ProxyOnly: true})
+	assert.NoError(t, err)
+	_, err = router.Dial(listenPeer(t))
+	assert.Error(t, err)
+	_, err = router.LookupIP("localhost")
+	assert.Error(t, err)
+	assert.Equal(t, int64(0), router.Status().Fallbacks)
+}
+
+func TestProxyRouter_OnionNeverFallsBack(t *testing.T) {
+	router, err := newProxyRouter(&config.Config{Proxy: closedAddr(t)})
+	assert.NoError(t, err)
+	_, err = router.Dial(testOnionAddr("exampleonionaddress.onion:8333"))
+	assert.Error(t, err)
+	assert.Equal(t, int64(0), router.Status().Fallbacks)
+}
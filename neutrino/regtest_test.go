@@ -0,0 +1,222 @@
+//go:build integration
+
+package neutrino
+
+import (
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/integration/rpctest"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satelliondao/satellion/coinselect"
+	"github.com/satelliondao/satellion/config"
+	"github.com/satelliondao/satellion/spend"
+	"github.com/satelliondao/satellion/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const regtestTimeout = 2 * time.Minute
+
+// setupRegtest starts a local btcd node in regtest mode with compact filters enabled
+// and a neutrino chain connected to it as the only peer.
+func setupRegtest(t *testing.T) (*rpctest.Harness, *Chain) {
+	harness, err := rpctest.New(&chaincfg.RegressionNetParams, nil, nil, "")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = harness.TearDown() })
+	// Generates a chain with mature coinbase outputs so the harness wallet can fund payments.
+	require.NoError(t, harness.SetUp(true, 25))
+
+	quorum := 1
+	cfg := &config.Config{
+		Peers:              []string{harness.P2PAddress()},
+		MinPeers:           1,
+		SyncTimeoutMinutes: 30,
+		Network:            "regtest",
+		DataDir:            t.TempDir(),
+		FilterHeaderQuorum: &quorum,
+	}
+	chain, err := NewChain(cfg)
+	require.NoError(t, err)
+	t.Cleanup(chain.Stop)
+
+	done := make(chan error, 1)
+	go func() { done <- chain.Syncronize() }()
+	select {
+	case err := <-done:
+		require.NoError(t, err)
+	case <-time.After(regtestTimeout):
+		t.Fatal("timed out waiting for initial sync")
+	}
+	return harness, chain
+}
+
+// waitForTip blocks until the neutrino chain has caught up with the harness node.
+func waitForTip(t *testing.T, harness *rpctest.Harness, chain *Chain) {
+	_, height, err := harness.Client.GetBestBlock()
+	require.NoError(t, err)
+	deadline := time.Now().Add(regtestTimeout)
+	for time.Now().Before(deadline) {
+		block, err := chain.BestBlock()
+		if err == nil && block.Height >= height && chain.IsSynced() {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for height %d", height)
+}
+
+// activateTaproot mines the three signalling windows after which segwit and taproot,
+// which the wallet spends with, are active on regtest.
+func activateTaproot(t *testing.T, harness *rpctest.Harness) {
+	_, height, err := harness.Client.GetBestBlock()
+	require.NoError(t, err)
+	window := int32(chaincfg.RegressionNetParams.MinerConfirmationWindow)
+	if missing := 3*window - height; missing > 0 {
+		_, err = harness.Client.Generate(uint32(missing))
+		require.NoError(t, err)
+	}
+}
+
+func mineToScript(t *testing.T, harness *rpctest.Harness, script []byte) {
+	out := wire.TxOut{Value: 50_000, PkScript: script}
+	_, err := harness.GenerateAndSubmitBlockWithCustomCoinbaseOutputs(nil, -1, time.Time{}, []wire.TxOut{out})
+	require.NoError(t, err)
+}
+
+func TestRegtest_SyncScanAndReceive(t *testing.T) {
+	harness, chain := setupRegtest(t)
+	w := wallet.New(&seed, passphrase, "", &chaincfg.RegressionNetParams)
+	w.CreatedAt = time.Now().Add(-time.Hour)
+	scanner := NewBalance(chain)
+
+	first, err := w.ReceiveAddress()
+	require.NoError(t, err)
+	firstScript, err := first.DeriveTaprootScriptPubKey()
+	require.NoError(t, err)
+	mineToScript(t, harness, firstScript)
+	waitForTip(t, harness, chain)
+
+	// Compact filters may report false positives, so matches are counted relative to earlier scans.
+	mined, err := scanner.ScanLedger(w)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, mined.UtxoCount, uint64(1), "coinbase paying the first receive address")
+
+	second, err := w.DeriveTaprootAddress(0, 1)
+	require.NoError(t, err)
+	secondScript, err := second.DeriveTaprootScriptPubKey()
+	require.NoError(t, err)
+	_, err = harness.SendOutputs([]*wire.TxOut{wire.NewTxOut(100_000, secondScript)}, 10)
+	require.NoError(t, err)
+
+	unconfirmed, err := scanner.ScanLedger(w)
+	require.NoError(t, err)
+	assert.Equal(t, mined.UtxoCount, unconfirmed.UtxoCount, "unconfirmed payment must not be counted")
+
+	_, err = harness.Client.Generate(1)
+	require.NoError(t, err)
+	waitForTip(t, harness, chain)
+
+	confirmed, err := scanner.ScanLedger(w)
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, confirmed.UtxoCount, mined.UtxoCount+1, "confirmed payment to the second receive address")
+}
+
+func TestRegtest_SendAndConfirm(t *testing.T) {
+	harness, chain := setupRegtest(t)
+	activateTaproot(t, harness)
+	w := wallet.New(&seed, passphrase, "", &chaincfg.RegressionNetParams)
+	w.CreatedAt = time.Now().Add(-time.Hour)
+	scanner := NewBalance(chain)
+
+	receive, err := w.ReceiveAddress()
+	require.NoError(t, err)
+	require.True(t, receive.Address.IsForNet(&chaincfg.RegressionNetParams), receive.Address.String())
+	script, err := receive.DeriveTaprootScriptPubKey()
+	require.NoError(t, err)
+	_, err = harness.SendOutputs([]*wire.TxOut{wire.NewTxOut(200_000, script)}, 10)
+	require.NoError(t, err)
+	_, err = harness.Client.Generate(1)
+	require.NoError(t, err)
+	waitForTip(t, harness, chain)
+	funded, err := scanner.ScanLedger(w)
+	require.NoError(t, err)
+	require.NotEmpty(t, funded.Utxos)
+
+	sel, err := coinselect.Select(coinselect.Request{Utxos: funded.Utxos, Amount: 50_000, Outputs: 1, FeeRate: 2})
+	require.NoError(t, err)
+	to, err := harness.NewAddress()
+	require.NoError(t, err)
+	change, err := w.ChangeAddress()
+	require.NoError(t, err)
+	signed, err := spend.Sign(w, sel, []spend.Output{{Address: to.String(), Amount: 50_000}}, change.Address.String(), &chaincfg.RegressionNetParams)
+	require.NoError(t, err, "the change address is encoded for regtest")
+	tx, err := signed.MsgTx()
+	require.NoError(t, err)
+	require.NoError(t, chain.SendTransaction(tx))
+	hash := tx.TxHash()
+	require.Eventually(t, func() bool {
+		_, err := harness.Client.GetRawTransaction(&hash)
+		return err == nil
+	}, regtestTimeout, 100*time.Millisecond, "the node accepts the payment")
+
+	_, err = harness.Client.Generate(1)
+	require.NoError(t, err)
+	waitForTip(t, harness, chain)
+	tipHash, err := harness.Client.GetBestBlockHash()
+	require.NoError(t, err)
+	block, err := harness.Client.GetBlock(tipHash)
+	require.NoError(t, err)
+	var mined bool
+	for _, btx := range block.Transactions {
+		mined = mined || btx.TxHash() == hash
+	}
+	assert.True(t, mined, "the payment confirms in the next block")
+
+	after, err := scanner.ScanLedger(w)
+	require.NoError(t, err)
+	var returned bool
+	for _, u := range after.Utxos {
+		for _, in := range sel.Inputs {
+			assert.NotEqual(t, in.OutPoint, u.OutPoint, "spent inputs leave the ledger")
+		}
+		if u.OutPoint.Hash == hash && u.Change {
+			returned = true
+			assert.Equal(t, sel.Change, u.Value)
+		}
+	}
+	assert.True(t, returned, "the change comes back to the wallet")
+}
+
+func TestRegtest_Peers(t *testing.T) {
+	harness, chain := setupRegtest(t)
+	peers := chain.Peers()
+	require.Len(t, peers, 1)
+	assert.Equal(t, harness.P2PAddress(), peers[0].Addr)
+	assert.True(t, peers[0].Connected)
+	assert.NotEmpty(t, peers[0].UserAgent)
+
+	require.NoError(t, chain.BanPeer(harness.P2PAddress()))
+	assert.Eventually(t, func() bool {
+		return chain.ConnectedCount() == 0
+	}, regtestTimeout, 100*time.Millisecond)
+	banned := chain.Peers()
+	require.Len(t, banned, 1)
+	assert.True(t, banned[0].Banned)
+	assert.False(t, banned[0].Connected)
+}
+
+func TestRegtest_FilterHeadersVerifiedAcrossPeers(t *testing.T) {
+	harness, chain := setupRegtest(t)
+	waitForTip(t, harness, chain)
+	check, err := chain.VerifyFilterHeaders()
+	require.NoError(t, err)
+	assert.True(t, check.Trusted())
+	assert.Equal(t, []string{harness.P2PAddress()}, check.Agreed)
+	assert.Empty(t, check.Disagreed)
+
+	last, disagreements := chain.FilterHeaderStatus()
+	assert.Equal(t, check, last)
+	assert.Empty(t, disagreements)
+}
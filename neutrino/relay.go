@@ -0,0 +1,250 @@
+package neutrino
+
+import (
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/peer"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// MempoolPeers is how many peers of the chain service are also asked to relay
+// transactions, over connections of their own: neutrino tells its peers not to.
+const MempoolPeers = 3
+
+const (
+	relayInterval         = 30 * time.Second
+	evictionCheckInterval = 10 * time.Minute
+	// unconditionalRelayDelay is how long Bitcoin Core keeps a transaction in its mempool
+	// before serving it to peers it was not announced to.
+	unconditionalRelayDelay = 2 * time.Minute
+	relayDialTimeout        = 30 * time.Second
+	// maxSeenTransactions bounds the txids remembered to avoid downloading a transaction
+	// announced by several peers more than once.
+	maxSeenTransactions = 100_000
+)
+
+// relay downloads the transactions announced by a few peers of the chain service and
+// feeds them to the mempool. Pending transactions are asked for again from time to time:
+// when no peer has them any more, they were evicted.
+type relay struct {
+	chain  *Chain
+	pool   *Mempool
+	quit   chan struct{}
+	once   sync.Once
+	mu     sync.Mutex
+	peers  map[string]*peer.Peer
+	seen   map[chainhash.Hash]struct{}
+	checks map[chainhash.Hash]*evictionCheck
+}
+
+// evictionCheck counts the peers asked for a pending transaction and those which
+// answered they do not have it.
+type evictionCheck struct {
+	asked, missing int
+}
+
+func newRelay(chain *Chain, pool *Mempool) *relay {
+	return &relay{
+		chain:  chain,
+		pool:   pool,
+		quit:   make(chan struct{}),
+		peers:  make(map[string]*peer.Peer),
+		seen:   make(map[chainhash.Hash]struct{}),
+		checks: make(map[chainhash.Hash]*evictionCheck),
+	}
+}
+
+func (r *relay) run() {
+	connect := time.NewTicker(relayInterval)
+	defer connect.Stop()
+	evictions := time.NewTicker(evictionCheckInterval)
+	defer evictions.Stop()
+	for {
+		r.connect()
+		select {
+		case <-connect.C:
+		case <-evictions.C:
+			r.checkEvictions()
+		case <-r.quit:
+			r.mu.Lock()
+			for _, p := range r.peers {
+				p.Disconnect()
+			}
+			r.mu.Unlock()
+			return
+		}
+	}
+}
+
+func (r *relay) stop() {
+	r.once.Do(func() { close(r.quit) })
+}
+
+// connect opens relay connections to peers of the chain service until MempoolPeers are
+// connected.
+func (r *relay) connect() {
+	r.mu.Lock()
+	for addr, p := range r.peers {
+		if !p.Connected() {
+			delete(r.peers, addr)
+		}
+	}
+	missing := MempoolPeers - len(r.peers)
+	r.mu.Unlock()
+	for _, sp := range r.chain.neutrino.Peers() {
+		if missing <= 0 {
+			return
+		}
+		addr := sp.Addr()
+		r.mu.Lock()
+		_, ok := r.peers[addr]
+		r.mu.Unlock()
+		if ok {
+			continue
+		}
+		if err := r.open(addr); err != nil {
+			continue
+		}
+		missing--
+	}
+}
+
+func (r *relay) open(addr string) error {
+	params, err := r.chain.config.ChainParams()
+	if err != nil {
+		return err
+	}
+	p, err := peer.NewOutboundPeer(r.peerConfig(params), addr)
+	if err != nil {
+		return err
+	}
+	conn, err := r.dial(addr)
+	if err != nil {
+		return err
+	}
+	p.AssociateConnection(conn)
+	r.mu.Lock()
+	r.peers[addr] = p
+	r.mu.Unlock()
+	return nil
+}
+
+// peerConfig is the configuration of relay connections.
+func (r *relay) peerConfig(params *chaincfg.Params) *peer.Config {
+	return &peer.Config{
+		UserAgentName:    "satellion",
+		UserAgentVersion: "mempool",
+		ChainParams:      params,
+		Listeners: peer.MessageListeners{
+			OnInv:      r.onInv,
+			OnTx:       r.onTx,
+			OnNotFound: r.onNotFound,
+		},
+	}
+}
+
+func (r *relay) dial(addr string) (net.Conn, error) {
+	if r.chain.proxy != nil {
+		return r.chain.proxy.Dial(peerAddr(addr))
+	}
+	return net.DialTimeout("tcp", addr, relayDialTimeout)
+}
+
+// peerAddr is the host:port of a peer.
+type peerAddr string
+
+func (a peerAddr) Network() string {
+	host, _, _ := net.SplitHostPort(string(a))
+	if strings.HasSuffix(host, ".onion") {
+		return "onion"
+	}
+	return "tcp"
+}
+
+func (a peerAddr) String() string {
+	return string(a)
+}
+
+// onInv downloads the announced transactions not seen yet, without their witnesses.
+func (r *relay) onInv(p *peer.Peer, msg *wire.MsgInv) {
+	get := wire.NewMsgGetData()
+	r.mu.Lock()
+	if len(r.seen) > maxSeenTransactions {
+		r.seen = make(map[chainhash.Hash]struct{})
+	}
+	for _, inv := range msg.InvList {
+		if inv.Type != wire.InvTypeTx && inv.Type != wire.InvTypeWitnessTx {
+			continue
+		}
+		if _, ok := r.seen[inv.Hash]; ok {
+			continue
+		}
+		r.seen[inv.Hash] = struct{}{}
+		if err := get.AddInvVect(wire.NewInvVect(wire.InvTypeTx, &inv.Hash)); err != nil {
+			break
+		}
+	}
+	r.mu.Unlock()
+	if len(get.InvList) > 0 {
+		p.QueueMessage(get, nil)
+	}
+}
+
+func (r *relay) onTx(_ *peer.Peer, tx *wire.MsgTx) {
+	r.mu.Lock()
+	delete(r.checks, tx.TxHash())
+	r.mu.Unlock()
+	r.pool.Add(tx, time.Now())
+}
+
+func (r *relay) onNotFound(_ *peer.Peer, msg *wire.MsgNotFound) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, inv := range msg.InvList {
+		check, ok := r.checks[inv.Hash]
+		if !ok {
+			continue
+		}
+		if check.missing++; check.missing >= check.asked {
+			delete(r.checks, inv.Hash)
+			r.pool.Evict(inv.Hash.String(), time.Now())
+		}
+	}
+}
+
+// checkEvictions asks every relay peer for the pending transactions they had time to
+// accept.
+func (r *relay) checkEvictions() {
+	hashes := r.pool.pendingBefore(time.Now().Add(-unconditionalRelayDelay))
+	if len(hashes) == 0 {
+		return
+	}
+	get := wire.NewMsgGetData()
+	for i := range hashes {
+		if err := get.AddInvVect(wire.NewInvVect(wire.InvTypeTx, &hashes[i])); err != nil {
+			break
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var connected []*peer.Peer
+	for _, p := range r.peers {
+		if p.Connected() {
+			connected = append(connected, p)
+		}
+	}
+	if len(connected) == 0 {
+		return
+	}
+	for _, h := range hashes {
+		r.checks[h] = &evictionCheck{asked: len(connected)}
+	}
+	for _, p := range connected {
+		p.QueueMessage(get, nil)
+	}
+}
@@ -0,0 +1,85 @@
+package neutrino
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/peer"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// relayPeers connects a relay peer of r to a remote peer over loopback. The remote
+// answers every getdata with tx.
+func relayPeers(t *testing.T, r *relay, tx *wire.MsgTx) (*peer.Peer, chan *wire.MsgGetData) {
+	params := &chaincfg.RegressionNetParams
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { listener.Close() })
+	requested := make(chan *wire.MsgGetData, 4)
+	// Both peers live in this process, so each takes the nonce of the other for its own.
+	remote := peer.NewInboundPeer(&peer.Config{ChainParams: params, AllowSelfConns: true, Listeners: peer.MessageListeners{
+		OnGetData: func(p *peer.Peer, msg *wire.MsgGetData) {
+			requested <- msg
+			p.QueueMessage(tx, nil)
+		},
+	}})
+	go func() {
+		if conn, err := listener.Accept(); err == nil {
+			remote.AssociateConnection(conn)
+		}
+	}()
+	cfg := r.peerConfig(params)
+	cfg.AllowSelfConns = true
+	local, err := peer.NewOutboundPeer(cfg, listener.Addr().String())
+	require.NoError(t, err)
+	conn, err := net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, err)
+	local.AssociateConnection(conn)
+	t.Cleanup(func() {
+		local.Disconnect()
+		remote.Disconnect()
+	})
+	require.Eventually(t, func() bool {
+		return local.VerAckReceived() && remote.VerAckReceived()
+	}, 5*time.Second, 10*time.Millisecond)
+	return remote, requested
+}
+
+func TestRelay_TracksAnnouncedTransactions(t *testing.T) {
+	pool, _, scripts, _ := watchedMempool(t)
+	r := newRelay(&Chain{}, pool)
+	incoming := spending([]wire.OutPoint{{Hash: [32]byte{9}}}, wire.NewTxOut(20_000, scripts[1]))
+	remote, requested := relayPeers(t, r, incoming)
+
+	hash := incoming.TxHash()
+	inv := wire.NewMsgInv()
+	require.NoError(t, inv.AddInvVect(wire.NewInvVect(wire.InvTypeWitnessTx, &hash)))
+	require.NoError(t, inv.AddInvVect(wire.NewInvVect(wire.InvTypeBlock, &hash)))
+	remote.QueueMessage(inv, nil)
+
+	select {
+	case msg := <-requested:
+		require.Len(t, msg.InvList, 1, "blocks are not asked for")
+		assert.Equal(t, wire.InvTypeTx, msg.InvList[0].Type, "downloaded without witnesses")
+		assert.Equal(t, hash, msg.InvList[0].Hash)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the announced transaction was not requested")
+	}
+	require.Eventually(t, func() bool { return len(pool.Entries()) == 1 }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, hash.String(), pool.Entries()[0].Txid)
+	in, out := pool.Pending()
+	assert.Equal(t, btcutil.Amount(20_000), in, "unconfirmed for the watched wallet")
+	assert.Zero(t, out)
+
+	remote.QueueMessage(inv, nil)
+	select {
+	case <-requested:
+		t.Fatal("a transaction seen before is not downloaded again")
+	case <-time.After(200 * time.Millisecond):
+	}
+}
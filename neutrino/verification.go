@@ -0,0 +1,402 @@
+package neutrino
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/gcs"
+	"github.com/btcsuite/btcd/btcutil/gcs/builder"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/neutrino"
+	"github.com/lightninglabs/neutrino/banman"
+)
+
+const (
+	// filterHeaderQueryTimeout bounds how long a single peer may take to serve a filter header.
+	filterHeaderQueryTimeout = 10 * time.Second
+	// filterRetryMin is how long the failure of a check is returned to filter requests
+	// before peers are asked again, doubled after each failure up to filterRetryMax.
+	filterRetryMin = 30 * time.Second
+	filterRetryMax = 10 * time.Minute
+)
+
+var ErrFilterHeaderQuorum = errors.New("filter header was not confirmed by enough peers")
+
+// FilterTrustError is returned for filters above the filter headers confirmed by the
+// last cross-peer check. It matches ErrFilterHeaderQuorum.
+type FilterTrustError struct {
+	// Reason tells the user why the filters are not trusted.
+	Reason string
+}
+
+func (e *FilterTrustError) Error() string {
+	return "compact filters are not trusted: " + e.Reason
+}
+
+func (e *FilterTrustError) Is(target error) bool {
+	return target == ErrFilterHeaderQuorum
+}
+
+// FilterHeaderCheck is the outcome of comparing our filter header chain tip with connected peers.
+// Filter headers commit to every previous header, so agreement at the tip covers all filters below it.
+// A single honest peer is enough to expose wrong filters: every disagreement is settled
+// by the block where the headers diverge, never by counting peers.
+type FilterHeaderCheck struct {
+	Height     int32
+	Header     chainhash.Hash
+	Agreed     []string
+	Disagreed  []string
+	Unanswered []string
+	// Unsettled lists the dissenting peers whose disagreement could not be settled, and
+	// Refuted tells whether a block proved one of our filters wrong.
+	Unsettled []string
+	Refuted   bool
+	Quorum    int
+	CheckedAt time.Time
+}
+
+// Trusted reports whether enough peers agreed and every disagreement was settled by a
+// block in favour of our filters.
+func (c *FilterHeaderCheck) Trusted() bool {
+	return len(c.Agreed) >= c.Quorum && !c.Refuted && len(c.Unsettled) == 0
+}
+
+// Problem tells why the check does not trust our filters, empty when it does.
+func (c *FilterHeaderCheck) Problem() string {
+	switch {
+	case c.Refuted:
+		return fmt.Sprintf("a block proved our filter headers at height %d wrong", c.Height)
+	case len(c.Unsettled) > 0:
+		return fmt.Sprintf("%s disagreed and could not be checked against a block", strings.Join(c.Unsettled, ", "))
+	case len(c.Agreed) < c.Quorum:
+		return fmt.Sprintf("%d of the %d required peers confirmed our filter headers, %d did not answer",
+			len(c.Agreed), c.Quorum, len(c.Unanswered))
+	}
+	return ""
+}
+
+// FilterDisagreement records a peer that served a filter header different from ours.
+type FilterDisagreement struct {
+	Peer   string
+	Height int32
+	Ours   chainhash.Hash
+	Theirs chainhash.Hash
+	// Diverged is the first height at which the filter headers of the peer differ from
+	// ours. Both filters of that block are checked against the block itself.
+	Diverged int32
+	// Settled tells whether our filter of the diverging block was checked, and OursWrong
+	// whether it misses scripts of the block. Reason is why it was not settled.
+	Settled   bool
+	OursWrong bool
+	Reason    string
+	// TheirsWrong tells whether the filter of the peer misses scripts of the block or does
+	// not match its own filter header. Only such peers are banned.
+	TheirsWrong bool
+	Banned      bool
+	At          time.Time
+}
+
+type filterVerifier struct {
+	mu             sync.Mutex
+	quorum         int
+	verifiedHeight int32
+	last           *FilterHeaderCheck
+	disagreements  []FilterDisagreement
+	// checking lets a single check run at a time, so that concurrent filter requests
+	// share its outcome instead of querying every peer again.
+	checking sync.Mutex
+	failure  error
+	retryAt  time.Time
+	backoff  time.Duration
+}
+
+func newFilterVerifier(quorum int) *filterVerifier {
+	return &filterVerifier{quorum: quorum, verifiedHeight: -1}
+}
+
+// FilterHeaderStatus returns the latest cross-peer filter header check, if any,
+// and every disagreement seen so far.
+func (s *Chain) FilterHeaderStatus() (*FilterHeaderCheck, []FilterDisagreement) {
+	s.verifier.mu.Lock()
+	defer s.verifier.mu.Unlock()
+	return s.verifier.last, append([]FilterDisagreement(nil), s.verifier.disagreements...)
+}
+
+// VerifyFilterHeaders asks every connected peer for the filter header at our filter
+// header tip and compares it with ours. Disagreements are settled with the block where
+// the headers diverge, and peers whose filter of that block is wrong are banned.
+func (s *Chain) VerifyFilterHeaders() (*FilterHeaderCheck, error) {
+	if !s.started.Load() {
+		return nil, fmt.Errorf("chain service is not running")
+	}
+	ours, height, err := s.neutrino.RegFilterHeaders.ChainTip()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get filter header tip: %w", err)
+	}
+	blockHash, err := s.neutrino.GetBlockHash(int64(height))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get block hash at height %d: %w", height, err)
+	}
+	check := &FilterHeaderCheck{
+		Height:    int32(height),
+		Header:    *ours,
+		Quorum:    s.verifier.quorum,
+		CheckedAt: time.Now(),
+	}
+	var disagreements []FilterDisagreement
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, sp := range s.neutrino.Peers() {
+		wg.Add(1)
+		go func(sp *neutrino.ServerPeer) {
+			defer wg.Done()
+			theirs, err := queryFilterHeader(sp, height, blockHash)
+			if err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+				check.Unanswered = append(check.Unanswered, sp.Addr())
+				return
+			}
+			if theirs.header == *ours {
+				mu.Lock()
+				defer mu.Unlock()
+				check.Agreed = append(check.Agreed, sp.Addr())
+				return
+			}
+			d := FilterDisagreement{
+				Peer:   sp.Addr(),
+				Height: int32(height),
+				Ours:   *ours,
+				Theirs: theirs.header,
+				At:     check.CheckedAt,
+			}
+			s.settle(sp, &d, height, theirs)
+			if d.TheirsWrong {
+				d.Banned = s.neutrino.BanPeer(d.Peer, banman.InvalidFilterHeader) == nil
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			check.Disagreed = append(check.Disagreed, d.Peer)
+			check.Refuted = check.Refuted || d.OursWrong
+			if !d.Settled {
+				check.Unsettled = append(check.Unsettled, d.Peer)
+			}
+			disagreements = append(disagreements, d)
+		}(sp)
+	}
+	wg.Wait()
+
+	s.verifier.mu.Lock()
+	defer s.verifier.mu.Unlock()
+	s.verifier.last = check
+	s.verifier.disagreements = append(s.verifier.disagreements, disagreements...)
+	if check.Trusted() && check.Height > s.verifier.verifiedHeight {
+		s.verifier.verifiedHeight = check.Height
+	}
+	return check, nil
+}
+
+// ensureFilterTrusted verifies filter headers across peers when the block at
+// blockHash is above the last verified height.
+func (s *Chain) ensureFilterTrusted(blockHash chainhash.Hash) error {
+	if s.verifier.quorum <= 0 {
+		return nil
+	}
+	height, err := s.neutrino.GetBlockHeight(&blockHash)
+	if err != nil {
+		return err
+	}
+	if done, err := s.verifier.cached(height, time.Now()); done {
+		return err
+	}
+	s.verifier.checking.Lock()
+	defer s.verifier.checking.Unlock()
+	if done, err := s.verifier.cached(height, time.Now()); done {
+		return err
+	}
+	check, err := s.VerifyFilterHeaders()
+	if err == nil {
+		if problem := check.Problem(); problem != "" {
+			err = &FilterTrustError{Reason: problem}
+		} else if height > check.Height {
+			err = &FilterTrustError{Reason: fmt.Sprintf("our filter headers only reach height %d", check.Height)}
+		}
+	}
+	s.verifier.record(err, time.Now())
+	return err
+}
+
+// cached reports whether the filter of the block at height needs no new check: it is
+// verified, or the last check failed less than a back-off ago and err is its failure.
+func (v *filterVerifier) cached(height int32, now time.Time) (bool, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if height <= v.verifiedHeight {
+		return true, nil
+	}
+	if v.failure != nil && now.Before(v.retryAt) {
+		return true, v.failure
+	}
+	return false, nil
+}
+
+// record keeps the outcome of a check, backing off further after each failure.
+func (v *filterVerifier) record(err error, now time.Time) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if err == nil {
+		v.failure, v.backoff = nil, 0
+		return
+	}
+	v.backoff = min(max(2*v.backoff, filterRetryMin), filterRetryMax)
+	v.failure, v.retryAt = err, now.Add(v.backoff)
+}
+
+// settle finds the first height at which the filter headers of sp, which served theirs
+// at tip, diverge from ours, and checks both filters of that block against the block.
+// A filter that misses a script of the block is wrong, however many peers serve it.
+func (s *Chain) settle(sp *neutrino.ServerPeer, d *FilterDisagreement, tip uint32, theirs *cfHeader) {
+	height, theirs, err := s.findDivergence(sp, tip, theirs)
+	if err != nil {
+		d.Reason = err.Error()
+		return
+	}
+	d.Diverged = int32(height)
+	blockHash, err := s.neutrino.GetBlockHash(int64(height))
+	if err != nil {
+		d.Reason = fmt.Sprintf("no block hash at height %d: %v", height, err)
+		return
+	}
+	block, err := s.neutrino.GetBlock(*blockHash)
+	if err != nil {
+		d.Reason = fmt.Sprintf("block %d could not be fetched: %v", height, err)
+		return
+	}
+	if theirFilter, err := queryFilter(sp, height, blockHash); err == nil {
+		d.TheirsWrong = filterWrong(theirFilter, theirs.filterHash, block)
+	}
+	ours, err := s.neutrino.GetCFilter(*blockHash, wire.GCSFilterRegular)
+	if err != nil {
+		d.Reason = fmt.Sprintf("our filter of block %d could not be fetched: %v", height, err)
+		return
+	}
+	oursHash, err := builder.GetFilterHash(ours)
+	if err != nil {
+		d.Reason = err.Error()
+		return
+	}
+	d.Settled = true
+	d.OursWrong = filterWrong(ours, oursHash, block)
+}
+
+// findDivergence returns the first height up to tip at which sp serves a filter header
+// different from ours, and the header it serves there. Filter headers chain, so an
+// honest peer agrees with ours below that height and disagrees above it.
+func (s *Chain) findDivergence(sp *neutrino.ServerPeer, tip uint32, theirs *cfHeader) (uint32, *cfHeader, error) {
+	lo, hi := int64(-1), int64(tip)
+	for hi-lo > 1 {
+		mid := (lo + hi) / 2
+		ours, err := s.neutrino.RegFilterHeaders.FetchHeaderByHeight(uint32(mid))
+		if err != nil {
+			return 0, nil, err
+		}
+		blockHash, err := s.neutrino.GetBlockHash(mid)
+		if err != nil {
+			return 0, nil, err
+		}
+		at, err := queryFilterHeader(sp, uint32(mid), blockHash)
+		if err != nil {
+			return 0, nil, err
+		}
+		if at.header == *ours {
+			lo = mid
+		} else {
+			hi, theirs = mid, at
+		}
+	}
+	if hi > 0 {
+		prev, err := s.neutrino.RegFilterHeaders.FetchHeaderByHeight(uint32(hi - 1))
+		if err != nil {
+			return 0, nil, err
+		}
+		if theirs.prev != *prev {
+			return 0, nil, fmt.Errorf("peer %s served filter headers that do not chain", sp.Addr())
+		}
+	}
+	return uint32(hi), theirs, nil
+}
+
+// filterWrong reports whether filter, committed to by filterHash, fails to match a
+// script that block creates or spends, or does not hash to filterHash.
+func filterWrong(filter *gcs.Filter, filterHash chainhash.Hash, block *btcutil.Block) bool {
+	hash, err := builder.GetFilterHash(filter)
+	if err != nil || hash != filterHash {
+		return true
+	}
+	_, err = neutrino.VerifyBasicBlockFilter(filter, block)
+	return err != nil
+}
+
+// cfHeader is a filter header served by a peer, with the filter hash and previous
+// header it was built from.
+type cfHeader struct {
+	header     chainhash.Hash
+	filterHash chainhash.Hash
+	prev       chainhash.Hash
+}
+
+// queryFilterHeader requests the regular filter header of the block at height from sp.
+func queryFilterHeader(sp *neutrino.ServerPeer, height uint32, blockHash *chainhash.Hash) (*cfHeader, error) {
+	msgs, cancel := sp.SubscribeRecvMsg()
+	defer cancel()
+	sp.QueueMessage(wire.NewMsgGetCFHeaders(wire.GCSFilterRegular, height, blockHash), nil)
+	timeout := time.After(filterHeaderQueryTimeout)
+	for {
+		select {
+		case msg := <-msgs:
+			resp, ok := msg.(*wire.MsgCFHeaders)
+			if !ok || resp.StopHash != *blockHash || resp.FilterType != wire.GCSFilterRegular {
+				continue
+			}
+			if len(resp.FilterHashes) != 1 {
+				return nil, fmt.Errorf("peer %s returned %d filter hashes", sp.Addr(), len(resp.FilterHashes))
+			}
+			return &cfHeader{
+				header:     chainhash.DoubleHashH(append(resp.FilterHashes[0][:], resp.PrevFilterHeader[:]...)),
+				filterHash: *resp.FilterHashes[0],
+				prev:       resp.PrevFilterHeader,
+			}, nil
+		case <-sp.OnDisconnect():
+			return nil, fmt.Errorf("peer %s disconnected", sp.Addr())
+		case <-timeout:
+			return nil, fmt.Errorf("peer %s did not serve filter header in time", sp.Addr())
+		}
+	}
+}
+
+// queryFilter requests the regular filter of the block at height from sp.
+func queryFilter(sp *neutrino.ServerPeer, height uint32, blockHash *chainhash.Hash) (*gcs.Filter, error) {
+	msgs, cancel := sp.SubscribeRecvMsg()
+	defer cancel()
+	sp.QueueMessage(wire.NewMsgGetCFilters(wire.GCSFilterRegular, height, blockHash), nil)
+	timeout := time.After(filterHeaderQueryTimeout)
+	for {
+		select {
+		case msg := <-msgs:
+			resp, ok := msg.(*wire.MsgCFilter)
+			if !ok || resp.BlockHash != *blockHash || resp.FilterType != wire.GCSFilterRegular {
+				continue
+			}
+			return gcs.FromNBytes(builder.DefaultP, builder.DefaultM, resp.Data)
+		case <-sp.OnDisconnect():
+			return nil, fmt.Errorf("peer %s disconnected", sp.Addr())
+		case <-timeout:
+			return nil, fmt.Errorf("peer %s did not serve filter in time", sp.Addr())
+		}
+	}
+}
@@ -0,0 +1,128 @@
+package neutrino
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/gcs"
+	"github.com/btcsuite/btcd/btcutil/gcs/builder"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFilterHeaderCheck_Trusted(t *testing.T) {
+	cases := []struct {
+		name      string
+		agreed    int
+		disagreed int
+		quorum    int
+		refuted   bool
+		unsettled int
+		trusted   bool
+	}{
+		{"quorum reached", 2, 0, 2, false, 0, true},
+		{"quorum not reached", 1, 0, 2, false, 0, false},
+		{"dissent settled for us", 2, 3, 2, false, 0, true},
+		{"refuted by a block", 3, 1, 2, true, 0, false},
+		{"unsettled dissent", 3, 1, 2, false, 1, false},
+		{"no peers", 0, 0, 1, false, 0, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			check := &FilterHeaderCheck{
+				Agreed:    make([]string, tc.agreed),
+				Disagreed: make([]string, tc.disagreed),
+				Unsettled: make([]string, tc.unsettled),
+				Refuted:   tc.refuted,
+				Quorum:    tc.quorum,
+			}
+			assert.Equal(t, tc.trusted, check.Trusted())
+		})
+	}
+}
+
+func TestEnsureFilterTrusted_Disabled(t *testing.T) {
+	chain := &Chain{verifier: newFilterVerifier(0)}
+	assert.NoError(t, chain.ensureFilterTrusted(chainhash.Hash{}))
+}
+
+func TestFilterHeaderStatus_Empty(t *testing.T) {
+	chain := &Chain{verifier: newFilterVerifier(2)}
+	check, disagreements := chain.FilterHeaderStatus()
+	assert.Nil(t, check)
+	assert.Empty(t, disagreements)
+}
+
+func TestFilterVerifier_BackOff(t *testing.T) {
+	v := newFilterVerifier(2)
+	now := time.Now()
+	done, err := v.cached(10, now)
+	assert.False(t, done, "nothing verified yet")
+	assert.NoError(t, err)
+
+	v.record(ErrFilterHeaderQuorum, now)
+	done, err = v.cached(10, now.Add(filterRetryMin-time.Second))
+	assert.True(t, done, "peers are not asked again right away")
+	assert.ErrorIs(t, err, ErrFilterHeaderQuorum)
+	done, _ = v.cached(10, now.Add(filterRetryMin))
+	assert.False(t, done)
+
+	v.record(ErrFilterHeaderQuorum, now)
+	done, _ = v.cached(10, now.Add(filterRetryMin))
+	assert.True(t, done, "the back-off doubles")
+	for range 10 {
+		v.record(ErrFilterHeaderQuorum, now)
+	}
+	assert.Equal(t, filterRetryMax, v.backoff)
+
+	v.record(nil, now)
+	v.verifiedHeight = 10
+	done, err = v.cached(10, now)
+	assert.True(t, done)
+	assert.NoError(t, err)
+	done, _ = v.cached(11, now)
+	assert.False(t, done, "a success clears the failure")
+}
+
+// paymentBlock returns a block with a coinbase paying coinbaseTo and a transaction
+// paying paid.
+func paymentBlock(t *testing.T, coinbaseTo, paid byte) *btcutil.Block {
+	script := func(b byte) []byte {
+		s, err := txscript.NewScriptBuilder().AddOp(txscript.OP_0).AddData(bytes.Repeat([]byte{b}, 20)).Script()
+		require.NoError(t, err)
+		return s
+	}
+	coinbase := wire.NewMsgTx(2)
+	coinbase.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: wire.MaxPrevOutIndex}, []byte{1, 1}, nil))
+	coinbase.AddTxOut(wire.NewTxOut(50, script(coinbaseTo)))
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{1}}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(40, script(paid)))
+	block := wire.NewMsgBlock(&wire.BlockHeader{})
+	require.NoError(t, block.AddTransaction(coinbase))
+	require.NoError(t, block.AddTransaction(tx))
+	return btcutil.NewBlock(block)
+}
+
+func TestFilterWrong(t *testing.T) {
+	block := paymentBlock(t, 1, 2)
+	filterHash := func(f *gcs.Filter) chainhash.Hash {
+		h, err := builder.GetFilterHash(f)
+		require.NoError(t, err)
+		return h
+	}
+	honest, err := builder.BuildBasicFilter(block.MsgBlock(), nil)
+	require.NoError(t, err)
+	assert.False(t, filterWrong(honest, filterHash(honest), block))
+	assert.True(t, filterWrong(honest, chainhash.Hash{1}, block), "the filter must match its filter header")
+
+	// Built with the same key, as the header is the same, but without the payment.
+	omitting, err := builder.BuildBasicFilter(paymentBlock(t, 1, 1).MsgBlock(), nil)
+	require.NoError(t, err)
+	assert.True(t, filterWrong(omitting, filterHash(omitting), block), "the payment is missing")
+}
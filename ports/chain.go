@@ -1,6 +1,7 @@
 package ports
 
 import (
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/btcutil/gcs"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/wire"
@@ -18,4 +19,6 @@ type Chain interface {
 	GetBlockHash(height int64) (*chainhash.Hash, error)
 	GetBlockHeader(hash *chainhash.Hash) (*wire.BlockHeader, error)
 	GetCFilter(hash chainhash.Hash) (*gcs.Filter, error)
+	GetBlock(hash chainhash.Hash) (*btcutil.Block, error)
+	SendTransaction(tx *wire.MsgTx) error
 }
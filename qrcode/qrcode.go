@@ -0,0 +1,132 @@
+// Package qrcode renders QR codes for terminals, with Unicode half blocks, and as PNG
+// images.
+package qrcode
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"rsc.io/qr"
+)
+
+// QuietZone is the white border, in modules, drawn around a code. The standard asks for
+// four; scanners cope with two, which saves space on small terminals.
+const QuietZone = 2
+
+// pngScale is the size in pixels of one module in exported PNG images.
+const pngScale = 8
+
+// Code is an encoded QR code.
+type Code struct {
+	code *qr.Code
+	text string
+}
+
+// Encode encodes text with medium error correction. Text made of digits, upper case
+// letters and " $%*+-./:" only is encoded in the denser alphanumeric mode.
+func Encode(text string) (*Code, error) {
+	c, err := qr.Encode(text, qr.M)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode QR code: %w", err)
+	}
+	return &Code{code: c, text: text}, nil
+}
+
+// Text returns the encoded text.
+func (c *Code) Text() string {
+	return c.text
+}
+
+// Size returns the width of the code in modules, quiet zone included.
+func (c *Code) Size() int {
+	return c.code.Size + 2*QuietZone
+}
+
+// Scale returns the largest number of terminal columns per module for which the code
+// fits in width columns and height rows, two modules tall per row. It returns 0 when
+// the code does not fit at all. A zero width or height means the size is unknown and
+// is not limited.
+func (c *Code) Scale(width, height int) int {
+	if width <= 0 || height <= 0 {
+		return 1
+	}
+	size, scale := c.Size(), 0
+	for next := 1; size*next <= width && (size*next+1)/2 <= height; next++ {
+		scale = next
+	}
+	return scale
+}
+
+// Render draws the code with half blocks, as large as fits in width columns and height
+// rows. Dark modules are drawn in the terminal background colour and light ones in the
+// foreground colour, so the code reads as dark on light on a dark terminal. It returns
+// "" when the terminal is too small.
+func (c *Code) Render(width, height int) string {
+	scale := c.Scale(width, height)
+	if scale == 0 {
+		return ""
+	}
+	n := c.Size() * scale
+	// light reports whether the pixel at (x, y) of the scaled code is light.
+	light := func(x, y int) bool {
+		if y >= n {
+			return true
+		}
+		return !c.code.Black(x/scale-QuietZone, y/scale-QuietZone)
+	}
+	var b strings.Builder
+	for y := 0; y < n; y += 2 {
+		for x := 0; x < n; x++ {
+			top, bottom := light(x, y), light(x, y+1)
+			switch {
+			case top && bottom:
+				b.WriteRune('█')
+			case top:
+				b.WriteRune('▀')
+			case bottom:
+				b.WriteRune('▄')
+			default:
+				b.WriteRune(' ')
+			}
+		}
+		if y+2 < n {
+			b.WriteByte('\n')
+		}
+	}
+	return b.String()
+}
+
+// PNG returns the code as a PNG image, with the standard quiet zone of four modules.
+func (c *Code) PNG() []byte {
+	scaled := *c.code
+	scaled.Scale = pngScale
+	return scaled.PNG()
+}
+
+// WritePNG writes the code as a PNG image to path, creating its directory. An existing
+// file is not overwritten.
+func (c *Code) WritePNG(path string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0o700); err != nil {
+		return err
+	}
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(c.PNG()); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// DefaultPath returns ~/.satellion/qr/<name>.png, where exported codes are written.
+func DefaultPath(name string) string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".satellion", "qr", name+".png")
+}
@@ -0,0 +1,81 @@
+package qrcode
+
+import (
+	"bytes"
+	"image/png"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testAddress = "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"
+
+// pixels turns a rendering back into rows of light (true) and dark pixels.
+func pixels(rendered string) [][]bool {
+	var rows [][]bool
+	for _, line := range strings.Split(rendered, "\n") {
+		var top, bottom []bool
+		for _, r := range line {
+			top = append(top, r == '█' || r == '▀')
+			bottom = append(bottom, r == '█' || r == '▄')
+		}
+		rows = append(rows, top, bottom)
+	}
+	return rows
+}
+
+func TestRender_MatchesModules(t *testing.T) {
+	c, err := Encode(strings.ToUpper(testAddress))
+	require.NoError(t, err)
+	for _, scale := range []int{1, 2} {
+		size := c.Size() * scale
+		px := pixels(c.Render(size, (size+1)/2))
+		require.GreaterOrEqual(t, len(px), size)
+		for y := 0; y < size; y++ {
+			require.Len(t, px[y], size)
+			for x := 0; x < size; x++ {
+				dark := c.code.Black(x/scale-QuietZone, y/scale-QuietZone)
+				require.Equal(t, !dark, px[y][x], "scale %d, pixel %d,%d", scale, x, y)
+			}
+		}
+	}
+}
+
+func TestScale(t *testing.T) {
+	c, err := Encode(strings.ToUpper(testAddress))
+	require.NoError(t, err)
+	size := c.Size()
+	assert.Equal(t, 1, c.Scale(0, 0), "unknown terminal size")
+	assert.Equal(t, 1, c.Scale(size, (size+1)/2))
+	assert.Equal(t, 2, c.Scale(3*size-1, 200))
+	assert.Equal(t, 2, c.Scale(500, size))
+	assert.Equal(t, 0, c.Scale(size-1, 200))
+	assert.Equal(t, 0, c.Scale(200, size/2-1))
+	assert.Empty(t, c.Render(size-1, 200))
+}
+
+func TestEncode_UpperCaseIsDenser(t *testing.T) {
+	lower, err := Encode("bitcoin:" + testAddress)
+	require.NoError(t, err)
+	upper, err := Encode(strings.ToUpper("bitcoin:" + testAddress))
+	require.NoError(t, err)
+	assert.Less(t, upper.Size(), lower.Size())
+	assert.Equal(t, "BITCOIN:"+strings.ToUpper(testAddress), upper.Text())
+}
+
+func TestWritePNG(t *testing.T) {
+	c, err := Encode(strings.ToUpper(testAddress))
+	require.NoError(t, err)
+	path := filepath.Join(t.TempDir(), "qr", "address.png")
+	require.NoError(t, c.WritePNG(path))
+	assert.Error(t, c.WritePNG(path), "existing files are not overwritten")
+
+	img, err := png.Decode(bytes.NewReader(c.PNG()))
+	require.NoError(t, err)
+	side := (c.code.Size + 8) * pngScale
+	assert.Equal(t, side, img.Bounds().Dx())
+	assert.Equal(t, side, img.Bounds().Dy())
+}
@@ -0,0 +1,26 @@
+package secret
+
+import "golang.org/x/sys/unix"
+
+// Harden disables core dumps of the process, so that secrets in memory do not end up on
+// disk when it crashes. It also keeps other processes of the same user from attaching.
+func Harden() error {
+	if err := unix.Setrlimit(unix.RLIMIT_CORE, &unix.Rlimit{Cur: 0, Max: 0}); err != nil {
+		return err
+	}
+	return unix.Prctl(unix.PR_SET_DUMPABLE, 0, 0, 0, 0)
+}
+
+// lock keeps the pages of b out of swap. It is best effort: RLIMIT_MEMLOCK is small on
+// most systems and failures are ignored.
+func lock(b []byte) {
+	if len(b) > 0 {
+		_ = unix.Mlock(b)
+	}
+}
+
+func unlock(b []byte) {
+	if len(b) > 0 {
+		_ = unix.Munlock(b)
+	}
+}
@@ -0,0 +1,10 @@
+//go:build !linux
+
+package secret
+
+// Harden is a no-op outside Linux.
+func Harden() error { return nil }
+
+func lock(b []byte) {}
+
+func unlock(b []byte) {}
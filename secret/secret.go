@@ -0,0 +1,63 @@
+// Package secret holds sensitive data in byte slices that can be wiped once they are no
+// longer needed, instead of strings that stay in memory until the garbage collector
+// reclaims them.
+package secret
+
+import "runtime"
+
+// Bytes is a wipeable buffer. Its pages are locked in memory where the platform allows it,
+// so that the secret is not written to swap.
+type Bytes struct {
+	b []byte
+}
+
+// New copies b into a new secret buffer. The caller remains responsible for wiping b.
+func New(b []byte) *Bytes {
+	s := &Bytes{b: make([]byte, len(b))}
+	copy(s.b, b)
+	lock(s.b)
+	return s
+}
+
+// FromString copies s into a new secret buffer. The string itself cannot be wiped.
+func FromString(s string) *Bytes {
+	b := &Bytes{b: []byte(s)}
+	lock(b.b)
+	return b
+}
+
+// Bytes returns the underlying slice, which is zeroed by Wipe.
+func (s *Bytes) Bytes() []byte {
+	if s == nil {
+		return nil
+	}
+	return s.b
+}
+
+// String returns a copy of the secret. Strings cannot be wiped: keep them short-lived.
+func (s *Bytes) String() string {
+	return string(s.Bytes())
+}
+
+func (s *Bytes) Len() int {
+	return len(s.Bytes())
+}
+
+// Wipe zeroes the buffer and releases it. A wiped buffer is empty.
+func (s *Bytes) Wipe() {
+	if s == nil || s.b == nil {
+		return
+	}
+	Wipe(s.b)
+	unlock(s.b)
+	s.b = nil
+}
+
+// Wipe zeroes b.
+func Wipe(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+	// Keeps the zeroing from being optimised away when b is not read again.
+	runtime.KeepAlive(b)
+}
@@ -0,0 +1,30 @@
+package secret
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBytes_Wipe(t *testing.T) {
+	src := []byte("correct horse")
+	s := New(src)
+	assert.Equal(t, "correct horse", s.String())
+
+	buf := s.Bytes()
+	s.Wipe()
+	assert.Equal(t, make([]byte, len(buf)), buf)
+	assert.Zero(t, s.Len())
+	assert.Equal(t, "correct horse", string(src), "New copies its input")
+
+	s.Wipe()
+	var nilSecret *Bytes
+	nilSecret.Wipe()
+	assert.Empty(t, nilSecret.String())
+}
+
+func TestWipe(t *testing.T) {
+	b := []byte{1, 2, 3}
+	Wipe(b)
+	assert.Equal(t, []byte{0, 0, 0}, b)
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil/gcs/builder"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/neutrino/pushtx"
+	"github.com/satelliondao/satellion/ports"
+	"github.com/satelliondao/satellion/spend"
+	"github.com/satelliondao/satellion/walletdb"
+)
+
+const (
+	// QueueInterval is how often Run looks for due broadcasts and new blocks.
+	QueueInterval = 30 * time.Second
+	// firstRetry is the delay before the second attempt, doubled with every attempt up
+	// to maxRetry. Each delay is spread by half of it either way, so that the retries do
+	// not tell peers when the wallet comes online.
+	firstRetry = 2 * time.Minute
+	maxRetry   = time.Hour
+	// purgeDepth is how many blocks deep a transaction is dropped from the queue, and
+	// stoppedKept how long a stopped one is kept to show why.
+	purgeDepth  = 6
+	stoppedKept = 24 * time.Hour
+)
+
+// BroadcastQueue broadcasts transactions until they are seen in a block, again and
+// again at jittered times and across restarts: a single broadcast is lost when the
+// peers drop the transaction or the wallet goes offline before it propagates. Blocks
+// are searched for the queued transactions with their compact filters.
+type BroadcastQueue struct {
+	repo  *walletdb.WalletDB
+	chain ports.Chain
+	mu    sync.Mutex
+}
+
+func NewBroadcastQueue(repo *walletdb.WalletDB, chain ports.Chain) *BroadcastQueue {
+	return &BroadcastQueue{repo: repo, chain: chain}
+}
+
+// Send broadcasts tx and queues it for wname, or as a transaction given by the user
+// when wname is empty. A transaction rejected by a peer is not queued. Without peers
+// it is queued unsent, to go out once they connect.
+func (q *BroadcastQueue) Send(wname string, tx *wire.MsgTx) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return err
+	}
+	best, err := q.chain.BestBlock()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	e := walletdb.QueuedTx{
+		Txid:          tx.TxHash().String(),
+		Raw:           hex.EncodeToString(buf.Bytes()),
+		Wallet:        wname,
+		ScannedHeight: best.Height,
+		NextAttempt:   now,
+		CreatedAt:     now,
+	}
+	if best.Peers == 0 {
+		e.LastError = "no peers connected"
+		return q.repo.SaveQueued(e)
+	}
+	if err := q.chain.SendTransaction(tx); err != nil {
+		return fmt.Errorf("failed to broadcast: %w", err)
+	}
+	q.attempted(&e, best.Peers, nil, now)
+	return q.repo.SaveQueued(e)
+}
+
+// List returns the queued transactions, oldest first.
+func (q *BroadcastQueue) List() ([]walletdb.QueuedTx, error) {
+	return q.repo.Queue()
+}
+
+// Remove stops broadcasting txid.
+func (q *BroadcastQueue) Remove(txid string) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return q.repo.RemoveQueued(txid)
+}
+
+// Run processes the queue every QueueInterval until quit is closed.
+func (q *BroadcastQueue) Run(quit <-chan struct{}) {
+	ticker := time.NewTicker(QueueInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-quit:
+			return
+		case now := <-ticker.C:
+			if err := q.Process(now); err != nil {
+				log.Printf("Warning: failed to process the broadcast queue: %v", err)
+			}
+		}
+	}
+}
+
+// Process searches the blocks since the last call for the queued transactions, stops
+// those of wallets which saw them confirmed or replaced, and broadcasts the due ones
+// again. Nothing happens without peers. The blocks are searched on a copy of the queue
+// without holding the lock, so that Send does not wait for the scan.
+func (q *BroadcastQueue) Process(now time.Time) error {
+	list, best, err := q.pending(now)
+	if err != nil || list == nil {
+		return err
+	}
+	scanErr := q.scan(list, best.Height)
+	if err := q.merge(list, best, now); err != nil {
+		return err
+	}
+	return scanErr
+}
+
+// pending returns a copy of the queue with what the wallets saw of each transaction,
+// and the best block, or nothing when the queue is empty or there are no peers.
+func (q *BroadcastQueue) pending(now time.Time) ([]walletdb.QueuedTx, *ports.BlockInfo, error) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	list, err := q.repo.Queue()
+	if err != nil || len(list) == 0 {
+		return nil, nil, err
+	}
+	best, err := q.chain.BestBlock()
+	if err != nil {
+		return nil, nil, err
+	}
+	if best.Peers == 0 {
+		return nil, nil, nil
+	}
+	for i := range list {
+		if err := q.checkWallet(&list[i], now); err != nil {
+			return nil, nil, err
+		}
+	}
+	return list, best, nil
+}
+
+// merge applies to the queue what was found in scanned, a copy of it taken before the
+// scan, then drops the transactions deep enough or stopped long enough and broadcasts
+// the due ones again. Transactions queued or removed during the scan are left as they
+// are.
+func (q *BroadcastQueue) merge(scanned []walletdb.QueuedTx, best *ports.BlockInfo, now time.Time) error {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	found := make(map[string]walletdb.QueuedTx, len(scanned))
+	for _, e := range scanned {
+		found[e.Txid] = e
+	}
+	list, err := q.repo.Queue()
+	if err != nil {
+		return err
+	}
+	var keep []walletdb.QueuedTx
+	for i := range list {
+		e := &list[i]
+		if f, ok := found[e.Txid]; ok {
+			if e.Active() {
+				e.Height, e.Stopped, e.StoppedAt = f.Height, f.Stopped, f.StoppedAt
+			}
+			e.ScannedHeight = max(e.ScannedHeight, f.ScannedHeight)
+		}
+		if e.Height != 0 && best.Height-e.Height+1 >= purgeDepth ||
+			e.Stopped != "" && now.Sub(e.StoppedAt) >= stoppedKept {
+			if err := q.repo.RemoveQueued(e.Txid); err != nil {
+				return err
+			}
+			continue
+		}
+		if e.Active() && !now.Before(e.NextAttempt) {
+			q.rebroadcast(e, best.Peers, now)
+		}
+		keep = append(keep, *e)
+	}
+	return q.repo.SaveQueued(keep...)
+}
+
+// checkWallet stops broadcasting a transaction its wallet saw replaced, and takes the
+// height of those it saw confirmed.
+func (q *BroadcastQueue) checkWallet(e *walletdb.QueuedTx, now time.Time) error {
+	if !e.Active() || e.Wallet == "" {
+		return nil
+	}
+	t, err := q.repo.Transaction(e.Wallet, e.Txid)
+	if errors.Is(err, walletdb.ErrTransactionNotFound) || errors.Is(err, walletdb.ErrWalletNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	switch t.Status {
+	case spend.Confirmed:
+		e.Height = t.Height
+	case spend.Replaced:
+		e.Stopped, e.StoppedAt = "replaced", now
+		if t.ReplacedBy != "" {
+			e.Stopped += " by " + t.ReplacedBy
+		}
+	}
+	return nil
+}
+
+// scan searches the blocks after the last one scanned up to tip for the active
+// transactions of list, downloading only the blocks whose filter matches one of
+// their outputs.
+func (q *BroadcastQueue) scan(list []walletdb.QueuedTx, tip int32) error {
+	from := tip
+	scripts := make(map[string][][]byte)
+	for i := range list {
+		e := &list[i]
+		if !e.Active() {
+			continue
+		}
+		tx, err := DecodeTx(e.Raw)
+		if err != nil {
+			return err
+		}
+		for _, out := range tx.TxOut {
+			scripts[e.Txid] = append(scripts[e.Txid], out.PkScript)
+		}
+		from = min(from, e.ScannedHeight)
+	}
+	for height := from + 1; height <= tip; height++ {
+		hash, err := q.chain.GetBlockHash(int64(height))
+		if err != nil {
+			return err
+		}
+		filter, err := q.chain.GetCFilter(*hash)
+		if err != nil {
+			return err
+		}
+		key := builder.DeriveKey(hash)
+		var txids map[string]bool
+		for i := range list {
+			e := &list[i]
+			if !e.Active() || e.ScannedHeight >= height {
+				continue
+			}
+			match, err := filter.MatchAny(key, scripts[e.Txid])
+			if err != nil {
+				return err
+			}
+			if match && txids == nil {
+				block, err := q.chain.GetBlock(*hash)
+				if err != nil {
+					return err
+				}
+				txids = make(map[string]bool)
+				for _, tx := range block.Transactions() {
+					txids[tx.Hash().String()] = true
+				}
+			}
+			if match && txids[e.Txid] {
+				e.Height = height
+			}
+			e.ScannedHeight = height
+		}
+	}
+	return nil
+}
+
+// rebroadcast sends e again. A transaction rejected as invalid, such as one whose
+// inputs were spent by another, is not sent any more.
+func (q *BroadcastQueue) rebroadcast(e *walletdb.QueuedTx, peers int, now time.Time) {
+	tx, err := DecodeTx(e.Raw)
+	if err == nil {
+		err = q.chain.SendTransaction(tx)
+	}
+	if pushtx.IsBroadcastError(err, pushtx.Invalid) {
+		e.Stopped, e.StoppedAt = "rejected: "+err.Error(), now
+	}
+	q.attempted(e, peers, err, now)
+}
+
+// attempted records an attempt to broadcast e and schedules the next one.
+func (q *BroadcastQueue) attempted(e *walletdb.QueuedTx, peers int, err error, now time.Time) {
+	e.Attempts++
+	e.Peers, e.LastAttempt, e.LastError = peers, now, ""
+	if err != nil {
+		e.LastError = err.Error()
+	}
+	delay := maxRetry
+	if e.Attempts <= 6 {
+		delay = min(firstRetry<<(e.Attempts-1), maxRetry)
+	}
+	e.NextAttempt = now.Add(time.Duration(float64(delay) * (0.5 + rand.Float64())))
+}
+
+// DecodeTx decodes a transaction in hex.
+func DecodeTx(raw string) (*wire.MsgTx, error) {
+	b, err := hex.DecodeString(raw)
+	if err != nil {
+		return nil, err
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(b)); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
@@ -0,0 +1,187 @@
+package service
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/btcutil/gcs/builder"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/neutrino/headerfs"
+	"github.com/lightninglabs/neutrino/pushtx"
+	"github.com/satelliondao/satellion/neutrino"
+	"github.com/satelliondao/satellion/ports"
+	"github.com/satelliondao/satellion/walletdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func setupQueue(t *testing.T) (*BroadcastQueue, *neutrino.MockChainService) {
+	db, err := walletdb.Connect(t.TempDir() + "/wallets.db")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	chain := &neutrino.MockChainService{}
+	return NewBroadcastQueue(walletdb.New(db, &chaincfg.MainNetParams), chain), chain
+}
+
+func tip(chain *neutrino.MockChainService, height int32, peers int) {
+	chain.On("BestBlock").Return(&ports.BlockInfo{BlockStamp: &headerfs.BlockStamp{Height: height}, Peers: peers}, nil).Once()
+}
+
+func rawTx(seed byte) *wire.MsgTx {
+	tx := wire.NewMsgTx(2)
+	tx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: chainhash.Hash{seed}}, nil, nil))
+	tx.AddTxOut(wire.NewTxOut(10_000, append([]byte{0x51, 0x20}, make([]byte, 32)...)))
+	return tx
+}
+
+func TestBroadcastQueue_Rebroadcast(t *testing.T) {
+	queue, chain := setupQueue(t)
+	chain.On("SendTransaction", mock.Anything).Return(nil).Twice()
+	chain.On("SendTransaction", mock.Anything).Return(&pushtx.BroadcastError{Code: pushtx.Invalid, Reason: "missing inputs"})
+	tip(chain, 100, 8)
+	require.NoError(t, queue.Send("", rawTx(1)))
+	list, err := queue.List()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	first := list[0]
+	assert.Equal(t, 1, first.Attempts)
+	assert.Equal(t, 8, first.Peers)
+	delay := first.NextAttempt.Sub(first.LastAttempt)
+	assert.True(t, delay >= firstRetry/2 && delay < firstRetry*3/2, "jittered around the first retry")
+
+	tip(chain, 100, 8)
+	require.NoError(t, queue.Process(first.LastAttempt.Add(time.Second)))
+	chain.AssertNumberOfCalls(t, "SendTransaction", 1)
+
+	for _, attempts := range []int{2, 3} {
+		tip(chain, 100, 5)
+		list, err = queue.List()
+		require.NoError(t, err)
+		require.NoError(t, queue.Process(list[0].NextAttempt))
+		list, err = queue.List()
+		require.NoError(t, err)
+		assert.Equal(t, attempts, list[0].Attempts)
+	}
+	assert.Equal(t, 5, list[0].Peers)
+	assert.Contains(t, list[0].Stopped, "missing inputs", "invalid transactions are not sent again")
+	assert.False(t, list[0].Active())
+
+	tip(chain, 100, 5)
+	require.NoError(t, queue.Process(list[0].StoppedAt.Add(stoppedKept)))
+	list, err = queue.List()
+	require.NoError(t, err)
+	assert.Empty(t, list)
+}
+
+func TestBroadcastQueue_NoPeers(t *testing.T) {
+	queue, chain := setupQueue(t)
+	tip(chain, 100, 0)
+	require.NoError(t, queue.Send("", rawTx(1)))
+	tip(chain, 100, 0)
+	require.NoError(t, queue.Process(time.Now()))
+	chain.AssertNotCalled(t, "SendTransaction", mock.Anything)
+
+	chain.On("SendTransaction", mock.Anything).Return(nil)
+	tip(chain, 100, 3)
+	require.NoError(t, queue.Process(time.Now()))
+	list, err := queue.List()
+	require.NoError(t, err)
+	require.Len(t, list, 1)
+	assert.Equal(t, 1, list[0].Attempts, "sent once peers connect")
+	assert.Empty(t, list[0].LastError)
+
+	chain.On("SendTransaction", mock.Anything).Unset()
+	chain.On("SendTransaction", mock.Anything).Return(assert.AnError)
+	tip(chain, 100, 3)
+	assert.ErrorIs(t, queue.Send("", rawTx(2)), assert.AnError)
+	list, err = queue.List()
+	require.NoError(t, err)
+	assert.Len(t, list, 1, "rejected transactions are not queued")
+}
+
+func TestBroadcastQueue_SeenInBlock(t *testing.T) {
+	queue, chain := setupQueue(t)
+	chain.On("SendTransaction", mock.Anything).Return(nil)
+	tx, other := rawTx(1), rawTx(2)
+	other.TxOut[0].PkScript = append([]byte{0x51, 0x20}, bytes.Repeat([]byte{1}, 32)...)
+	tip(chain, 100, 8)
+	require.NoError(t, queue.Send("", tx))
+	tip(chain, 100, 8)
+	require.NoError(t, queue.Send("", other))
+
+	block := wire.NewMsgBlock(&wire.BlockHeader{Timestamp: time.Unix(1, 0)})
+	require.NoError(t, block.AddTransaction(tx))
+	hash := block.BlockHash()
+	filter, err := builder.BuildBasicFilter(block, nil)
+	require.NoError(t, err)
+	chain.On("GetBlockHash", int64(101)).Return(&hash, nil)
+	chain.On("GetCFilter", hash).Return(filter, nil)
+	chain.On("GetBlock", hash).Return(btcutil.NewBlock(block), nil).Once()
+
+	tip(chain, 101, 8)
+	require.NoError(t, queue.Process(time.Now()))
+	list, err := queue.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, int32(101), list[0].Height)
+	assert.False(t, list[0].Active(), "seen in a block")
+	assert.Zero(t, list[1].Height, "the filter does not match the other one")
+	assert.Equal(t, int32(101), list[1].ScannedHeight)
+
+	for height := int64(102); height <= 106; height++ {
+		empty := wire.NewMsgBlock(&wire.BlockHeader{Timestamp: time.Unix(height, 0)})
+		h := empty.BlockHash()
+		f, err := builder.BuildBasicFilter(empty, nil)
+		require.NoError(t, err)
+		chain.On("GetBlockHash", height).Return(&h, nil)
+		chain.On("GetCFilter", h).Return(f, nil)
+	}
+	tip(chain, 106, 8)
+	require.NoError(t, queue.Process(time.Now()))
+	list, err = queue.List()
+	require.NoError(t, err)
+	require.Len(t, list, 1, "dropped six blocks deep")
+	assert.Equal(t, other.TxHash().String(), list[0].Txid)
+}
+
+func TestBroadcastQueue_SendDuringScan(t *testing.T) {
+	queue, chain := setupQueue(t)
+	chain.On("SendTransaction", mock.Anything).Return(nil)
+	tx := rawTx(1)
+	tip(chain, 100, 8)
+	require.NoError(t, queue.Send("", tx))
+
+	block := wire.NewMsgBlock(&wire.BlockHeader{Timestamp: time.Unix(1, 0)})
+	require.NoError(t, block.AddTransaction(tx))
+	hash := block.BlockHash()
+	filter, err := builder.BuildBasicFilter(block, nil)
+	require.NoError(t, err)
+	scanning, release := make(chan struct{}), make(chan struct{})
+	chain.On("GetBlockHash", int64(101)).Return(&hash, nil)
+	chain.On("GetCFilter", hash).Run(func(mock.Arguments) {
+		close(scanning)
+		<-release
+	}).Return(filter, nil)
+	chain.On("GetBlock", hash).Return(btcutil.NewBlock(block), nil)
+
+	tip(chain, 101, 8)
+	done := make(chan error)
+	go func() { done <- queue.Process(time.Now()) }()
+	<-scanning
+	tip(chain, 101, 8)
+	require.NoError(t, queue.Send("", rawTx(2)), "not held up by the scan")
+	close(release)
+	require.NoError(t, <-done)
+
+	list, err := queue.List()
+	require.NoError(t, err)
+	require.Len(t, list, 2, "sent during the scan and kept")
+	byTxid := map[string]walletdb.QueuedTx{list[0].Txid: list[0], list[1].Txid: list[1]}
+	assert.Equal(t, int32(101), byTxid[tx.TxHash().String()].Height)
+	assert.Zero(t, byTxid[rawTx(2).TxHash().String()].Height)
+}
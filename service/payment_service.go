@@ -0,0 +1,254 @@
+package service
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satelliondao/satellion/bip329"
+	"github.com/satelliondao/satellion/coinselect"
+	"github.com/satelliondao/satellion/ports"
+	"github.com/satelliondao/satellion/spend"
+	"github.com/satelliondao/satellion/sweep"
+	"github.com/satelliondao/satellion/wallet"
+	"github.com/satelliondao/satellion/walletdb"
+)
+
+// PaymentService signs the payments of the wallets, broadcasts them and keeps track of
+// them until they confirm. Coins are always chosen by coinselect, which never spends
+// frozen coins nor coins spent by pending transactions. Every broadcast goes through
+// the queue, which sends it again until it is seen in a block.
+type PaymentService struct {
+	repo   *walletdb.WalletDB
+	chain  ports.Chain
+	params *chaincfg.Params
+	queue  *BroadcastQueue
+}
+
+func NewPaymentService(repo *walletdb.WalletDB, chain ports.Chain, params *chaincfg.Params) *PaymentService {
+	return &PaymentService{repo: repo, chain: chain, params: params, queue: NewBroadcastQueue(repo, chain)}
+}
+
+// Queue returns the rebroadcast queue of the payments.
+func (s *PaymentService) Queue() *BroadcastQueue {
+	return s.queue
+}
+
+// Pay signs a payment to recipients at feeRate sat/vB, funded by the picked outputs of w
+// or by the coin selector when none are picked. A recipient with a zero amount receives
+// everything left, without change: the picked outputs, or all spendable ones, less the
+// other amounts and the fee. Nothing is broadcast.
+func (s *PaymentService) Pay(w *wallet.Wallet, recipients []spend.Output, picked []wire.OutPoint, feeRate btcutil.Amount) (*spend.Transaction, error) {
+	if err := spend.CheckOutputs(recipients, s.params); err != nil {
+		return nil, err
+	}
+	set, frozen, pending, err := s.coins(w.Name)
+	if err != nil {
+		return nil, err
+	}
+	var amount btcutil.Amount
+	for _, r := range recipients {
+		amount += r.Amount
+	}
+	req := coinselect.Request{
+		Utxos:   set.Utxos,
+		Frozen:  frozen,
+		Pending: pending,
+		Picked:  picked,
+		Amount:  amount,
+		Outputs: len(recipients),
+		FeeRate: feeRate,
+	}
+	if rest := spend.Rest(recipients); rest >= 0 {
+		sel, value, err := coinselect.SelectAll(req)
+		if err != nil {
+			return nil, err
+		}
+		recipients = append([]spend.Output{}, recipients...)
+		recipients[rest].Amount = value
+		if err := spend.CheckOutputs(recipients, s.params); err != nil {
+			return nil, err
+		}
+		return spend.Sign(w, sel, recipients, "", s.params)
+	}
+	sel, err := coinselect.Select(req)
+	if err != nil {
+		return nil, err
+	}
+	change, err := w.ChangeAddress()
+	if err != nil {
+		return nil, err
+	}
+	return spend.Sign(w, sel, recipients, change.Address.String(), s.params)
+}
+
+// Bump signs a replacement of the pending transaction txid of w paying its recipients
+// at feeRate sat/vB.
+func (s *PaymentService) Bump(w *wallet.Wallet, txid string, feeRate btcutil.Amount) (*spend.Transaction, error) {
+	orig, err := s.repo.Transaction(w.Name, txid)
+	if err != nil {
+		return nil, err
+	}
+	set, frozen, pending, err := s.coins(w.Name)
+	if err != nil {
+		return nil, err
+	}
+	for ref := range frozen {
+		pending[ref] = true
+	}
+	change, err := w.ChangeAddress()
+	if err != nil {
+		return nil, err
+	}
+	return spend.Bump(w, &orig, feeRate, set.Utxos, pending, change.Address.String(), s.params)
+}
+
+// Cancel signs a replacement of the pending transaction txid of w paying its inputs
+// back to a change address at feeRate sat/vB.
+func (s *PaymentService) Cancel(w *wallet.Wallet, txid string, feeRate btcutil.Amount) (*spend.Transaction, error) {
+	orig, err := s.repo.Transaction(w.Name, txid)
+	if err != nil {
+		return nil, err
+	}
+	change, err := w.ChangeAddress()
+	if err != nil {
+		return nil, err
+	}
+	return spend.Cancel(w, &orig, feeRate, change.Address.String(), s.params)
+}
+
+// ChildPaysForParent signs a child spending the output vout of the unconfirmed parent,
+// which must pay w, to a change address so that both reach feeRate sat/vB. parentFee is
+// the fee of the parent, which the wallet cannot compute without its inputs.
+func (s *PaymentService) ChildPaysForParent(w *wallet.Wallet, parent *wire.MsgTx, parentFee btcutil.Amount, vout uint32, feeRate btcutil.Amount) (*spend.Transaction, error) {
+	incoming, err := spend.Incoming(w, parent)
+	if err != nil {
+		return nil, err
+	}
+	for _, out := range incoming {
+		if out.OutPoint.Index != vout {
+			continue
+		}
+		change, err := w.ChangeAddress()
+		if err != nil {
+			return nil, err
+		}
+		return spend.Child(w, parent, parentFee, out, feeRate, change.Address.String(), s.params)
+	}
+	return nil, fmt.Errorf("output %d of %s does not pay this wallet", vout, parent.TxHash())
+}
+
+// BroadcastPackage sends parent before its child, so that peers which never saw the
+// parent accept the child, and records the child like Broadcast.
+func (s *PaymentService) BroadcastPackage(w *wallet.Wallet, parent *wire.MsgTx, child *spend.Transaction, label string) error {
+	if err := s.queue.Send("", parent); err != nil {
+		return fmt.Errorf("parent: %w", err)
+	}
+	return s.Broadcast(w, child, label)
+}
+
+// Broadcast sends t to the peers and records it for w: t is tracked until it confirms,
+// the transaction it replaces is marked replaced and the next payment gets a fresh
+// change address. The label, or else the label of the replaced transaction, is kept
+// as the BIP329 label of t.
+func (s *PaymentService) Broadcast(w *wallet.Wallet, t *spend.Transaction, label string) error {
+	tx, err := t.MsgTx()
+	if err != nil {
+		return err
+	}
+	if err := s.queue.Send(w.Name, tx); err != nil {
+		return err
+	}
+	records := []spend.Transaction{*t}
+	if t.Replaces != "" {
+		orig, err := s.repo.Transaction(w.Name, t.Replaces)
+		if err != nil {
+			return err
+		}
+		orig.Status, orig.ReplacedBy = spend.Replaced, t.Txid
+		records = append(records, orig)
+		if label == "" {
+			l, err := s.repo.Label(w.Name, bip329.Tx, orig.Txid)
+			if err != nil {
+				return err
+			}
+			label = l.Label
+		}
+	}
+	if err := s.repo.SaveTransactions(w.Name, records...); err != nil {
+		return err
+	}
+	if label != "" {
+		if err := s.repo.SetLabels(w.Name, bip329.Label{Type: bip329.Tx, Ref: t.Txid, Label: label}); err != nil {
+			return err
+		}
+	}
+	for _, o := range t.Outputs {
+		if o.Change {
+			w.NextChangeIndex++
+			if err := s.repo.Save(w); err != nil {
+				return err
+			}
+			break
+		}
+	}
+	if t.Replaces != "" {
+		return nil
+	}
+	for _, o := range t.Recipients() {
+		if err := s.repo.RecordPayment(o.Address, t.CreatedAt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Sweep signs a transaction moving utxos, the coins of external keys, to the receive
+// address of w at feeRate sat/vB, the one shown by the receive page.
+func (s *PaymentService) Sweep(w *wallet.Wallet, keys []*sweep.Key, utxos []wallet.Utxo, feeRate btcutil.Amount) (*sweep.Sweep, error) {
+	to, err := w.ReceiveAddress()
+	if err != nil {
+		return nil, err
+	}
+	return sweep.Sign(keys, utxos, to.Address, feeRate)
+}
+
+// BroadcastSweep sends a sweep signed by Sweep for w and moves the receive page to the
+// next address, so that the address is not handed out again. The label is kept as the
+// BIP329 label of the transaction. The coins show up in the wallet with the next scan.
+func (s *PaymentService) BroadcastSweep(w *wallet.Wallet, sw *sweep.Sweep, label string) error {
+	to, err := w.ReceiveAddress()
+	if err != nil {
+		return err
+	}
+	if to.Address.String() != sw.To.String() {
+		return fmt.Errorf("the sweep does not pay the receive address, sign it again")
+	}
+	if err := s.queue.Send(w.Name, sw.Tx); err != nil {
+		return err
+	}
+	w.NextReceiveIndex++
+	if err := s.repo.Save(w); err != nil {
+		return err
+	}
+	if label == "" {
+		return nil
+	}
+	return s.repo.SetLabels(w.Name, bip329.Label{Type: bip329.Tx, Ref: sw.Tx.TxHash().String(), Label: label})
+}
+
+// coins returns the unspent outputs of the last scan of wname, the frozen ones and the
+// ones spent by pending transactions.
+func (s *PaymentService) coins(wname string) (walletdb.UtxoSet, map[string]bool, map[string]bool, error) {
+	set, err := s.repo.Utxos(wname)
+	if err != nil {
+		return set, nil, nil, err
+	}
+	frozen, err := s.repo.Frozen(wname)
+	if err != nil {
+		return set, nil, nil, err
+	}
+	pending, err := s.repo.PendingSpends(wname)
+	return set, frozen, pending, err
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/lightninglabs/neutrino/headerfs"
+	"github.com/satelliondao/satellion/bip329"
+	"github.com/satelliondao/satellion/mnemonic"
+	"github.com/satelliondao/satellion/neutrino"
+	"github.com/satelliondao/satellion/ports"
+	"github.com/satelliondao/satellion/spend"
+	"github.com/satelliondao/satellion/sweep"
+	"github.com/satelliondao/satellion/wallet"
+	"github.com/satelliondao/satellion/walletdb"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+// recipient is the first BIP86 test vector receive address of "abandon ... about".
+const recipient = "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"
+
+func setupPaymentService(t *testing.T) (*PaymentService, *walletdb.WalletDB, *neutrino.MockChainService, *wallet.Wallet) {
+	return setupPaymentServiceOn(t, &chaincfg.MainNetParams)
+}
+
+// setupPaymentServiceOn sets up a payment service and a wallet for params.
+func setupPaymentServiceOn(t *testing.T, params *chaincfg.Params) (*PaymentService, *walletdb.WalletDB, *neutrino.MockChainService, *wallet.Wallet) {
+	db, err := walletdb.Connect(t.TempDir() + "/wallets.db")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	repo := walletdb.New(db, params)
+	w := wallet.New(mnemonic.NewRandom(), "", "", params)
+	w.Name = "payer"
+	require.NoError(t, repo.Seal(w, "", []byte("password")))
+	var utxos []wallet.Utxo
+	for i, value := range []btcutil.Amount{50_000, 30_000} {
+		addr, err := w.DeriveTaprootAddress(0, uint32(i))
+		require.NoError(t, err)
+		script, err := addr.DeriveTaprootScriptPubKey()
+		require.NoError(t, err)
+		utxos = append(utxos, wallet.Utxo{
+			OutPoint: wire.OutPoint{Hash: [32]byte{byte(i + 1)}},
+			Value:    value,
+			PkScript: script,
+			Address:  addr.Address.String(),
+			Index:    uint32(i),
+			Height:   100,
+		})
+	}
+	require.NoError(t, repo.SaveUtxos("payer", walletdb.UtxoSet{Height: 110, ScannedAt: time.Now(), Utxos: utxos}))
+	chain := &neutrino.MockChainService{}
+	chain.On("BestBlock").Return(&ports.BlockInfo{BlockStamp: &headerfs.BlockStamp{Height: 110}, Peers: 8}, nil)
+	return NewPaymentService(repo, chain, params), repo, chain, w
+}
+
+// sent returns the transactions broadcast through chain, in order.
+func sent(chain *neutrino.MockChainService) []*wire.MsgTx {
+	var txs []*wire.MsgTx
+	for _, call := range chain.Calls {
+		if call.Method == "SendTransaction" {
+			txs = append(txs, call.Arguments.Get(0).(*wire.MsgTx))
+		}
+	}
+	return txs
+}
+
+func TestPaymentService_PayBumpCancel(t *testing.T) {
+	payments, repo, chain, w := setupPaymentService(t)
+	chain.On("SendTransaction", mock.Anything).Return(nil)
+
+	set, err := repo.Utxos("payer")
+	require.NoError(t, err)
+	frozen := set.Utxos[1].Ref()
+	require.NoError(t, repo.SetFrozen("payer", frozen, true))
+	_, err = payments.Pay(w, []spend.Output{{Address: recipient, Amount: 60_000}}, nil, 2)
+	assert.Error(t, err, "the frozen coin cannot help pay")
+
+	orig, err := payments.Pay(w, []spend.Output{{Address: recipient, Amount: 20_000}}, nil, 2)
+	require.NoError(t, err)
+	require.Len(t, orig.Inputs, 1)
+	assert.NotEqual(t, frozen, orig.Inputs[0].Ref())
+	require.NoError(t, payments.Broadcast(w, orig, "rent"))
+	assert.Equal(t, uint32(1), w.NextChangeIndex, "the change address is used")
+	paid, err := repo.Payments(recipient)
+	require.NoError(t, err)
+	assert.Equal(t, 1, paid.Count)
+	pending, err := repo.PendingSpends("payer")
+	require.NoError(t, err)
+	assert.True(t, pending[orig.Inputs[0].Ref()])
+
+	_, err = payments.Pay(w, []spend.Output{{Address: recipient, Amount: 1_000}}, nil, 2)
+	assert.Error(t, err, "coins of pending transactions are not spent twice, the other is frozen")
+
+	bumped, err := payments.Bump(w, orig.Txid, 5)
+	require.NoError(t, err)
+	require.NoError(t, payments.Broadcast(w, bumped, ""))
+	replaced, err := repo.Transaction("payer", orig.Txid)
+	require.NoError(t, err)
+	assert.Equal(t, spend.Replaced, replaced.Status)
+	assert.Equal(t, bumped.Txid, replaced.ReplacedBy)
+	l, err := repo.Label("payer", bip329.Tx, bumped.Txid)
+	require.NoError(t, err)
+	assert.Equal(t, "rent", l.Label, "replacements keep the label")
+	paid, err = repo.Payments(recipient)
+	require.NoError(t, err)
+	assert.Equal(t, 1, paid.Count, "a replacement is not another payment")
+
+	_, err = payments.Cancel(w, orig.Txid, 10)
+	assert.ErrorIs(t, err, spend.ErrNotReplaceable)
+	cancel, err := payments.Cancel(w, bumped.Txid, 10)
+	require.NoError(t, err)
+	require.NoError(t, payments.Broadcast(w, cancel, ""))
+	assert.Empty(t, cancel.Recipients())
+	chain.AssertNumberOfCalls(t, "SendTransaction", 3)
+}
+
+func TestPaymentService_Testnet(t *testing.T) {
+	payments, _, chain, w := setupPaymentServiceOn(t, &chaincfg.TestNet3Params)
+	chain.On("SendTransaction", mock.Anything).Return(nil)
+	const testnet = "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"
+
+	_, err := payments.Pay(w, []spend.Output{{Address: recipient, Amount: 20_000}}, nil, 2)
+	assert.ErrorIs(t, err, spend.ErrWrongNetwork)
+	orig, err := payments.Pay(w, []spend.Output{{Address: testnet, Amount: 20_000}}, nil, 2)
+	require.NoError(t, err, "the change address is a testnet one")
+	var change *spend.Output
+	for i, o := range orig.Outputs {
+		if o.Change {
+			change = &orig.Outputs[i]
+		}
+	}
+	require.NotNil(t, change)
+	assert.True(t, strings.HasPrefix(change.Address, "tb1p"), change.Address)
+	require.NoError(t, payments.Broadcast(w, orig, ""))
+
+	bumped, err := payments.Bump(w, orig.Txid, 5)
+	require.NoError(t, err)
+	require.NoError(t, payments.Broadcast(w, bumped, ""))
+	cancel, err := payments.Cancel(w, bumped.Txid, 10)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(cancel.Outputs[0].Address, "tb1p"))
+
+	addr, err := w.DeriveTaprootAddress(0, 5)
+	require.NoError(t, err)
+	script, err := addr.DeriveTaprootScriptPubKey()
+	require.NoError(t, err)
+	parent := wire.NewMsgTx(2)
+	parent.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: [32]byte{9}}, nil, [][]byte{make([]byte, 64)}))
+	parent.AddTxOut(wire.NewTxOut(40_000, script))
+	child, err := payments.ChildPaysForParent(w, parent, 100, 0, 10)
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(child.Outputs[0].Address, "tb1p"))
+}
+
+func TestPaymentService_BroadcastFailure(t *testing.T) {
+	payments, repo, chain, w := setupPaymentService(t)
+	chain.On("SendTransaction", mock.Anything).Return(assert.AnError)
+	tx, err := payments.Pay(w, []spend.Output{{Address: recipient, Amount: 20_000}}, nil, 2)
+	require.NoError(t, err)
+	assert.ErrorIs(t, payments.Broadcast(w, tx, ""), assert.AnError)
+	list, err := repo.Transactions("payer")
+	require.NoError(t, err)
+	assert.Empty(t, list, "rejected transactions are not tracked")
+}
+
+func TestPaymentService_ChildPaysForParent(t *testing.T) {
+	payments, repo, chain, w := setupPaymentService(t)
+	chain.On("SendTransaction", mock.Anything).Return(nil)
+	addr, err := w.DeriveTaprootAddress(0, 5)
+	require.NoError(t, err)
+	script, err := addr.DeriveTaprootScriptPubKey()
+	require.NoError(t, err)
+	parent := wire.NewMsgTx(2)
+	parent.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: [32]byte{9}}, nil, [][]byte{make([]byte, 64)}))
+	parent.AddTxOut(wire.NewTxOut(40_000, script))
+
+	_, err = payments.ChildPaysForParent(w, parent, 100, 1, 10)
+	assert.Error(t, err, "there is no output 1")
+	child, err := payments.ChildPaysForParent(w, parent, 100, 0, 10)
+	require.NoError(t, err)
+	require.NoError(t, payments.BroadcastPackage(w, parent, child, ""))
+	chain.AssertNumberOfCalls(t, "SendTransaction", 2)
+	assert.Equal(t, parent, sent(chain)[0], "the parent goes first")
+
+	set, err := repo.Utxos("payer")
+	require.NoError(t, err)
+	require.NoError(t, repo.ReconcileTransactions("payer", nil, set.Utxos))
+	saved, err := repo.Transaction("payer", child.Txid)
+	require.NoError(t, err)
+	assert.Equal(t, spend.Pending, saved.Status, "unconfirmed inputs are not missing from a scan")
+}
+
+func TestPaymentService_PayAllAndBatch(t *testing.T) {
+	payments, repo, _, w := setupPaymentService(t)
+	set, err := repo.Utxos("payer")
+	require.NoError(t, err)
+	require.NoError(t, repo.SetFrozen("payer", set.Utxos[1].Ref(), true))
+
+	all, err := payments.Pay(w, []spend.Output{{Address: recipient}}, nil, 2)
+	require.NoError(t, err)
+	require.Len(t, all.Outputs, 1, "no change")
+	require.Len(t, all.Inputs, 1, "frozen coins stay")
+	assert.Equal(t, 50_000-all.Fee, all.Outputs[0].Amount)
+	assert.Equal(t, uint32(0), w.NextChangeIndex)
+
+	other, err := w.DeriveTaprootAddress(0, 7)
+	require.NoError(t, err)
+	batch := []spend.Output{{Address: recipient, Amount: 10_000}, {Address: other.Address.String(), Amount: 5_000}}
+	tx, err := payments.Pay(w, batch, nil, 2)
+	require.NoError(t, err)
+	assert.Equal(t, batch, tx.Recipients())
+	assert.Equal(t, btcutil.Amount(15_000), tx.Sent())
+
+	_, err = payments.Pay(w, append(batch, batch[0]), nil, 2)
+	assert.ErrorIs(t, err, spend.ErrDuplicateRecipient)
+	_, err = payments.Pay(w, []spend.Output{{Address: recipient, Amount: 49_900}, {Address: other.Address.String()}}, nil, 2)
+	assert.Error(t, err, "nothing is left for the rest")
+}
+
+func TestPaymentService_Sweep(t *testing.T) {
+	payments, repo, chain, w := setupPaymentService(t)
+	chain.On("SendTransaction", mock.Anything).Return(nil)
+	keys, err := sweep.Parse("wpkh(KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWn)", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	coin := wallet.Utxo{OutPoint: wire.OutPoint{Hash: [32]byte{7}}, Value: 25_000, PkScript: keys[0].Script, Height: 50}
+
+	sw, err := payments.Sweep(w, keys, []wallet.Utxo{coin}, 3)
+	require.NoError(t, err)
+	shown, err := w.ReceiveAddress()
+	require.NoError(t, err)
+	assert.Equal(t, shown.Address.String(), sw.To.String(), "the address shown by the receive page")
+	require.NoError(t, payments.BroadcastSweep(w, sw, "paper wallet"))
+	assert.Equal(t, uint32(1), w.NextReceiveIndex)
+	l, err := repo.Label("payer", bip329.Tx, sw.Tx.TxHash().String())
+	require.NoError(t, err)
+	assert.Equal(t, "paper wallet", l.Label)
+	assert.Error(t, payments.BroadcastSweep(w, sw, ""), "the receive page moved past the address")
+	chain.AssertNumberOfCalls(t, "SendTransaction", 1)
+}
@@ -1,57 +1,162 @@
 package service
 
 import (
-	"crypto/sha256"
-	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/satelliondao/satellion/mnemonic"
+	"github.com/satelliondao/satellion/secret"
 	"github.com/satelliondao/satellion/wallet"
 	"github.com/satelliondao/satellion/walletdb"
 )
 
+var (
+	ErrInvalidPassword = errors.New("invalid password")
+	ErrEmptyPassword   = errors.New("password cannot be empty")
+)
+
+// Failed unlock attempts beyond freeUnlockAttempts wait twice as long as the previous one,
+// starting at unlockBackoffBase and capped at unlockBackoffMax.
+const (
+	freeUnlockAttempts = 3
+	unlockBackoffBase  = 2 * time.Second
+	unlockBackoffMax   = 15 * time.Minute
+)
+
+// UnlockThrottledError is returned when an unlock is attempted before the back-off has elapsed.
+type UnlockThrottledError struct {
+	Wait time.Duration
+}
+
+func (e *UnlockThrottledError) Error() string {
+	return fmt.Sprintf("too many failed attempts, try again in %s", e.Wait.Round(time.Second))
+}
+
 type WalletService struct {
 	walletRepo *walletdb.WalletDB
+	now        func() time.Time
 }
 
 func NewWalletService(walletRepo *walletdb.WalletDB) *WalletService {
-	return &WalletService{walletRepo: walletRepo}
+	return &WalletService{walletRepo: walletRepo, now: time.Now}
 }
 
-func (s *WalletService) AddWallet(name string, m mnemonic.Mnemonic, passphrase string) error {
+// AddWallet stores a new wallet whose mnemonic and optional BIP39 passphrase are
+// encrypted with password, and makes it the active wallet.
+func (s *WalletService) AddWallet(name string, m mnemonic.Mnemonic, passphrase, password string) error {
 	if name == "" {
 		return fmt.Errorf("invalid wallet data")
 	}
-	model := wallet.New(&m, passphrase, "")
+	if password == "" {
+		return ErrEmptyPassword
+	}
+	model := wallet.New(&m, passphrase, "", s.walletRepo.Params())
 	model.Name = name
 	model.CreatedAt = time.Now()
+	if err := s.walletRepo.Seal(model, passphrase, []byte(password)); err != nil {
+		return err
+	}
+	return s.walletRepo.SetDefault(name)
+}
 
-	err := s.walletRepo.Save(model)
+// Unlock checks password against the active wallet. Failed attempts are persisted and
+// delay further attempts exponentially. Wallets stored before seeds were encrypted are
+// unlocked with their BIP39 passphrase until SealLegacy gives them a password.
+func (s *WalletService) Unlock(password string) error {
+	name, err := s.walletRepo.GetActiveWalletName()
 	if err != nil {
 		return err
 	}
-	return s.walletRepo.SetDefault(name)
+	state, err := s.walletRepo.UnlockState(name)
+	if err != nil {
+		return err
+	}
+	now := s.now()
+	if wait := state.LastFailure.Add(unlockDelay(state.Failures)).Sub(now); wait > 0 {
+		return &UnlockThrottledError{Wait: wait}
+	}
+	ok := true
+	w, err := s.walletRepo.Get(name, []byte(password))
+	switch {
+	case errors.Is(err, walletdb.ErrInvalidPassword):
+		ok = false
+	case err != nil:
+		return err
+	case w.WatchOnly():
+		// Nothing to protect: the keys are on the external signer.
+	case !w.Sealed:
+		ok, err = verifyLegacy(w, password)
+		w.Wipe()
+		if err != nil {
+			return err
+		}
+	default:
+		w.Wipe()
+	}
+	if !ok {
+		state.Failures++
+		state.LastFailure = now
+		if err := s.walletRepo.SaveUnlockState(name, state); err != nil {
+			return err
+		}
+		return ErrInvalidPassword
+	}
+	if state.Failures > 0 {
+		return s.walletRepo.SaveUnlockState(name, walletdb.UnlockState{})
+	}
+	return nil
 }
 
-func (s *WalletService) Unlock(passphrase string) error {
-	w, err := s.walletRepo.GetActiveWallet(passphrase)
+// SealLegacy encrypts the seed of the active wallet, stored before seeds were encrypted,
+// with password. passphrase is the BIP39 passphrase the wallet was unlocked with so far.
+func (s *WalletService) SealLegacy(passphrase, password string) error {
+	if password == "" {
+		return ErrEmptyPassword
+	}
+	w, err := s.walletRepo.GetActiveWallet([]byte(passphrase))
+	if err != nil {
+		return err
+	}
+	if w.Sealed {
+		return fmt.Errorf("wallet %s already has a password", w.Name)
+	}
+	if w.WatchOnly() {
+		return fmt.Errorf("wallet %s is watch-only", w.Name)
+	}
+	defer w.Wipe()
+	ok, err := verifyLegacy(w, passphrase)
 	if err != nil {
 		return err
 	}
-	if w == nil || w.Mnemonic == nil {
-		return fmt.Errorf("no active wallet")
+	if !ok {
+		return ErrInvalidPassword
 	}
-	hashSeed := sha256.Sum256(w.Mnemonic.Seed(passphrase))
-	unlockKey := hex.EncodeToString(hashSeed[:])
+	return s.walletRepo.Seal(w, passphrase, []byte(password))
+}
 
-	if w.Lock != unlockKey {
-		return fmt.Errorf("invalid passphrase")
+// verifyLegacy checks passphrase against the lock of a wallet stored before seeds were
+// encrypted.
+func verifyLegacy(w *wallet.Wallet, passphrase string) (bool, error) {
+	seed := w.Mnemonic.Seed(passphrase)
+	defer secret.Wipe(seed)
+	return wallet.VerifyLock(w.Lock, seed)
+}
+
+// unlockDelay returns how long to wait after the given number of consecutive failures.
+func unlockDelay(failures int) time.Duration {
+	if failures < freeUnlockAttempts {
+		return 0
 	}
-	return nil
+	delay := unlockBackoffBase
+	for i := freeUnlockAttempts; i < failures && delay < unlockBackoffMax; i++ {
+		delay *= 2
+	}
+	return min(delay, unlockBackoffMax)
 }
 
-func (s *WalletService) ImportWallet(name string, mnemonicPhrase string, passphrase string) error {
+// ImportWallet validates mnemonicPhrase and stores it like AddWallet.
+func (s *WalletService) ImportWallet(name string, mnemonicPhrase string, passphrase, password string) error {
 	if name == "" {
 		return fmt.Errorf("invalid wallet data")
 	}
@@ -59,16 +164,7 @@ func (s *WalletService) ImportWallet(name string, mnemonicPhrase string, passphr
 	if err := validator.Validate(mnemonicPhrase); err != nil {
 		return fmt.Errorf("invalid mnemonic: %v", err)
 	}
-	words := validator.Normalize(mnemonicPhrase)
-	m := mnemonic.New(words)
-	model := wallet.New(&m, passphrase, "")
-	model.Name = name
-	model.CreatedAt = time.Now()
-	err := s.walletRepo.Save(model)
-	if err != nil {
-		return err
-	}
-	return s.walletRepo.SetDefault(name)
+	return s.AddWallet(name, mnemonic.New(validator.Normalize(mnemonicPhrase)), passphrase, password)
 }
 
 func (s *WalletService) WalletRepo() *walletdb.WalletDB {
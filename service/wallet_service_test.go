@@ -6,7 +6,9 @@ import (
 	"testing"
 	"time"
 
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/satelliondao/satellion/mnemonic"
+	"github.com/satelliondao/satellion/wallet"
 	"github.com/satelliondao/satellion/walletdb"
 	"github.com/stretchr/testify/assert"
 )
@@ -21,7 +23,7 @@ func setupTestWalletService(t *testing.T) (*WalletService, string, func()) {
 	if err != nil {
 		t.Fatalf("connect failed: %v", err)
 	}
-	repo := walletdb.New(db)
+	repo := walletdb.New(db, &chaincfg.MainNetParams)
 	service := NewWalletService(repo)
 	cleanup := func() {
 		db.Close()
@@ -39,17 +41,17 @@ func TestWalletService_AddWallet_SetsCreatedAt(t *testing.T) {
 	}
 	testMnemonic := mnemonic.New(words)
 	beforeAdd := time.Now()
-	err := service.AddWallet("test-wallet", testMnemonic, "")
+	err := service.AddWallet("test-wallet", testMnemonic, "", "password")
 	afterAdd := time.Now()
 	assert.NoError(t, err)
-	retrievedWallet, err := service.walletRepo.Get("test-wallet", "")
+	retrievedWallet, err := service.walletRepo.Get("test-wallet", []byte("password"))
 	assert.NoError(t, err)
 	assert.False(t, retrievedWallet.CreatedAt.IsZero(), "CreatedAt should be set")
 	assert.True(t, retrievedWallet.CreatedAt.After(beforeAdd) || retrievedWallet.CreatedAt.Equal(beforeAdd))
 	assert.True(t, retrievedWallet.CreatedAt.Before(afterAdd) || retrievedWallet.CreatedAt.Equal(afterAdd))
 }
 
-func TestWalletService_Unlock_ValidatesPassphrase(t *testing.T) {
+func TestWalletService_Unlock_ValidatesPassword(t *testing.T) {
 	service, _, cleanup := setupTestWalletService(t)
 	defer cleanup()
 	words := []string{
@@ -57,11 +59,100 @@ func TestWalletService_Unlock_ValidatesPassphrase(t *testing.T) {
 		"abandon", "abandon", "abandon", "abandon", "abandon", "about",
 	}
 	testMnemonic := mnemonic.New(words)
-	err := service.AddWallet("test-wallet", testMnemonic, "correct-passphrase")
+	err := service.AddWallet("test-wallet", testMnemonic, "", "correct-password")
 	assert.NoError(t, err)
-	err = service.Unlock("correct-passphrase")
+	err = service.Unlock("correct-password")
 	assert.NoError(t, err)
-	err = service.Unlock("wrong-passphrase")
+	err = service.Unlock("wrong-password")
 	assert.Error(t, err)
-	assert.Contains(t, err.Error(), "invalid passphrase")
+	assert.Contains(t, err.Error(), "invalid password")
+}
+
+func TestWalletService_PasswordIsSeparateFromPassphrase(t *testing.T) {
+	service, _, cleanup := setupTestWalletService(t)
+	defer cleanup()
+	words := []string{
+		"abandon", "abandon", "abandon", "abandon", "abandon", "abandon",
+		"abandon", "abandon", "abandon", "abandon", "abandon", "about",
+	}
+	m := mnemonic.New(words)
+	assert.ErrorIs(t, service.AddWallet("test-wallet", m, "TREZOR", ""), ErrEmptyPassword)
+	assert.NoError(t, service.AddWallet("test-wallet", m, "TREZOR", "password"))
+
+	assert.ErrorIs(t, service.Unlock("TREZOR"), ErrInvalidPassword)
+	assert.NoError(t, service.Unlock("password"))
+	w, err := service.walletRepo.Get("test-wallet", []byte("password"))
+	assert.NoError(t, err)
+	assert.True(t, w.Sealed)
+	assert.Equal(t, wallet.MasterFingerprint(&m, "TREZOR"), w.Fingerprint)
+	withPassphrase := wallet.New(&m, "TREZOR", "", &chaincfg.MainNetParams)
+	want, _ := withPassphrase.ReceiveAddress()
+	got, err := w.ReceiveAddress()
+	assert.NoError(t, err)
+	assert.Equal(t, want.Address.String(), got.Address.String(), "addresses depend on the passphrase, not the password")
+
+	listed, err := service.walletRepo.GetAll()
+	assert.NoError(t, err)
+	assert.Nil(t, listed[0].Mnemonic, "sealed wallets are listed locked")
+	assert.Equal(t, w.Fingerprint, listed[0].Fingerprint)
+}
+
+func TestWalletService_SealLegacy(t *testing.T) {
+	service, _, cleanup := setupTestWalletService(t)
+	defer cleanup()
+	m := mnemonic.NewRandom()
+	lock, err := wallet.NewLock(m.Seed("old-passphrase"), wallet.LockParams)
+	assert.NoError(t, err)
+	legacy := wallet.New(m, "old-passphrase", lock, &chaincfg.MainNetParams)
+	legacy.Name = "legacy"
+	assert.NoError(t, service.walletRepo.Save(legacy))
+	assert.NoError(t, service.walletRepo.SetDefault("legacy"))
+
+	assert.NoError(t, service.Unlock("old-passphrase"))
+	assert.ErrorIs(t, service.SealLegacy("wrong", "new-password"), ErrInvalidPassword)
+	assert.NoError(t, service.SealLegacy("old-passphrase", "new-password"))
+	assert.NoError(t, service.Unlock("new-password"))
+	w, err := service.walletRepo.Get("legacy", []byte("new-password"))
+	assert.NoError(t, err)
+	assert.Equal(t, legacy.Fingerprint, w.Fingerprint)
+	assert.Equal(t, m.Words, w.Mnemonic.Words)
+}
+
+func TestWalletService_Unlock_BacksOffAfterFailures(t *testing.T) {
+	service, _, cleanup := setupTestWalletService(t)
+	defer cleanup()
+	words := []string{
+		"abandon", "abandon", "abandon", "abandon", "abandon", "abandon",
+		"abandon", "abandon", "abandon", "abandon", "abandon", "about",
+	}
+	assert.NoError(t, service.AddWallet("test-wallet", mnemonic.New(words), "", "correct-password"))
+	now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	for i := 0; i < freeUnlockAttempts; i++ {
+		assert.ErrorIs(t, service.Unlock("wrong-password"), ErrInvalidPassword)
+	}
+	var throttled *UnlockThrottledError
+	err := service.Unlock("correct-password")
+	assert.ErrorAs(t, err, &throttled)
+	assert.Equal(t, unlockBackoffBase, throttled.Wait)
+
+	// The back-off is persisted, so a fresh service over the same database is throttled too.
+	restarted := NewWalletService(service.walletRepo)
+	restarted.now = service.now
+	assert.ErrorAs(t, restarted.Unlock("correct-password"), &throttled)
+
+	now = now.Add(unlockBackoffBase)
+	assert.NoError(t, restarted.Unlock("correct-password"))
+	state, err := service.walletRepo.UnlockState("test-wallet")
+	assert.NoError(t, err)
+	assert.Zero(t, state.Failures)
+}
+
+func TestUnlockDelay(t *testing.T) {
+	assert.Zero(t, unlockDelay(0))
+	assert.Zero(t, unlockDelay(freeUnlockAttempts-1))
+	assert.Equal(t, unlockBackoffBase, unlockDelay(freeUnlockAttempts))
+	assert.Equal(t, 2*unlockBackoffBase, unlockDelay(freeUnlockAttempts+1))
+	assert.Equal(t, unlockBackoffMax, unlockDelay(100))
 }
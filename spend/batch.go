@@ -0,0 +1,129 @@
+package spend
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/satelliondao/satellion/bip21"
+	"github.com/satelliondao/satellion/coinselect"
+)
+
+var (
+	// ErrDuplicateRecipient is returned when a payment pays the same address twice.
+	ErrDuplicateRecipient = errors.New("address is paid twice")
+	// ErrDust is returned when an output is too small to be relayed.
+	ErrDust = errors.New("amount is dust")
+	// ErrWrongNetwork is returned when an address belongs to another network.
+	ErrWrongNetwork = errors.New("address is for another network")
+)
+
+// networks are the networks an address may come from, to name the one of a mismatch.
+var networks = []*chaincfg.Params{
+	&chaincfg.MainNetParams, &chaincfg.TestNet3Params, &chaincfg.SigNetParams, &chaincfg.RegressionNetParams,
+}
+
+// CheckOutputs checks recipients before signing: every address is for params and paid
+// once, and no amount is dust for its address. One recipient may have a zero amount to
+// receive everything left, which is checked once known.
+func CheckOutputs(recipients []Output, params *chaincfg.Params) error {
+	if len(recipients) == 0 {
+		return fmt.Errorf("no recipient")
+	}
+	if rest := Rest(recipients); rest >= 0 && Rest(recipients[rest+1:]) >= 0 {
+		return fmt.Errorf("only one recipient can receive everything left")
+	}
+	seen := make(map[string]bool, len(recipients))
+	for i, o := range recipients {
+		addr, err := decodeAddress(o.Address, params)
+		if err != nil {
+			return fmt.Errorf("recipient %d: %w", i+1, err)
+		}
+		encoded := addr.EncodeAddress()
+		if seen[encoded] {
+			return fmt.Errorf("recipient %d: %w: %s", i+1, ErrDuplicateRecipient, encoded)
+		}
+		seen[encoded] = true
+		if o.Amount == 0 {
+			continue
+		}
+		script, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return err
+		}
+		if dust := coinselect.Dust(script); o.Amount < dust {
+			return fmt.Errorf("recipient %d: %w: %s is below %s", i+1, ErrDust, o.Amount, dust)
+		}
+	}
+	return nil
+}
+
+// Rest returns the index of the first recipient receiving everything left, with a zero
+// amount, or -1 when all amounts are set.
+func Rest(recipients []Output) int {
+	for i, o := range recipients {
+		if o.Amount == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// decodeAddress decodes an address for params, naming the network of addresses for
+// another one.
+func decodeAddress(s string, params *chaincfg.Params) (btcutil.Address, error) {
+	addr, err := btcutil.DecodeAddress(s, params)
+	if err == nil && addr.IsForNet(params) {
+		return addr, nil
+	}
+	for _, other := range networks {
+		if other.Name == params.Name {
+			continue
+		}
+		if a, err := btcutil.DecodeAddress(s, other); err == nil && a.IsForNet(other) {
+			return nil, fmt.Errorf("%w: %s is a %s address, the wallet is on %s", ErrWrongNetwork, s, other.Name, params.Name)
+		}
+	}
+	return nil, fmt.Errorf("%q is not a %s address", s, params.Name)
+}
+
+// ParseBatch reads the recipients of a batch payment as CSV lines of address and amount
+// in BTC. An optional header line, blank lines and lines starting with # are skipped.
+func ParseBatch(r io.Reader) ([]Output, error) {
+	reader := csv.NewReader(r)
+	reader.Comment = '#'
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	var list []Output
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		line, _ := reader.FieldPos(0)
+		if len(record) != 2 {
+			return nil, fmt.Errorf("line %d: expected address,amount", line)
+		}
+		addr, amount := strings.TrimSpace(record[0]), strings.TrimSpace(record[1])
+		if len(list) == 0 && strings.EqualFold(addr, "address") {
+			continue
+		}
+		value, err := bip21.ParseAmount(amount)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		list = append(list, Output{Address: addr, Amount: value})
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("no recipients in the file")
+	}
+	return list, nil
+}
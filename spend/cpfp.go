@@ -0,0 +1,92 @@
+package spend
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satelliondao/satellion/coinselect"
+	"github.com/satelliondao/satellion/wallet"
+)
+
+// Incoming returns the outputs of tx paying addresses of w. They are unconfirmed, with
+// a zero height.
+func Incoming(w *wallet.Wallet, tx *wire.MsgTx) ([]wallet.Utxo, error) {
+	owned := make(map[string]*wallet.Address)
+	last := max(w.NextReceiveIndex, w.NextChangeIndex) + wallet.GapLimit
+	for _, change := range []uint32{0, 1} {
+		for i := uint32(0); i <= last; i++ {
+			addr, err := w.DeriveTaprootAddress(change, i)
+			if err != nil {
+				return nil, err
+			}
+			script, err := addr.DeriveTaprootScriptPubKey()
+			if err != nil {
+				return nil, err
+			}
+			owned[string(script)] = addr
+		}
+	}
+	var list []wallet.Utxo
+	hash := tx.TxHash()
+	for i, out := range tx.TxOut {
+		addr, ok := owned[string(out.PkScript)]
+		if !ok {
+			continue
+		}
+		list = append(list, wallet.Utxo{
+			OutPoint: wire.OutPoint{Hash: hash, Index: uint32(i)},
+			Value:    btcutil.Amount(out.Value),
+			PkScript: out.PkScript,
+			Address:  addr.Address.String(),
+			Change:   addr.Change,
+			Index:    addr.DeriviationIndex,
+		})
+	}
+	return list, nil
+}
+
+// ChildFee returns the fee a child of childVSize vbytes pays for its unconfirmed parent
+// so that both reach feeRate sat/vB together, and at least its own relay.
+func ChildFee(parentVSize int64, parentFee btcutil.Amount, childVSize int64, feeRate btcutil.Amount) btcutil.Amount {
+	fee := btcutil.Amount(parentVSize+childVSize)*feeRate - parentFee
+	return max(fee, btcutil.Amount(childVSize)*coinselect.IncrementalRelayFeeRate)
+}
+
+// PackageFeeRate returns the fee rate in sat/vB miners get for mining a parent and its
+// child together.
+func PackageFeeRate(parentVSize int64, parentFee btcutil.Amount, childVSize int64, childFee btcutil.Amount) float64 {
+	return float64(parentFee+childFee) / float64(parentVSize+childVSize)
+}
+
+// Child signs a transaction spending out, an output of the unconfirmed parent, to the
+// change address, paying the fee that brings parent and child to feeRate sat/vB.
+// parentFee is the fee of the parent as reported by its sender or a block explorer: a
+// light client cannot see the values the parent spends.
+func Child(w *wallet.Wallet, parent *wire.MsgTx, parentFee btcutil.Amount, out wallet.Utxo, feeRate btcutil.Amount, change string, params *chaincfg.Params) (*Transaction, error) {
+	if out.OutPoint.Hash != parent.TxHash() {
+		return nil, fmt.Errorf("%s is not an output of %s", out.OutPoint, parent.TxHash())
+	}
+	parentVSize := vsize(parent)
+	if rate := float64(parentFee) / float64(parentVSize); rate >= float64(feeRate) {
+		return nil, fmt.Errorf("the parent already pays %.1f sat/vB", rate)
+	}
+	childVSize := coinselect.VSize(1, 1)
+	fee := ChildFee(parentVSize, parentFee, childVSize, feeRate)
+	if out.Value-fee < coinselect.DustLimit {
+		return nil, fmt.Errorf("%w: the output of %s cannot pay a fee of %s", coinselect.ErrInsufficientFunds, out.Value, fee)
+	}
+	sel := &coinselect.Selection{Inputs: []wallet.Utxo{out}, Fee: fee, Change: out.Value - fee}
+	t, err := Sign(w, sel, nil, change, params)
+	if err != nil {
+		return nil, err
+	}
+	t.Parent = parent.TxHash().String()
+	return t, nil
+}
+
+// VSize returns the virtual size of tx.
+func VSize(tx *wire.MsgTx) int64 {
+	return vsize(tx)
+}
@@ -0,0 +1,229 @@
+// Package spend builds and signs the transactions sent by the wallet, and the BIP125
+// replacements that bump their fee or cancel them.
+package spend
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satelliondao/satellion/coinselect"
+	"github.com/satelliondao/satellion/wallet"
+)
+
+// rbfSequence signals that inputs may be replaced, as in BIP125.
+const rbfSequence = wire.MaxTxInSequenceNum - 2
+
+// ErrNotReplaceable is returned when replacing a transaction that is not pending.
+var ErrNotReplaceable = errors.New("only pending transactions can be replaced")
+
+// Status is the state of a transaction sent by the wallet.
+type Status string
+
+const (
+	Pending   Status = "pending"
+	Confirmed Status = "confirmed"
+	// Replaced transactions had an input spent by another transaction, one of their
+	// replacements or a double spend.
+	Replaced Status = "replaced"
+)
+
+// Output is a payment to Address. Change outputs pay back to the wallet.
+type Output struct {
+	Address string         `json:"address"`
+	Amount  btcutil.Amount `json:"amount"`
+	Change  bool           `json:"change,omitempty"`
+}
+
+// Transaction is a transaction signed by the wallet, with what it spends and pays.
+type Transaction struct {
+	Txid string `json:"txid"`
+	// Raw is the signed transaction in hex.
+	Raw     string         `json:"raw"`
+	Inputs  []wallet.Utxo  `json:"inputs"`
+	Outputs []Output       `json:"outputs"`
+	Fee     btcutil.Amount `json:"fee"`
+	Status  Status         `json:"status"`
+	// Height is the height of the block confirming the transaction.
+	Height int32 `json:"height,omitempty"`
+	// Replaces is the txid of the transaction this one replaces, ReplacedBy the txid of
+	// its replacement once known.
+	Replaces   string `json:"replaces,omitempty"`
+	ReplacedBy string `json:"replaced_by,omitempty"`
+	// Cancel reports whether the transaction cancels Replaces by paying everything back
+	// to the wallet.
+	Cancel bool `json:"cancel,omitempty"`
+	// Parent is the txid of the unconfirmed transaction this child pays for.
+	Parent    string    `json:"parent,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// MsgTx decodes the signed transaction.
+func (t *Transaction) MsgTx() (*wire.MsgTx, error) {
+	raw, err := hex.DecodeString(t.Raw)
+	if err != nil {
+		return nil, err
+	}
+	tx := wire.NewMsgTx(wire.TxVersion)
+	if err := tx.Deserialize(bytes.NewReader(raw)); err != nil {
+		return nil, err
+	}
+	return tx, nil
+}
+
+// VSize returns the virtual size of the signed transaction.
+func (t *Transaction) VSize() int64 {
+	tx, err := t.MsgTx()
+	if err != nil {
+		return 0
+	}
+	return vsize(tx)
+}
+
+// FeeRate returns the fee rate in sat/vB.
+func (t *Transaction) FeeRate() float64 {
+	size := t.VSize()
+	if size == 0 {
+		return 0
+	}
+	return float64(t.Fee) / float64(size)
+}
+
+// Recipients returns the outputs that do not pay back to the wallet.
+func (t *Transaction) Recipients() []Output {
+	var list []Output
+	for _, o := range t.Outputs {
+		if !o.Change {
+			list = append(list, o)
+		}
+	}
+	return list
+}
+
+// Sent returns the total paid to the recipients.
+func (t *Transaction) Sent() btcutil.Amount {
+	var sum btcutil.Amount
+	for _, o := range t.Recipients() {
+		sum += o.Amount
+	}
+	return sum
+}
+
+// Sign builds the transaction paying recipients with the inputs of sel, returning its
+// change to the change address, and signs it with w. Every input signals replaceability.
+func Sign(w *wallet.Wallet, sel *coinselect.Selection, recipients []Output, change string, params *chaincfg.Params) (*Transaction, error) {
+	outputs := append([]Output{}, recipients...)
+	if sel.Change > 0 {
+		outputs = append(outputs, Output{Address: change, Amount: sel.Change, Change: true})
+	}
+	tx := wire.NewMsgTx(2)
+	for _, u := range sel.Inputs {
+		in := wire.NewTxIn(&u.OutPoint, nil, nil)
+		in.Sequence = rbfSequence
+		tx.AddTxIn(in)
+	}
+	for _, o := range outputs {
+		addr, err := decodeAddress(o.Address, params)
+		if err != nil {
+			return nil, err
+		}
+		script, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return nil, err
+		}
+		tx.AddTxOut(wire.NewTxOut(int64(o.Amount), script))
+	}
+	if err := w.SignTransaction(tx, sel.Inputs); err != nil {
+		return nil, err
+	}
+	var buf bytes.Buffer
+	if err := tx.Serialize(&buf); err != nil {
+		return nil, err
+	}
+	return &Transaction{
+		Txid:      tx.TxHash().String(),
+		Raw:       hex.EncodeToString(buf.Bytes()),
+		Inputs:    sel.Inputs,
+		Outputs:   outputs,
+		Fee:       sel.Fee,
+		Status:    Pending,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// Bump signs a replacement of orig paying the same recipients at feeRate sat/vB. The
+// fee comes out of the change, and out of extra inputs picked from utxos when the
+// inputs of orig do not cover it. Outputs in exclude, keyed by txid:vout, are never
+// added: the frozen ones and those spent by other pending transactions.
+func Bump(w *wallet.Wallet, orig *Transaction, feeRate btcutil.Amount, utxos []wallet.Utxo, exclude map[string]bool, change string, params *chaincfg.Params) (*Transaction, error) {
+	if err := checkReplaceable(orig, feeRate); err != nil {
+		return nil, err
+	}
+	recipients := orig.Recipients()
+	sel, err := coinselect.Select(coinselect.Request{
+		Utxos:    utxos,
+		Pending:  exclude,
+		Required: orig.Inputs,
+		Replaces: orig.Fee,
+		Amount:   orig.Sent(),
+		Outputs:  len(recipients),
+		FeeRate:  feeRate,
+	})
+	if err != nil {
+		return nil, err
+	}
+	t, err := Sign(w, sel, recipients, change, params)
+	if err != nil {
+		return nil, err
+	}
+	t.Replaces = orig.Txid
+	return t, nil
+}
+
+// Cancel signs a replacement of orig spending its inputs back to the change address
+// at feeRate sat/vB, so that the recipients are never paid if it confirms first.
+func Cancel(w *wallet.Wallet, orig *Transaction, feeRate btcutil.Amount, change string, params *chaincfg.Params) (*Transaction, error) {
+	if err := checkReplaceable(orig, feeRate); err != nil {
+		return nil, err
+	}
+	var in btcutil.Amount
+	for _, u := range orig.Inputs {
+		in += u.Value
+	}
+	size := coinselect.VSize(len(orig.Inputs), 1)
+	fee := max(btcutil.Amount(size)*feeRate, orig.Fee+btcutil.Amount(size)*coinselect.IncrementalRelayFeeRate)
+	if in-fee < coinselect.DustLimit {
+		return nil, fmt.Errorf("%w: inputs of %s do not pay a fee of %s", coinselect.ErrInsufficientFunds, in, fee)
+	}
+	sel := &coinselect.Selection{Inputs: orig.Inputs, Fee: fee, Change: in - fee}
+	t, err := Sign(w, sel, nil, change, params)
+	if err != nil {
+		return nil, err
+	}
+	t.Replaces, t.Cancel = orig.Txid, true
+	return t, nil
+}
+
+// checkReplaceable checks that orig is pending and that feeRate beats its fee rate, a
+// condition of BIP125.
+func checkReplaceable(orig *Transaction, feeRate btcutil.Amount) error {
+	if orig.Status != Pending {
+		return fmt.Errorf("%w: %s is %s", ErrNotReplaceable, orig.Txid, orig.Status)
+	}
+	if float64(feeRate) <= orig.FeeRate() {
+		return fmt.Errorf("fee rate must be above the current %.1f sat/vB", orig.FeeRate())
+	}
+	return nil
+}
+
+// vsize returns the virtual size of tx: its weight divided by 4, rounded up.
+func vsize(tx *wire.MsgTx) int64 {
+	weight := int64(tx.SerializeSizeStripped()*3 + tx.SerializeSize())
+	return (weight + 3) / 4
+}
@@ -0,0 +1,180 @@
+package spend
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satelliondao/satellion/coinselect"
+	"github.com/satelliondao/satellion/mnemonic"
+	"github.com/satelliondao/satellion/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var params = &chaincfg.MainNetParams
+
+// recipient is the first BIP86 test vector receive address of "abandon ... about".
+const recipient = "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr"
+
+func testWallet(t *testing.T) (*wallet.Wallet, []wallet.Utxo, string) {
+	m := mnemonic.New([]string{
+		"abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "about",
+	})
+	w := wallet.New(&m, "", "", &chaincfg.MainNetParams)
+	var utxos []wallet.Utxo
+	for i, value := range []btcutil.Amount{40_000, 20_000} {
+		addr, err := w.DeriveTaprootAddress(0, uint32(i+1))
+		require.NoError(t, err)
+		script, err := addr.DeriveTaprootScriptPubKey()
+		require.NoError(t, err)
+		utxos = append(utxos, wallet.Utxo{
+			OutPoint: wire.OutPoint{Hash: [32]byte{byte(i + 1)}},
+			Value:    value,
+			PkScript: script,
+			Address:  addr.Address.String(),
+			Index:    uint32(i + 1),
+		})
+	}
+	change, err := w.ChangeAddress()
+	require.NoError(t, err)
+	return w, utxos, change.Address.String()
+}
+
+func send(t *testing.T, w *wallet.Wallet, utxos []wallet.Utxo, change string, amount btcutil.Amount) *Transaction {
+	sel, err := coinselect.Select(coinselect.Request{Utxos: utxos, Amount: amount, FeeRate: 2})
+	require.NoError(t, err)
+	tx, err := Sign(w, sel, []Output{{Address: recipient, Amount: amount}}, change, params)
+	require.NoError(t, err)
+	return tx
+}
+
+func TestSign(t *testing.T) {
+	w, utxos, change := testWallet(t)
+	tx := send(t, w, utxos, change, 25_000)
+	assert.Equal(t, Pending, tx.Status)
+	require.Len(t, tx.Outputs, 2)
+	assert.True(t, tx.Outputs[1].Change)
+	assert.Equal(t, btcutil.Amount(25_000), tx.Sent())
+
+	msg, err := tx.MsgTx()
+	require.NoError(t, err)
+	assert.Equal(t, tx.Txid, msg.TxHash().String())
+	assert.Equal(t, uint32(rbfSequence), msg.TxIn[0].Sequence)
+	assert.LessOrEqual(t, tx.VSize(), coinselect.VSize(1, 2), "estimates never undercount")
+	assert.GreaterOrEqual(t, tx.FeeRate(), 2.0)
+
+	_, err = Sign(w, &coinselect.Selection{Inputs: utxos[:1]}, []Output{{Address: "tb1qnotmainnet", Amount: 1}}, change, params)
+	assert.Error(t, err)
+}
+
+func TestBump(t *testing.T) {
+	w, utxos, change := testWallet(t)
+	orig := send(t, w, utxos, change, 25_000)
+	exclude := map[string]bool{orig.Inputs[0].Ref(): true}
+
+	bumped, err := Bump(w, orig, 10, utxos, exclude, change, params)
+	require.NoError(t, err)
+	assert.Equal(t, orig.Txid, bumped.Replaces)
+	assert.Equal(t, orig.Recipients(), bumped.Recipients())
+	assert.Equal(t, orig.Inputs, bumped.Inputs)
+	assert.GreaterOrEqual(t, bumped.Fee, orig.Fee+btcutil.Amount(bumped.VSize()), "BIP125 rule 4")
+	assert.Greater(t, bumped.FeeRate(), orig.FeeRate())
+
+	// A fee the change cannot pay brings in the other output.
+	big, err := Bump(w, orig, 200, utxos, exclude, change, params)
+	require.NoError(t, err)
+	assert.Len(t, big.Inputs, 2)
+
+	_, err = Bump(w, orig, 1, utxos, exclude, change, params)
+	assert.Error(t, err, "the fee rate must go up")
+	orig.Status = Confirmed
+	_, err = Bump(w, orig, 10, utxos, exclude, change, params)
+	assert.ErrorIs(t, err, ErrNotReplaceable)
+}
+
+func TestCancel(t *testing.T) {
+	w, utxos, change := testWallet(t)
+	orig := send(t, w, utxos, change, 25_000)
+	cancel, err := Cancel(w, orig, 5, change, params)
+	require.NoError(t, err)
+	assert.True(t, cancel.Cancel)
+	assert.Empty(t, cancel.Recipients())
+	require.Len(t, cancel.Outputs, 1)
+	assert.Equal(t, change, cancel.Outputs[0].Address)
+	assert.Equal(t, orig.Inputs[0].Value-cancel.Fee, cancel.Outputs[0].Amount)
+	assert.GreaterOrEqual(t, cancel.Fee, orig.Fee+btcutil.Amount(cancel.VSize()))
+}
+
+func TestChild(t *testing.T) {
+	w, utxos, change := testWallet(t)
+	// A parent from someone else paying the wallet, with a made-up input.
+	parent := wire.NewMsgTx(2)
+	parent.AddTxIn(wire.NewTxIn(&wire.OutPoint{Hash: [32]byte{9}}, nil, [][]byte{make([]byte, 64)}))
+	parent.AddTxOut(wire.NewTxOut(70_000, []byte{0x51}))
+	parent.AddTxOut(wire.NewTxOut(30_000, utxos[1].PkScript))
+
+	incoming, err := Incoming(w, parent)
+	require.NoError(t, err)
+	require.Len(t, incoming, 1)
+	out := incoming[0]
+	assert.Equal(t, uint32(1), out.OutPoint.Index)
+	assert.Equal(t, utxos[1].Address, out.Address)
+	assert.Zero(t, out.Height)
+
+	parentFee := btcutil.Amount(VSize(parent))
+	child, err := Child(w, parent, parentFee, out, 10, change, params)
+	require.NoError(t, err)
+	assert.Equal(t, parent.TxHash().String(), child.Parent)
+	require.Len(t, child.Outputs, 1)
+	assert.Equal(t, out.Value-child.Fee, child.Outputs[0].Amount)
+	assert.GreaterOrEqual(t, PackageFeeRate(VSize(parent), parentFee, child.VSize(), child.Fee), 10.0)
+
+	_, err = Child(w, parent, parentFee*20, out, 10, change, params)
+	assert.Error(t, err, "the parent already pays enough")
+	_, err = Child(w, parent, parentFee, out, 1_000, change, params)
+	assert.ErrorIs(t, err, coinselect.ErrInsufficientFunds)
+}
+
+func TestChildFee(t *testing.T) {
+	assert.Equal(t, btcutil.Amount(10*(200+100)-200), ChildFee(200, 200, 100, 10))
+	assert.Equal(t, btcutil.Amount(100), ChildFee(200, 5_000, 100, 10), "the child pays at least its own relay")
+	assert.Equal(t, 10.0, PackageFeeRate(200, 200, 100, 2_800))
+}
+
+// BIP173 test vectors: a mainnet P2WPKH address and the same program on testnet.
+const (
+	p2wpkh  = "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4"
+	testnet = "tb1qw508d6qejxtdg4y5r3zarvary0c5xw7kxpjzsx"
+)
+
+func TestCheckOutputs(t *testing.T) {
+	assert.NoError(t, CheckOutputs([]Output{{Address: recipient, Amount: 330}, {Address: p2wpkh, Amount: 294}}, params))
+	assert.NoError(t, CheckOutputs([]Output{{Address: recipient, Amount: 1_000}, {Address: p2wpkh}}, params), "one recipient takes the rest")
+
+	err := CheckOutputs([]Output{{Address: recipient, Amount: 1_000}, {Address: recipient, Amount: 2_000}}, params)
+	assert.ErrorIs(t, err, ErrDuplicateRecipient)
+	assert.ErrorIs(t, CheckOutputs([]Output{{Address: recipient, Amount: 329}}, params), ErrDust)
+	assert.ErrorIs(t, CheckOutputs([]Output{{Address: p2wpkh, Amount: 293}}, params), ErrDust)
+	err = CheckOutputs([]Output{{Address: testnet, Amount: 1_000}}, params)
+	assert.ErrorIs(t, err, ErrWrongNetwork)
+	assert.Contains(t, err.Error(), "testnet3")
+	assert.Error(t, CheckOutputs([]Output{{Address: "bc1qnotanaddress", Amount: 1_000}}, params))
+	assert.Error(t, CheckOutputs([]Output{{Address: recipient}, {Address: p2wpkh}}, params), "only one recipient takes the rest")
+	assert.Error(t, CheckOutputs(nil, params))
+}
+
+func TestParseBatch(t *testing.T) {
+	list, err := ParseBatch(strings.NewReader("address,amount\n" + recipient + ", 0.001\n\n# rent\n" + p2wpkh + ",1\n"))
+	require.NoError(t, err)
+	assert.Equal(t, []Output{{Address: recipient, Amount: 100_000}, {Address: p2wpkh, Amount: 100_000_000}}, list)
+
+	_, err = ParseBatch(strings.NewReader(recipient + ",0.001,extra\n"))
+	assert.ErrorContains(t, err, "line 1")
+	_, err = ParseBatch(strings.NewReader(recipient + ",1e3\n"))
+	assert.Error(t, err)
+	_, err = ParseBatch(strings.NewReader("address,amount\n"))
+	assert.Error(t, err)
+}
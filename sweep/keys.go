@@ -0,0 +1,159 @@
+// Package sweep moves the coins of external keys, such as those of paper wallets, to
+// the wallet. The keys are only held in memory and are never stored.
+package sweep
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/satelliondao/satellion/wallet"
+)
+
+// ErrWrongNetwork is returned for keys of another network.
+var ErrWrongNetwork = errors.New("key is for another network")
+
+// Kind is the type of output a key spends, named as in output descriptors.
+type Kind string
+
+const (
+	P2PKH  Kind = "pkh"
+	P2WPKH Kind = "wpkh"
+	P2TR   Kind = "tr"
+)
+
+// Key is an external private key with the address of one kind it spends.
+type Key struct {
+	Kind    Kind
+	Address btcutil.Address
+	Script  []byte
+	priv    *btcec.PrivateKey
+	// compressed tells whether P2PKH addresses hash the compressed public key.
+	compressed bool
+}
+
+// Parse parses keys separated by spaces, commas or new lines. Each is a WIF private key,
+// which may have been used with any address kind and stands for all of them, or a
+// single-key descriptor such as wpkh(WIF), pkh(WIF) or tr(WIF), with or without a
+// checksum. Uncompressed WIF keys only have P2PKH addresses.
+func Parse(s string, params *chaincfg.Params) ([]*Key, error) {
+	fields := strings.FieldsFunc(s, func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\n' || r == '\r' || r == '\t'
+	})
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no key given")
+	}
+	var keys []*Key
+	for i, field := range fields {
+		parsed, err := parseKey(field, params)
+		if err != nil {
+			Zero(keys)
+			return nil, fmt.Errorf("key %d: %w", i+1, err)
+		}
+		keys = append(keys, parsed...)
+	}
+	return keys, nil
+}
+
+func parseKey(s string, params *chaincfg.Params) ([]*Key, error) {
+	open := strings.IndexByte(s, '(')
+	if open < 0 {
+		wif, err := decodeWIF(s, params)
+		if err != nil {
+			return nil, err
+		}
+		if !wif.CompressPubKey {
+			return newKeys(wif, params, P2PKH)
+		}
+		return newKeys(wif, params, P2PKH, P2WPKH, P2TR)
+	}
+	if i := strings.LastIndexByte(s, '#'); i >= 0 {
+		if want := wallet.DescriptorChecksum(s[:i]); s[i+1:] != want {
+			return nil, fmt.Errorf("descriptor checksum %q does not match, expected %q", s[i+1:], want)
+		}
+		s = s[:i]
+	}
+	kind := Kind(s[:open])
+	if kind != P2PKH && kind != P2WPKH && kind != P2TR {
+		return nil, fmt.Errorf("unsupported descriptor %s(), expected pkh(), wpkh() or tr()", kind)
+	}
+	if !strings.HasSuffix(s, ")") {
+		return nil, fmt.Errorf("descriptor is not closed")
+	}
+	inner := s[open+1 : len(s)-1]
+	// Key origins only document where the key came from.
+	if strings.HasPrefix(inner, "[") {
+		end := strings.IndexByte(inner, ']')
+		if end < 0 {
+			return nil, fmt.Errorf("descriptor key origin is not closed")
+		}
+		inner = inner[end+1:]
+	}
+	wif, err := decodeWIF(inner, params)
+	if err != nil {
+		return nil, fmt.Errorf("descriptor must hold a WIF private key: %w", err)
+	}
+	if kind != P2PKH && !wif.CompressPubKey {
+		return nil, fmt.Errorf("%s() needs a compressed key", kind)
+	}
+	return newKeys(wif, params, kind)
+}
+
+func decodeWIF(s string, params *chaincfg.Params) (*btcutil.WIF, error) {
+	wif, err := btcutil.DecodeWIF(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid WIF private key")
+	}
+	if !wif.IsForNet(params) {
+		return nil, fmt.Errorf("%w: the wallet is on %s", ErrWrongNetwork, params.Name)
+	}
+	return wif, nil
+}
+
+// newKeys returns the keys of wif for each kind.
+func newKeys(wif *btcutil.WIF, params *chaincfg.Params, kinds ...Kind) ([]*Key, error) {
+	pub := wif.PrivKey.PubKey()
+	keys := make([]*Key, 0, len(kinds))
+	for _, kind := range kinds {
+		var addr btcutil.Address
+		var err error
+		switch kind {
+		case P2PKH:
+			addr, err = btcutil.NewAddressPubKeyHash(btcutil.Hash160(wif.SerializePubKey()), params)
+		case P2WPKH:
+			addr, err = btcutil.NewAddressWitnessPubKeyHash(btcutil.Hash160(pub.SerializeCompressed()), params)
+		case P2TR:
+			addr, err = btcutil.NewAddressTaproot(schnorr.SerializePubKey(txscript.ComputeTaprootKeyNoScript(pub)), params)
+		}
+		if err != nil {
+			return nil, err
+		}
+		script, err := txscript.PayToAddrScript(addr)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, &Key{Kind: kind, Address: addr, Script: script, priv: wif.PrivKey, compressed: wif.CompressPubKey})
+	}
+	return keys, nil
+}
+
+// Addresses returns the addresses of keys, to scan for.
+func Addresses(keys []*Key) []btcutil.Address {
+	list := make([]btcutil.Address, len(keys))
+	for i, k := range keys {
+		list[i] = k.Address
+	}
+	return list
+}
+
+// Zero wipes the private keys.
+func Zero(keys []*Key) {
+	for _, k := range keys {
+		k.priv.Zero()
+	}
+}
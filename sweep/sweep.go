@@ -0,0 +1,103 @@
+package sweep
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satelliondao/satellion/coinselect"
+	"github.com/satelliondao/satellion/wallet"
+)
+
+// Virtual sizes of the inputs spending each kind of key, rounded up.
+var inputVSize = map[Kind]int64{
+	P2PKH:  148,
+	P2WPKH: 68,
+	P2TR:   coinselect.InputVSize,
+}
+
+// uncompressedExtra is what an uncompressed public key adds to a P2PKH input.
+const uncompressedExtra = 32
+
+// Sweep is a signed transaction moving every coin of external keys to one address.
+type Sweep struct {
+	Tx     *wire.MsgTx
+	Inputs []wallet.Utxo
+	Fee    btcutil.Amount
+	// Amount is what the address receives: the coins less the fee.
+	Amount btcutil.Amount
+	To     btcutil.Address
+}
+
+// VSize returns the estimated virtual size of a sweep of utxos paid by keys.
+func VSize(keys []*Key, utxos []wallet.Utxo) (int64, error) {
+	size := int64(coinselect.TxOverheadVSize + coinselect.OutputVSize)
+	for _, u := range utxos {
+		k := find(keys, u.PkScript)
+		if k == nil {
+			return 0, fmt.Errorf("no key spends %s", u.OutPoint)
+		}
+		size += inputVSize[k.Kind]
+		if k.Kind == P2PKH && !k.compressed {
+			size += uncompressedExtra
+		}
+	}
+	return size, nil
+}
+
+// Sign signs a transaction spending utxos, paid to the addresses of keys, to the address
+// to at feeRate sat/vB.
+func Sign(keys []*Key, utxos []wallet.Utxo, to btcutil.Address, feeRate btcutil.Amount) (*Sweep, error) {
+	if len(utxos) == 0 {
+		return nil, fmt.Errorf("%w: the keys have no coins", coinselect.ErrInsufficientFunds)
+	}
+	size, err := VSize(keys, utxos)
+	if err != nil {
+		return nil, err
+	}
+	var in btcutil.Amount
+	for _, u := range utxos {
+		in += u.Value
+	}
+	fee := btcutil.Amount(size) * feeRate
+	if in-fee < coinselect.DustLimit {
+		return nil, fmt.Errorf("%w: coins of %s do not pay a fee of %s", coinselect.ErrInsufficientFunds, in, fee)
+	}
+	script, err := txscript.PayToAddrScript(to)
+	if err != nil {
+		return nil, err
+	}
+	tx := wire.NewMsgTx(2)
+	prevOuts := txscript.NewMultiPrevOutFetcher(nil)
+	for _, u := range utxos {
+		tx.AddTxIn(wire.NewTxIn(&u.OutPoint, nil, nil))
+		prevOuts.AddPrevOut(u.OutPoint, wire.NewTxOut(int64(u.Value), u.PkScript))
+	}
+	tx.AddTxOut(wire.NewTxOut(int64(in-fee), script))
+	hashes := txscript.NewTxSigHashes(tx, prevOuts)
+	for i, u := range utxos {
+		k := find(keys, u.PkScript)
+		switch k.Kind {
+		case P2PKH:
+			tx.TxIn[i].SignatureScript, err = txscript.SignatureScript(tx, i, u.PkScript, txscript.SigHashAll, k.priv, k.compressed)
+		case P2WPKH:
+			tx.TxIn[i].Witness, err = txscript.WitnessSignature(tx, hashes, i, int64(u.Value), u.PkScript, txscript.SigHashAll, k.priv, true)
+		case P2TR:
+			tx.TxIn[i].Witness, err = txscript.TaprootWitnessSignature(tx, hashes, i, int64(u.Value), u.PkScript, txscript.SigHashDefault, k.priv)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to sign input %d: %w", i, err)
+		}
+	}
+	return &Sweep{Tx: tx, Inputs: utxos, Fee: fee, Amount: in - fee, To: to}, nil
+}
+
+func find(keys []*Key, script []byte) *Key {
+	for _, k := range keys {
+		if string(k.Script) == string(script) {
+			return k
+		}
+	}
+	return nil
+}
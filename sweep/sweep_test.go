@@ -0,0 +1,94 @@
+package sweep
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satelliondao/satellion/coinselect"
+	"github.com/satelliondao/satellion/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var params = &chaincfg.MainNetParams
+
+// The private key 1, compressed and uncompressed.
+const (
+	wif             = "KwDiBf89QgGbjEhKnhXJuH7LrciVrZi3qYjgd9M7rFU73sVHnoWn"
+	uncompressedWIF = "5HpHagT65TZzG1PH3CSu63k8DbpvD8s5ip4nEB3kEsreAnchuDf"
+)
+
+func TestParse(t *testing.T) {
+	keys, err := Parse(wif, params)
+	require.NoError(t, err)
+	require.Len(t, keys, 3, "a WIF key stands for every kind")
+	assert.Equal(t, "1BgGZ9tcN4rm9KBzDn7KprQz87SZ26SAMH", keys[0].Address.EncodeAddress())
+	assert.Equal(t, "bc1qw508d6qejxtdg4y5r3zarvary0c5xw7kv8f3t4", keys[1].Address.EncodeAddress())
+	assert.Equal(t, P2TR, keys[2].Kind)
+
+	keys, err = Parse(uncompressedWIF, params)
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	assert.Equal(t, "1EHNa6Q4Jz2uvNExL497mE43ikXhwF6kZm", keys[0].Address.EncodeAddress())
+
+	desc := "wpkh(" + wif + ")"
+	keys, err = Parse(desc+"#"+wallet.DescriptorChecksum(desc)+", tr([deadbeef/86h/0h/0h/0/1]"+wif+")", params)
+	require.NoError(t, err)
+	require.Len(t, keys, 2)
+	assert.Equal(t, P2WPKH, keys[0].Kind)
+	assert.Equal(t, P2TR, keys[1].Kind)
+
+	_, err = Parse(desc+"#aaaaaaaa", params)
+	assert.ErrorContains(t, err, "checksum")
+	_, err = Parse("wpkh("+uncompressedWIF+")", params)
+	assert.Error(t, err)
+	_, err = Parse("sh(wpkh("+wif+"))", params)
+	assert.Error(t, err)
+	_, err = Parse(wif, &chaincfg.TestNet3Params)
+	assert.ErrorIs(t, err, ErrWrongNetwork)
+	_, err = Parse("", params)
+	assert.Error(t, err)
+}
+
+func TestSign(t *testing.T) {
+	keys, err := Parse(wif+" "+uncompressedWIF, params)
+	require.NoError(t, err)
+	var utxos []wallet.Utxo
+	for i, k := range keys {
+		utxos = append(utxos, wallet.Utxo{
+			OutPoint: wire.OutPoint{Hash: [32]byte{byte(i + 1)}},
+			Value:    10_000,
+			PkScript: k.Script,
+			Address:  k.Address.EncodeAddress(),
+		})
+	}
+	to, err := btcutil.DecodeAddress("bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr", params)
+	require.NoError(t, err)
+	s, err := Sign(keys, utxos, to, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 40_000-s.Fee, s.Amount)
+	require.Len(t, s.Tx.TxOut, 1)
+
+	prevOuts := txscript.NewMultiPrevOutFetcher(nil)
+	for _, u := range utxos {
+		prevOuts.AddPrevOut(u.OutPoint, wire.NewTxOut(int64(u.Value), u.PkScript))
+	}
+	hashes := txscript.NewTxSigHashes(s.Tx, prevOuts)
+	for i, u := range utxos {
+		engine, err := txscript.NewEngine(u.PkScript, s.Tx, i, txscript.StandardVerifyFlags, nil, hashes, int64(u.Value), prevOuts)
+		require.NoError(t, err)
+		assert.NoError(t, engine.Execute(), "input %d spending %s", i, keys[i].Kind)
+	}
+	size := int64((s.Tx.SerializeSizeStripped()*3 + s.Tx.SerializeSize() + 3) / 4)
+	estimate, err := VSize(keys, utxos)
+	require.NoError(t, err)
+	assert.LessOrEqual(t, size, estimate, "estimates never undercount")
+
+	_, err = Sign(keys, utxos, to, 1_000)
+	assert.ErrorIs(t, err, coinselect.ErrInsufficientFunds)
+	_, err = Sign(keys, nil, to, 2)
+	assert.ErrorIs(t, err, coinselect.ErrInsufficientFunds)
+}
@@ -0,0 +1,293 @@
+package backup
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/satelliondao/satellion/backup"
+	"github.com/satelliondao/satellion/secret"
+	"github.com/satelliondao/satellion/ui/framework"
+	"github.com/satelliondao/satellion/ui/router"
+)
+
+type step int
+
+const (
+	stepMenu step = iota
+	stepPassword
+	stepConfirm
+	stepPath
+	stepRestorePassword
+	stepWorking
+	stepDone
+)
+
+const (
+	actionBackup          = "backup"
+	actionBackupWithSeeds = "backup-seeds"
+	actionRestore         = "restore"
+	actionRestoreSettings = "restore-settings"
+)
+
+type doneMsg struct {
+	lines []string
+	err   error
+}
+
+type state struct {
+	ctx      *framework.AppContext
+	selector *framework.ChoiceSelector
+	step     step
+	action   string
+	password textinput.Model
+	confirm  textinput.Model
+	path     textinput.Model
+	result   []string
+	err      string
+}
+
+func New(ctx *framework.AppContext, params interface{}) framework.Page {
+	return &state{
+		ctx: ctx,
+		selector: framework.NewChoiceSelector([]framework.Choice{
+			{Label: "Create encrypted backup", Value: actionBackup},
+			{Label: "Create encrypted backup including seeds of wallets sharing this password", Value: actionBackupWithSeeds},
+			{Label: "Restore from backup file", Value: actionRestore},
+			{Label: "Restore from backup file, replacing the settings too (except HWI and proxy)", Value: actionRestoreSettings},
+		}),
+		password: passwordInput("Backup password"),
+		confirm:  passwordInput("Repeat password"),
+		path:     pathInput(),
+	}
+}
+
+func (s *state) Init() tea.Cmd {
+	return nil
+}
+
+func (s *state) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	nav := framework.HandleNav(msg, router.Home())
+	if nav != nil {
+		return s, nav
+	}
+	switch v := msg.(type) {
+	case doneMsg:
+		s.step = stepDone
+		s.result = v.lines
+		if v.err != nil {
+			s.err = v.err.Error()
+		}
+		return s, nil
+	case tea.KeyMsg:
+		if v.Type == tea.KeyEnter {
+			return s.handleEnter()
+		}
+	}
+	var cmd tea.Cmd
+	switch s.step {
+	case stepMenu:
+		s.selector.Update(msg)
+	case stepPassword, stepRestorePassword:
+		s.password, cmd = s.password.Update(msg)
+	case stepConfirm:
+		s.confirm, cmd = s.confirm.Update(msg)
+	case stepPath:
+		s.path, cmd = s.path.Update(msg)
+	}
+	return s, cmd
+}
+
+func (s *state) start(action string) (tea.Model, tea.Cmd) {
+	s.action = action
+	s.err = ""
+	if action == actionRestore || action == actionRestoreSettings {
+		s.step = stepPath
+		s.path.Focus()
+	} else {
+		s.step = stepPassword
+		s.password.Focus()
+	}
+	return s, textinput.Blink
+}
+
+func (s *state) handleEnter() (tea.Model, tea.Cmd) {
+	s.err = ""
+	switch s.step {
+	case stepMenu:
+		if selected := s.selector.Selected(); selected != nil {
+			return s.start(selected.Value.(string))
+		}
+	case stepPassword:
+		if s.password.Value() == "" {
+			s.err = "Password cannot be empty"
+			return s, nil
+		}
+		s.password.Blur()
+		s.confirm.Focus()
+		s.step = stepConfirm
+	case stepConfirm:
+		if s.confirm.Value() != s.password.Value() {
+			s.err = "Passwords do not match"
+			s.confirm.SetValue("")
+			return s, nil
+		}
+		s.step = stepWorking
+		return s, s.writeBackup(s.action == actionBackupWithSeeds, s.password.Value())
+	case stepPath:
+		if s.path.Value() == "" {
+			s.err = "Path cannot be empty"
+			return s, nil
+		}
+		s.path.Blur()
+		s.password.Focus()
+		s.step = stepRestorePassword
+	case stepRestorePassword:
+		s.step = stepWorking
+		return s, s.restore(s.path.Value(), s.password.Value(), s.action == actionRestoreSettings)
+	case stepDone:
+		return s, router.Home()
+	}
+	return s, nil
+}
+
+func (s *state) writeBackup(seeds bool, password string) tea.Cmd {
+	walletPassword := s.ctx.Password()
+	return func() tea.Msg {
+		f, err := backup.Export(s.ctx.WalletRepo, s.ctx.Config, seeds, walletPassword)
+		secret.Wipe(walletPassword)
+		if err != nil {
+			return doneMsg{err: err}
+		}
+		path := backup.DefaultPath()
+		if err := backup.WriteFile(path, f, password); err != nil {
+			return doneMsg{err: err}
+		}
+		lines := []string{fmt.Sprintf("Backed up %d wallet(s) to %s", len(f.Wallets), path)}
+		if missing := f.WithoutSeeds(); len(missing) > 0 {
+			lines = append(lines, fmt.Sprintf("Seeds not included for %s: keep their mnemonics to be able to restore them.", strings.Join(missing, ", ")))
+		}
+		return doneMsg{lines: lines}
+	}
+}
+
+func (s *state) restore(path, password string, settings bool) tea.Cmd {
+	walletPassword := s.ctx.Password()
+	return func() tea.Msg {
+		defer secret.Wipe(walletPassword)
+		f, err := backup.ReadFile(expandHome(path), password)
+		if err != nil {
+			return doneMsg{err: err}
+		}
+		report, err := backup.Restore(s.ctx.WalletRepo, f, s.ctx.Config, walletPassword)
+		if err != nil {
+			return doneMsg{err: err}
+		}
+		lines := reportLines(report)
+		if settings && f.Settings != nil {
+			restored, kept := f.RestoredSettings(s.ctx.Config)
+			if err := s.ctx.Config.Save(restored); err != nil {
+				return doneMsg{lines: lines, err: fmt.Errorf("failed to save settings: %w", err)}
+			}
+			lines = append(lines, "Settings restored: restart satellion to apply them")
+			if len(kept) > 0 {
+				lines = append(lines, fmt.Sprintf("Kept the local %s: edit config.json to take the ones of the backup", strings.Join(kept, ", ")))
+			}
+		}
+		return doneMsg{lines: lines}
+	}
+}
+
+func reportLines(r *backup.Report) []string {
+	var lines []string
+	for _, name := range r.Added {
+		lines = append(lines, "Added "+name)
+	}
+	for _, name := range r.Merged {
+		lines = append(lines, "Updated address indexes of "+name)
+	}
+	for _, from := range sortedKeys(r.Renamed) {
+		lines = append(lines, fmt.Sprintf("Restored %s as %s: a different wallet already uses the name", from, r.Renamed[from]))
+	}
+	for _, name := range sortedKeys(r.Skipped) {
+		lines = append(lines, fmt.Sprintf("Skipped %s: %s", name, r.Skipped[name]))
+	}
+	if len(lines) == 0 {
+		lines = append(lines, "All wallets in the backup are already up to date")
+	}
+	return lines
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func (s *state) View() string {
+	v := framework.View().L("Backup")
+	switch s.step {
+	case stepMenu:
+		v.L(s.selector.Render())
+	case stepPassword:
+		v.L("Choose a password to encrypt the backup:").L(s.password.View())
+	case stepConfirm:
+		v.L("Repeat the password:").L(s.confirm.View())
+	case stepPath:
+		v.L("Path of the backup file:").L(s.path.View())
+	case stepRestorePassword:
+		v.L("Backup file: %s", s.path.Value()).L("Password:").L(s.password.View())
+	case stepWorking:
+		v.L("Working...")
+	case stepDone:
+		for _, line := range s.result {
+			v.L(line)
+		}
+		v.Help("Enter to go home")
+	}
+	if s.step == stepMenu {
+		v.Warn("Anyone with a backup that includes seeds and its password can spend your funds.")
+		v.L("Restored wallets are encrypted with the password of the current wallet.")
+	}
+	if s.err != "" {
+		v.Err(s.err)
+	}
+	return v.QuitHint().Build()
+}
+
+func passwordInput(placeholder string) textinput.Model {
+	i := textinput.New()
+	i.Placeholder = placeholder
+	i.EchoMode = textinput.EchoPassword
+	i.EchoCharacter = '•'
+	i.CharLimit = 128
+	i.Width = 24
+	return i
+}
+
+func pathInput() textinput.Model {
+	i := textinput.New()
+	i.Placeholder = "~/.satellion/backups/satellion-...satbak"
+	i.CharLimit = 512
+	i.Width = 60
+	return i
+}
+
+// expandHome resolves a leading ~/ to the user's home directory.
+func expandHome(path string) string {
+	if !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, path[2:])
+}
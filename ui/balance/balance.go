@@ -1,10 +1,13 @@
 package balance
 
 import (
+	"time"
+
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/fatih/color"
 	"github.com/satelliondao/satellion/neutrino"
 	"github.com/satelliondao/satellion/ui/framework"
+	"github.com/satelliondao/satellion/walletdb"
 )
 
 type Status int
@@ -53,7 +56,18 @@ func (s *State) StartScan() tea.Cmd {
 	s.err = nil
 	s.info = nil
 	s.progress = 0
-	return s.scanBalance()
+	// The addresses are derived here rather than in the command, which runs outside the
+	// update loop and would race with an auto-lock wiping the wallet keys. The command
+	// only gets the public address space.
+	w, err := s.ctx.ActiveWallet()
+	if err != nil {
+		return func() tea.Msg { return balanceCompleteMsg{err: err} }
+	}
+	space, err := neutrino.NewBalance(s.ctx.ChainService).AddressSpaceOf(w)
+	if err != nil {
+		return func() tea.Msg { return balanceCompleteMsg{err: err} }
+	}
+	return s.scanBalance(w.Name, space)
 }
 
 func (s *State) Update(msg tea.Msg) tea.Cmd {
@@ -83,10 +97,11 @@ func (s *State) View() string {
 	case BalanceScanning:
 		v.L("Scanning... %.1f%%", s.progress)
 	case BalanceError:
-		v.L(color.New(color.FgRed).Sprintf("Error: %v", s.err))
+		v.L(color.New(color.FgRed).Sprintf("Error: %s", framework.ErrorText(s.err)))
 	case BalanceComplete:
 		if s.info != nil {
 			v.L("%d sats, %d UTXOs", s.info.Balance, s.info.UtxoCount)
+			s.mempoolView(v)
 		} else {
 			v.Err("No balance information available")
 		}
@@ -96,17 +111,49 @@ func (s *State) View() string {
 	return v.Build()
 }
 
-func (s *State) scanBalance() tea.Cmd {
-	return func() tea.Msg {
-		wallet, err := s.ctx.WalletRepo.GetActiveWallet(s.ctx.Passphrase)
-		if err != nil {
-			return balanceCompleteMsg{err: err}
+// mempoolView shows the unconfirmed amounts relayed by peers apart from the balance, and
+// the unconfirmed transactions that will not confirm as they are.
+func (s *State) mempoolView(v *framework.ViewBuilder) {
+	pool := s.ctx.ChainService.Mempool()
+	if in, out := pool.Pending(); in > 0 || out > 0 {
+		v.L(color.New(color.FgYellow).Sprintf("Pending: +%d sats incoming, -%d sats outgoing", int64(in), int64(out)))
+	}
+	for _, e := range pool.Entries() {
+		switch e.Status {
+		case neutrino.MempoolEvicted:
+			v.Warn("%s (%+d sats) was evicted from the mempools of the peers", e.Txid, int64(e.Net()))
+		case neutrino.MempoolDoubleSpent:
+			by := "a mined transaction"
+			if e.ConflictsWith != "" {
+				by = e.ConflictsWith
+			}
+			v.Warn("%s (%+d sats) was double spent by %s", e.Txid, int64(e.Net()), by)
 		}
+	}
+}
 
-		info, err := neutrino.NewBalance(s.ctx.ChainService).ScanLedger(wallet)
+// scanBalance scans the address space of the wallet called name and keeps its unspent
+// outputs for the coins page and the coin selector. The transactions sent by the wallet
+// are updated with what the scan saw.
+func (s *State) scanBalance(name string, space *neutrino.AddressSpace) tea.Cmd {
+	return func() tea.Msg {
+		info, err := neutrino.NewBalance(s.ctx.ChainService).ScanAddressSpace(space)
 		if err != nil {
 			return balanceCompleteMsg{err: err}
 		}
+		err = s.ctx.WalletRepo.SaveUtxos(name, walletdb.UtxoSet{
+			Height:    info.Height,
+			ScannedAt: time.Now(),
+			Utxos:     info.Utxos,
+		})
+		if err == nil {
+			err = s.ctx.WalletRepo.ReconcileTransactions(name, info.Confirmed, info.Utxos)
+		}
+		if err == nil {
+			pool := s.ctx.ChainService.Mempool()
+			pool.Reconcile(info.Confirmed, info.Utxos, time.Now())
+			err = pool.Watch(name, info.Addresses, info.Utxos)
+		}
 		return balanceCompleteMsg{info: info, err: err}
 	}
 }
@@ -0,0 +1,202 @@
+package coins
+
+import (
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+	"github.com/satelliondao/satellion/bip21"
+	"github.com/satelliondao/satellion/bip329"
+	"github.com/satelliondao/satellion/ui/framework"
+	"github.com/satelliondao/satellion/ui/router"
+	"github.com/satelliondao/satellion/wallet"
+)
+
+// state lists the unspent outputs found by the last scan. Coins can be frozen so the
+// coin selector never spends them, and picked to fund the next payment.
+type state struct {
+	ctx     *framework.AppContext
+	wname   string
+	utxos   []wallet.Utxo
+	labels  map[string]string
+	frozen  map[string]bool
+	picked  map[wire.OutPoint]bool
+	tip     int32
+	scanned bool
+	cursor  int
+	err     string
+}
+
+func New(ctx *framework.AppContext, params interface{}) framework.Page {
+	return &state{ctx: ctx, picked: make(map[wire.OutPoint]bool)}
+}
+
+func (m *state) Init() tea.Cmd {
+	m.reload()
+	return nil
+}
+
+func (m *state) reload() {
+	name, err := m.ctx.WalletRepo.GetActiveWalletName()
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.wname = name
+	set, err := m.ctx.WalletRepo.Utxos(name)
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.utxos, m.tip, m.scanned = set.Utxos, set.Height, !set.ScannedAt.IsZero()
+	// The scan height is only a floor: the chain may have grown since.
+	if block, err := m.ctx.ChainService.BestBlock(); err == nil && block.Height > m.tip {
+		m.tip = block.Height
+	}
+	labels, err := m.ctx.WalletRepo.Labels(name)
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.labels = make(map[string]string)
+	for _, l := range labels {
+		if l.Label == "" {
+			continue
+		}
+		switch l.Type {
+		case bip329.Output, bip329.Addr:
+			m.labels[string(l.Type)+":"+l.Ref] = l.Label
+		}
+	}
+	if m.frozen, err = m.ctx.WalletRepo.Frozen(name); err != nil {
+		m.err = err.Error()
+	}
+	if m.cursor >= len(m.utxos) {
+		m.cursor = max(len(m.utxos)-1, 0)
+	}
+}
+
+// label returns the label of u, or of the address it pays when u has none.
+func (m *state) label(u wallet.Utxo) string {
+	if l, ok := m.labels["output:"+u.Ref()]; ok {
+		return l
+	}
+	return m.labels["addr:"+u.Address]
+}
+
+func (m *state) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	nav := framework.HandleNav(msg, router.Home())
+	if nav != nil {
+		return m, nav
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	m.err = ""
+	switch key.String() {
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.utxos)-1 {
+			m.cursor++
+		}
+	case "f":
+		m.toggleFrozen()
+	case " ":
+		m.togglePicked()
+	case "enter", "s":
+		return m, m.send()
+	}
+	return m, nil
+}
+
+func (m *state) toggleFrozen() {
+	if len(m.utxos) == 0 {
+		return
+	}
+	u := m.utxos[m.cursor]
+	if err := m.ctx.WalletRepo.SetFrozen(m.wname, u.Ref(), !m.frozen[u.Ref()]); err != nil {
+		m.err = err.Error()
+		return
+	}
+	delete(m.picked, u.OutPoint)
+	m.reload()
+}
+
+func (m *state) togglePicked() {
+	if len(m.utxos) == 0 {
+		return
+	}
+	u := m.utxos[m.cursor]
+	if m.frozen[u.Ref()] {
+		m.err = "Frozen coins cannot be spent. Press F to unfreeze it first."
+		return
+	}
+	if m.picked[u.OutPoint] {
+		delete(m.picked, u.OutPoint)
+	} else {
+		m.picked[u.OutPoint] = true
+	}
+}
+
+// send opens the send page funded by the picked coins, in the order they are listed.
+func (m *state) send() tea.Cmd {
+	if len(m.picked) == 0 {
+		return router.Send()
+	}
+	var inputs []wire.OutPoint
+	for _, u := range m.utxos {
+		if m.picked[u.OutPoint] {
+			inputs = append(inputs, u.OutPoint)
+		}
+	}
+	return router.SendFrom(inputs)
+}
+
+func (m *state) View() string {
+	v := framework.View()
+	v.L("Coins").L("")
+	if !m.scanned {
+		v.L("No coins yet. Synchronize the blockchain to scan the wallet.")
+	} else if len(m.utxos) == 0 {
+		v.L("The wallet has no unspent coins.")
+	}
+	var total, selected btcutil.Amount
+	for i, u := range m.utxos {
+		cursor := " "
+		if m.cursor == i {
+			cursor = color.New(color.FgHiCyan).Sprint(">")
+		}
+		mark := "[ ]"
+		switch {
+		case m.frozen[u.Ref()]:
+			mark = color.New(color.FgHiBlue).Sprint("[F]")
+		case m.picked[u.OutPoint]:
+			mark = color.New(color.FgGreen).Sprint("[x]")
+			selected += u.Value
+		}
+		total += u.Value
+		v.L("%s %s %s BTC  %d conf  %s", cursor, mark,
+			color.New(color.Bold).Sprintf("%12s", bip21.FormatAmount(u.Value)), u.Confirmations(m.tip), u.Address)
+		details := fmt.Sprintf("%s  %s", u.Ref(), u.Path())
+		if l := m.label(u); l != "" {
+			details += "  " + l
+		}
+		v.L("        %s", color.New(color.FgHiBlack).Sprint(details))
+	}
+	if len(m.utxos) > 0 {
+		v.L("").L("Total: %s BTC in %d coins", bip21.FormatAmount(total), len(m.utxos))
+	}
+	if len(m.picked) > 0 {
+		v.L("Picked: %s BTC in %d coins", bip21.FormatAmount(selected), len(m.picked))
+	}
+	return v.Err(m.err).
+		Help("SPACE to pick coins for the next payment, F to freeze or unfreeze, ENTER to send.\nFrozen coins are never spent.").
+		QuitHint().
+		Build()
+}
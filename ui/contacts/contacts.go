@@ -0,0 +1,240 @@
+package contacts
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+	"github.com/satelliondao/satellion/contacts"
+	"github.com/satelliondao/satellion/ui/framework"
+	"github.com/satelliondao/satellion/ui/router"
+)
+
+const (
+	fieldName = iota
+	fieldRecipient
+	fieldNotes
+	fieldCount
+)
+
+// state lists the address book and edits one contact at a time.
+type state struct {
+	ctx      *framework.AppContext
+	list     []contacts.Contact
+	cursor   int
+	editing  bool
+	original *contacts.Contact
+	inputs   []textinput.Model
+	focus    int
+	confirm  bool
+	err      string
+}
+
+func New(ctx *framework.AppContext, params interface{}) framework.Page {
+	s := &state{ctx: ctx, inputs: make([]textinput.Model, fieldCount)}
+	for i, placeholder := range []string{"Name", "Address or tr(...) descriptor", "Notes (optional)"} {
+		in := textinput.New()
+		in.Placeholder = placeholder
+		in.CharLimit = 128
+		in.Width = 64
+		s.inputs[i] = in
+	}
+	s.inputs[fieldRecipient].CharLimit = 512
+	return s
+}
+
+func (m *state) Init() tea.Cmd {
+	m.reload()
+	return nil
+}
+
+func (m *state) reload() {
+	list, err := m.ctx.WalletRepo.Contacts()
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.list = list
+	if m.cursor >= len(list) {
+		m.cursor = max(len(list)-1, 0)
+	}
+}
+
+func (m *state) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.editing {
+		return m, m.updateForm(msg)
+	}
+	nav := framework.HandleNav(msg, router.Home())
+	if nav != nil {
+		return m, nav
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	if m.confirm {
+		m.confirm = false
+		if key.String() == "d" && len(m.list) > 0 {
+			if err := m.ctx.WalletRepo.DeleteContact(m.list[m.cursor].Name); err != nil {
+				m.err = err.Error()
+			}
+			m.reload()
+		}
+		return m, nil
+	}
+	m.err = ""
+	switch key.String() {
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.list)-1 {
+			m.cursor++
+		}
+	case "a":
+		return m, m.edit(nil)
+	case "enter", "e":
+		if len(m.list) > 0 {
+			return m, m.edit(&m.list[m.cursor])
+		}
+	case "d":
+		m.confirm = len(m.list) > 0
+	}
+	return m, nil
+}
+
+// edit opens the form on c, or on a new contact when c is nil.
+func (m *state) edit(c *contacts.Contact) tea.Cmd {
+	m.editing = true
+	m.original = c
+	for i := range m.inputs {
+		m.inputs[i].Reset()
+	}
+	if c != nil {
+		m.inputs[fieldName].SetValue(c.Name)
+		m.inputs[fieldRecipient].SetValue(c.Address + c.Descriptor)
+		m.inputs[fieldNotes].SetValue(c.Notes)
+	}
+	return m.setFocus(fieldName)
+}
+
+func (m *state) setFocus(field int) tea.Cmd {
+	m.inputs[m.focus].Blur()
+	m.focus = field
+	return m.inputs[m.focus].Focus()
+}
+
+func (m *state) updateForm(msg tea.Msg) tea.Cmd {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.Type {
+		case tea.KeyCtrlC:
+			return tea.Quit
+		case tea.KeyEsc:
+			m.editing = false
+			m.err = ""
+			return nil
+		case tea.KeyTab, tea.KeyDown:
+			return m.setFocus((m.focus + 1) % fieldCount)
+		case tea.KeyShiftTab, tea.KeyUp:
+			return m.setFocus((m.focus + fieldCount - 1) % fieldCount)
+		case tea.KeyEnter:
+			if m.focus < fieldCount-1 {
+				return m.setFocus(m.focus + 1)
+			}
+			m.save()
+			return nil
+		}
+	}
+	var cmd tea.Cmd
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+	return cmd
+}
+
+func (m *state) save() {
+	c := contacts.Contact{
+		Name:  strings.TrimSpace(m.inputs[fieldName].Value()),
+		Notes: strings.TrimSpace(m.inputs[fieldNotes].Value()),
+	}
+	recipient := strings.TrimSpace(m.inputs[fieldRecipient].Value())
+	if strings.HasPrefix(recipient, "tr(") {
+		c.Descriptor = recipient
+	} else {
+		c.Address = recipient
+	}
+	if m.original != nil {
+		c.CreatedAt = m.original.CreatedAt
+		if c.Descriptor == m.original.Descriptor {
+			c.NextIndex = m.original.NextIndex
+		}
+	}
+	params, err := m.ctx.Config.ChainParams()
+	if err == nil {
+		err = c.Validate(params)
+	}
+	if err == nil && (m.original == nil || m.original.Name != c.Name) {
+		for _, other := range m.list {
+			if other.Name == c.Name {
+				m.err = "A contact with this name already exists."
+				return
+			}
+		}
+	}
+	if err == nil {
+		err = m.ctx.WalletRepo.SaveContact(c)
+	}
+	if err == nil && m.original != nil && m.original.Name != c.Name {
+		err = m.ctx.WalletRepo.DeleteContact(m.original.Name)
+	}
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.editing = false
+	m.err = ""
+	m.reload()
+}
+
+func (m *state) View() string {
+	v := framework.View()
+	if m.editing {
+		title := "New contact"
+		if m.original != nil {
+			title = "Edit " + m.original.Name
+		}
+		v.L(title).L("")
+		for _, in := range m.inputs {
+			v.L(in.View())
+		}
+		return v.Err(m.err).
+			Help("A descriptor gives a fresh address for every payment. TAB to move between fields,\nENTER to save, ESC to cancel.").
+			Build()
+	}
+	v.L("Contacts").L("")
+	if len(m.list) == 0 {
+		v.L("No contacts yet.")
+	}
+	for i, c := range m.list {
+		cursor := " "
+		if m.cursor == i {
+			cursor = color.New(color.FgHiCyan).Sprint(">")
+		}
+		to := c.Address
+		if c.Descriptor != "" {
+			to = fmt.Sprintf("descriptor, next address #%d", c.NextIndex)
+		}
+		v.L("%s %s  %s", cursor, color.New(color.Bold).Sprint(c.Name), to)
+		if c.Notes != "" {
+			v.L("    %s", color.New(color.FgHiBlack).Sprint(c.Notes))
+		}
+	}
+	if m.confirm {
+		v.Warn("\nPress D again to delete %s.", m.list[m.cursor].Name)
+	}
+	return v.Err(m.err).
+		Help("A to add, ENTER to edit, D to delete.").
+		QuitHint().
+		Build()
+}
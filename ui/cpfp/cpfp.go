@@ -0,0 +1,258 @@
+package cpfp
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+	"github.com/satelliondao/satellion/bip21"
+	"github.com/satelliondao/satellion/spend"
+	"github.com/satelliondao/satellion/ui/framework"
+	"github.com/satelliondao/satellion/ui/router"
+)
+
+const (
+	fieldParent = iota
+	fieldParentFee
+	fieldFeeRate
+	fieldCount
+)
+
+// state speeds up an unconfirmed payment to the wallet with a child spending its output
+// back to the wallet, paying enough fee for miners to take both: child pays for parent.
+// The wallet only learns about payments once they confirm, so the parent is pasted as
+// raw hex with its fee, which the sender or a block explorer can tell.
+type state struct {
+	ctx     *framework.AppContext
+	inputs  []textinput.Model
+	focus   int
+	parent  *wire.MsgTx
+	fee     btcutil.Amount
+	rate    btcutil.Amount
+	picker  *framework.ChoiceSelector
+	signed  *spend.Transaction
+	sending bool
+	sent    string
+	err     string
+}
+
+type sentMsg struct {
+	txid string
+	err  error
+}
+
+func New(ctx *framework.AppContext, params interface{}) framework.Page {
+	s := &state{ctx: ctx, inputs: make([]textinput.Model, fieldCount)}
+	for i, placeholder := range []string{"Raw parent transaction in hex", "Fee paid by the parent in sats", "Target fee rate of both in sat/vB"} {
+		in := textinput.New()
+		in.Placeholder = placeholder
+		in.CharLimit = 20
+		in.Width = 64
+		s.inputs[i] = in
+	}
+	s.inputs[fieldParent].CharLimit = 200_000
+	s.inputs[fieldParent].Focus()
+	return s
+}
+
+func (m *state) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m *state) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.picker != nil {
+		return m, m.updatePicker(msg)
+	}
+	if sent, ok := msg.(sentMsg); ok {
+		m.sending = false
+		if sent.err != nil {
+			m.err = sent.err.Error()
+			return m, nil
+		}
+		m.sent, m.signed = sent.txid, nil
+		return m, nil
+	}
+	if m.sending || m.sent != "" {
+		return m, framework.HandleNav(msg, router.Home())
+	}
+	nav := framework.HandleNav(msg, router.Home())
+	if nav != nil {
+		return m, nav
+	}
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.Type {
+		case tea.KeyEnter:
+			return m, m.handleEnter()
+		case tea.KeyTab, tea.KeyDown:
+			return m, m.setFocus((m.focus + 1) % fieldCount)
+		case tea.KeyShiftTab, tea.KeyUp:
+			return m, m.setFocus((m.focus + fieldCount - 1) % fieldCount)
+		}
+	}
+	var cmd tea.Cmd
+	before := m.inputs[m.focus].Value()
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+	if m.inputs[m.focus].Value() != before {
+		m.signed, m.err = nil, ""
+	}
+	return m, cmd
+}
+
+func (m *state) setFocus(field int) tea.Cmd {
+	m.inputs[m.focus].Blur()
+	m.focus = field
+	return m.inputs[m.focus].Focus()
+}
+
+func (m *state) handleEnter() tea.Cmd {
+	if m.focus < fieldCount-1 {
+		return m.setFocus(m.focus + 1)
+	}
+	if m.signed != nil {
+		return m.broadcast()
+	}
+	m.err = ""
+	if err := m.validate(); err != nil {
+		m.err = err.Error()
+		return nil
+	}
+	w, err := m.ctx.ActiveWallet()
+	if err != nil {
+		m.err = err.Error()
+		return nil
+	}
+	incoming, err := spend.Incoming(w, m.parent)
+	if err != nil {
+		m.err = err.Error()
+		return nil
+	}
+	switch len(incoming) {
+	case 0:
+		m.err = "The transaction does not pay this wallet."
+	case 1:
+		m.sign(incoming[0].OutPoint.Index)
+	default:
+		choices := make([]framework.Choice, len(incoming))
+		for i, out := range incoming {
+			choices[i] = framework.Choice{
+				Label: fmt.Sprintf("%s BTC to %s", bip21.FormatAmount(out.Value), out.Address),
+				Value: out.OutPoint.Index,
+			}
+		}
+		m.picker = framework.NewChoiceSelector(choices)
+	}
+	return nil
+}
+
+func (m *state) updatePicker(msg tea.Msg) tea.Cmd {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.Type {
+		case tea.KeyCtrlC:
+			return tea.Quit
+		case tea.KeyEsc:
+			m.picker = nil
+			return nil
+		}
+	}
+	res := m.picker.Update(msg)
+	if res.Action != framework.ActionSelection {
+		return nil
+	}
+	m.picker = nil
+	m.sign(res.Selected.Value.(uint32))
+	return nil
+}
+
+// sign signs the child spending the output vout of the parent for review.
+func (m *state) sign(vout uint32) {
+	w, err := m.ctx.ActiveWallet()
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.signed, err = m.ctx.Payments.ChildPaysForParent(w, m.parent, m.fee, vout, m.rate)
+	if err != nil {
+		m.err = err.Error()
+	}
+}
+
+// validate decodes the parent, its fee and the target fee rate.
+func (m *state) validate() error {
+	raw, err := hex.DecodeString(strings.TrimSpace(m.inputs[fieldParent].Value()))
+	if err != nil {
+		return fmt.Errorf("the parent must be a raw transaction in hex")
+	}
+	parent := wire.NewMsgTx(wire.TxVersion)
+	if err := parent.Deserialize(bytes.NewReader(raw)); err != nil {
+		return fmt.Errorf("invalid parent transaction: %w", err)
+	}
+	fee, err := strconv.ParseInt(strings.TrimSpace(m.inputs[fieldParentFee].Value()), 10, 64)
+	if err != nil || fee < 0 {
+		return fmt.Errorf("the parent fee must be a whole number of sats")
+	}
+	rate, err := strconv.ParseInt(strings.TrimSpace(m.inputs[fieldFeeRate].Value()), 10, 64)
+	if err != nil || rate < 1 {
+		return fmt.Errorf("fee rate must be a whole number of sat/vB, at least 1")
+	}
+	m.parent, m.fee, m.rate = parent, btcutil.Amount(fee), btcutil.Amount(rate)
+	return nil
+}
+
+// broadcast sends the parent, in case peers never saw it, then the reviewed child.
+func (m *state) broadcast() tea.Cmd {
+	w, err := m.ctx.ActiveWallet()
+	if err != nil {
+		m.err = err.Error()
+		return nil
+	}
+	m.sending, m.err = true, ""
+	parent, signed := m.parent, m.signed
+	return func() tea.Msg {
+		if err := m.ctx.Payments.BroadcastPackage(w, parent, signed, ""); err != nil {
+			return sentMsg{err: err}
+		}
+		return sentMsg{txid: signed.Txid}
+	}
+}
+
+func (m *state) View() string {
+	v := framework.View()
+	v.L("Speed up an incoming payment").L("")
+	for _, in := range m.inputs {
+		v.L(in.View())
+	}
+	v.L("")
+	if m.picker != nil {
+		v.L("The transaction pays this wallet several times. Spend:").L(m.picker.Render())
+		return v.Help("ENTER to pick, ESC to go back.").Build()
+	}
+	if m.signed != nil {
+		parentSize, childSize := spend.VSize(m.parent), m.signed.VSize()
+		v.L("Parent: %s", m.parent.TxHash())
+		v.L("  %d vB, fee %d sats (%.1f sat/vB)", parentSize, int64(m.fee), float64(m.fee)/float64(parentSize))
+		v.L("Child:  %s", m.signed.Txid)
+		v.L("  %d vB, fee %d sats, %s BTC back to %s", childSize, int64(m.signed.Fee),
+			bip21.FormatAmount(m.signed.Outputs[0].Amount), m.signed.Outputs[0].Address)
+		v.L("Package fee rate: %s", color.New(color.Bold).Sprintf("%.1f sat/vB",
+			spend.PackageFeeRate(parentSize, m.fee, childSize, m.signed.Fee)))
+		if m.sending {
+			v.Warn("Broadcasting...")
+		} else {
+			v.L(color.New(color.FgHiCyan).Sprint("Press ENTER to broadcast."))
+		}
+	}
+	if m.sent != "" {
+		v.L(color.New(color.FgGreen).Sprint("✓ Sent")).L("Child: %s", m.sent)
+	}
+	return v.Err(m.err).
+		Help("Paste the unconfirmed transaction paying this wallet. TAB to move between fields,\nENTER to continue.").
+		QuitHint().
+		Build()
+}
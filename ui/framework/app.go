@@ -1,6 +1,8 @@
 package framework
 
 import (
+	"time"
+
 	tea "github.com/charmbracelet/bubbletea"
 )
 
@@ -36,21 +38,65 @@ func HandleNav(msg tea.Msg, home tea.Cmd) tea.Cmd {
 	return nil
 }
 
+// idleCheckInterval is how often the app checks whether the idle timeout expired.
+const idleCheckInterval = 5 * time.Second
+
+type idleTickMsg time.Time
+
 type App struct {
-	ctx     *AppContext
-	pages   map[string]PageFactory
-	current Page
+	ctx          *AppContext
+	pages        map[string]PageFactory
+	current      Page
+	lockPage     string
+	idleTimeout  time.Duration
+	lastActivity time.Time
 }
 
 func NewApp(ctx *AppContext, pages map[string]PageFactory, start string) *App {
 	cur := pages[start](ctx, interface{}(nil))
-	return &App{ctx: ctx, pages: pages, current: cur}
+	return &App{ctx: ctx, pages: pages, current: cur, lastActivity: time.Now()}
+}
+
+// WithAutoLock locks the wallet and shows lockPage after idle without a key press, or
+// when ctrl+l is pressed. A zero idle disables the timeout but keeps the hotkey.
+func (a *App) WithAutoLock(lockPage string, idle time.Duration) *App {
+	a.lockPage = lockPage
+	a.idleTimeout = idle
+	return a
+}
+
+func (a *App) Init() tea.Cmd {
+	if a.lockPage == "" || a.idleTimeout <= 0 {
+		return a.current.Init()
+	}
+	return tea.Batch(a.current.Init(), idleTick())
+}
+
+func idleTick() tea.Cmd {
+	return tea.Tick(idleCheckInterval, func(t time.Time) tea.Msg { return idleTickMsg(t) })
 }
 
-func (a *App) Init() tea.Cmd { return a.current.Init() }
+// lock wipes the session secrets and replaces the current page with the lock page.
+func (a *App) lock() tea.Cmd {
+	a.ctx.Lock()
+	a.current = a.pages[a.lockPage](a.ctx, nil)
+	return a.current.Init()
+}
 
 func (a *App) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch m := msg.(type) {
+	case idleTickMsg:
+		if a.ctx.Unlocked() && time.Time(m).Sub(a.lastActivity) >= a.idleTimeout {
+			return a, tea.Batch(a.lock(), idleTick())
+		}
+		return a, idleTick()
+	case tea.WindowSizeMsg:
+		a.ctx.Width, a.ctx.Height = m.Width, m.Height
+	case tea.KeyMsg:
+		a.lastActivity = time.Now()
+		if m.Type == tea.KeyCtrlL && a.lockPage != "" && a.ctx.Unlocked() {
+			return a, a.lock()
+		}
 	case NavigateMsg:
 		f, ok := a.pages[m.To]
 		if !ok {
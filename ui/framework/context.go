@@ -2,55 +2,122 @@ package framework
 
 import (
 	"fmt"
-	"os"
-	"path/filepath"
 
 	"github.com/satelliondao/satellion/config"
+	"github.com/satelliondao/satellion/enclave"
 	"github.com/satelliondao/satellion/neutrino"
+	"github.com/satelliondao/satellion/secret"
 	"github.com/satelliondao/satellion/service"
+	"github.com/satelliondao/satellion/wallet"
 	"github.com/satelliondao/satellion/walletdb"
 )
 
 type AppContext struct {
-	Passphrase    string
 	WalletService *service.WalletService
+	Payments      *service.PaymentService
 	ChainService  *neutrino.Chain
 	Config        *config.Config
 	WalletRepo    *walletdb.WalletDB
+	// Width and Height are the last known terminal size, 0 until the terminal reports it.
+	Width    int
+	Height   int
+	unlocked bool
+	password *secret.Bytes
+	wallet   *wallet.Wallet
+	quit     chan struct{}
 }
 
 func NewContext() (*AppContext, error) {
-	home, err := os.UserHomeDir()
+	loaded, err := config.Load()
 	if err != nil {
-		home = "."
+		return nil, fmt.Errorf("failed to load config: %w", err)
 	}
-	path := filepath.Join(home, ".satellion", "wallets.db")
-	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
-		return nil, fmt.Errorf("failed to prepare wallets db dir: %w", err)
+	wallet.LockParams = loaded.LockParams()
+	params, err := loaded.ChainParams()
+	if err != nil {
+		return nil, err
 	}
-	db, err := walletdb.Connect(path)
+	db, err := walletdb.Open(walletdb.DefaultPath())
 	if err != nil {
 		return nil, fmt.Errorf("failed to open wallets db: %w", err)
 	}
-	loaded, err := config.Load()
-	if err != nil {
-		return nil, fmt.Errorf("failed to load config: %w", err)
+	repo := walletdb.New(db, params)
+	if err := repo.UseEnclave(loaded.Enclave, enclave.DefaultDir()); err != nil {
+		db.Close()
+		return nil, err
 	}
-	repo := walletdb.New(db)
 	walletService := service.NewWalletService(repo)
 	chainService, err := neutrino.NewChain(loaded)
 	if err != nil {
+		db.Close()
 		return nil, fmt.Errorf("failed to create chain service: %w", err)
 	}
-	return &AppContext{
+	ctx := &AppContext{
 		WalletService: walletService,
+		Payments:      service.NewPaymentService(repo, chainService, params),
 		ChainService:  chainService,
 		Config:        loaded,
 		WalletRepo:    repo,
-	}, nil
+		quit:          make(chan struct{}),
+	}
+	go ctx.Payments.Queue().Run(ctx.quit)
+	return ctx, nil
+}
+
+// Unlock keeps password for the session once the active wallet accepted it.
+func (ctx *AppContext) Unlock(password string) {
+	ctx.Lock()
+	ctx.password = secret.FromString(password)
+	ctx.unlocked = true
+}
+
+// Password returns a copy of the password of the unlocked wallet, nil when locked. It is
+// a copy so that a command running outside the update loop keeps it across a lock: wipe
+// it once done. The password is never turned into a string, which could not be wiped.
+func (ctx *AppContext) Password() []byte {
+	if ctx.password == nil {
+		return nil
+	}
+	return append([]byte(nil), ctx.password.Bytes()...)
+}
+
+// Unlocked reports whether a wallet was unlocked and not locked since.
+func (ctx *AppContext) Unlocked() bool {
+	return ctx.unlocked
+}
+
+// Lock forgets the password and wipes the key material of the active wallet.
+func (ctx *AppContext) Lock() {
+	if ctx.wallet != nil {
+		ctx.wallet.Wipe()
+		ctx.wallet = nil
+	}
+	ctx.password.Wipe()
+	ctx.password = nil
+	ctx.unlocked = false
+}
+
+// ActiveWallet returns the unlocked active wallet, shared by every page until the next lock.
+func (ctx *AppContext) ActiveWallet() (*wallet.Wallet, error) {
+	if !ctx.unlocked {
+		return nil, fmt.Errorf("wallet is locked")
+	}
+	if ctx.wallet == nil {
+		w, err := ctx.WalletRepo.GetActiveWallet(ctx.password.Bytes())
+		if err != nil {
+			return nil, err
+		}
+		ctx.wallet = w
+	}
+	return ctx.wallet, nil
 }
 
 func (ctx *AppContext) Cleanup() {
+	ctx.Lock()
+	if ctx.quit != nil {
+		close(ctx.quit)
+		ctx.quit = nil
+	}
 	if ctx.ChainService != nil {
 		ctx.ChainService.Stop()
 	}
@@ -1,9 +1,11 @@
 package framework
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/fatih/color"
+	"github.com/satelliondao/satellion/neutrino"
 )
 
 type ViewBuilder struct {
@@ -57,6 +59,17 @@ func (b *ViewBuilder) Err(err string) *ViewBuilder {
 	return b
 }
 
+// ErrorText describes err to the user, with why compact filters are not trusted when a
+// filter header check holds a scan back.
+func ErrorText(err error) string {
+	var untrusted *neutrino.FilterTrustError
+	if errors.As(err, &untrusted) {
+		return fmt.Sprintf("Compact filters are not trusted yet: %s. Peers are asked again shortly, "+
+			"the sync page shows what each one served.", untrusted.Reason)
+	}
+	return err.Error()
+}
+
 func (b *ViewBuilder) Build() string {
 	v := b.v
 	if b.errText != "" {
@@ -0,0 +1,416 @@
+package history
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+	"github.com/satelliondao/satellion/bip21"
+	"github.com/satelliondao/satellion/bip329"
+	"github.com/satelliondao/satellion/service"
+	"github.com/satelliondao/satellion/spend"
+	"github.com/satelliondao/satellion/ui/framework"
+	"github.com/satelliondao/satellion/ui/router"
+	"github.com/satelliondao/satellion/walletdb"
+)
+
+type action int
+
+const (
+	actionNone action = iota
+	actionBump
+	actionCancel
+	actionRaw
+)
+
+// state lists the transactions sent by the wallet with their rebroadcast status, then
+// the raw transactions given by the user. Pending ones can be replaced by a transaction
+// paying a higher fee, or cancelled by a double spend back to the wallet.
+type state struct {
+	ctx    *framework.AppContext
+	wname  string
+	list   []spend.Transaction
+	labels map[string]string
+	// queued maps the txids of the rebroadcast queue to their status, raw lists those
+	// given by the user.
+	queued  map[string]walletdb.QueuedTx
+	raw     []walletdb.QueuedTx
+	cursor  int
+	action  action
+	rate    textinput.Model
+	hex     textinput.Model
+	signed  *spend.Transaction
+	sending bool
+	err     string
+}
+
+type broadcastMsg struct{ err error }
+
+type tickMsg time.Time
+
+func New(ctx *framework.AppContext, params interface{}) framework.Page {
+	rate := textinput.New()
+	rate.CharLimit = 6
+	rate.Width = 40
+	hex := textinput.New()
+	hex.Placeholder = "Signed transaction in hex"
+	hex.Width = 60
+	return &state{ctx: ctx, rate: rate, hex: hex}
+}
+
+func (m *state) Init() tea.Cmd {
+	m.reload()
+	return m.tick()
+}
+
+// tick refreshes the rebroadcast status as the queue is processed in the background.
+func (m *state) tick() tea.Cmd {
+	return tea.Tick(5*time.Second, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+func (m *state) reload() {
+	name, err := m.ctx.WalletRepo.GetActiveWalletName()
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.wname = name
+	if m.list, err = m.ctx.WalletRepo.Transactions(name); err != nil {
+		m.err = err.Error()
+		return
+	}
+	labels, err := m.ctx.WalletRepo.Labels(name)
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.labels = make(map[string]string)
+	for _, l := range labels {
+		if l.Type == bip329.Tx {
+			m.labels[l.Ref] = l.Label
+		}
+	}
+	m.reloadQueue()
+}
+
+func (m *state) reloadQueue() {
+	list, err := m.ctx.Payments.Queue().List()
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.queued = make(map[string]walletdb.QueuedTx, len(list))
+	m.raw = nil
+	for _, q := range list {
+		m.queued[q.Txid] = q
+		if q.Wallet == "" {
+			m.raw = append(m.raw, q)
+		}
+	}
+	if m.cursor >= len(m.list)+len(m.raw) {
+		m.cursor = max(len(m.list)+len(m.raw)-1, 0)
+	}
+}
+
+func (m *state) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if _, ok := msg.(tickMsg); ok {
+		m.reloadQueue()
+		return m, m.tick()
+	}
+	if done, ok := msg.(broadcastMsg); ok {
+		m.sending = false
+		if done.err != nil {
+			m.err = done.err.Error()
+			return m, nil
+		}
+		m.close()
+		m.reload()
+		return m, nil
+	}
+	if m.action != actionNone {
+		return m, m.updateReplace(msg)
+	}
+	nav := framework.HandleNav(msg, router.Home())
+	if nav != nil {
+		return m, nav
+	}
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	m.err = ""
+	switch key.String() {
+	case "up", "k":
+		if m.cursor > 0 {
+			m.cursor--
+		}
+	case "down", "j":
+		if m.cursor < len(m.list)+len(m.raw)-1 {
+			m.cursor++
+		}
+	case "b":
+		return m, m.open(actionBump)
+	case "c":
+		return m, m.open(actionCancel)
+	case "r":
+		m.action = actionRaw
+		m.hex.Reset()
+		return m, m.hex.Focus()
+	case "x":
+		m.remove()
+	}
+	return m, nil
+}
+
+// remove stops broadcasting the selected raw transaction.
+func (m *state) remove() {
+	i := m.cursor - len(m.list)
+	if i < 0 || i >= len(m.raw) {
+		m.err = "Only raw transactions can be removed from the queue."
+		return
+	}
+	if err := m.ctx.Payments.Queue().Remove(m.raw[i].Txid); err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.reloadQueue()
+}
+
+// open asks for the fee rate of a replacement of the selected transaction.
+func (m *state) open(a action) tea.Cmd {
+	if m.cursor >= len(m.list) {
+		return nil
+	}
+	t := m.list[m.cursor]
+	if t.Status != spend.Pending {
+		m.err = fmt.Sprintf("Only pending transactions can be replaced, this one is %s.", t.Status)
+		return nil
+	}
+	m.action = a
+	m.rate.Reset()
+	m.rate.Placeholder = fmt.Sprintf("New fee rate in sat/vB, above %.1f", t.FeeRate())
+	return m.rate.Focus()
+}
+
+func (m *state) close() {
+	m.action, m.signed = actionNone, nil
+	m.rate.Blur()
+	m.hex.Blur()
+}
+
+func (m *state) updateReplace(msg tea.Msg) tea.Cmd {
+	if m.sending {
+		return nil
+	}
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.Type {
+		case tea.KeyCtrlC:
+			return tea.Quit
+		case tea.KeyEsc:
+			m.close()
+			m.err = ""
+			return nil
+		case tea.KeyEnter:
+			if m.action == actionRaw {
+				return m.broadcastRaw()
+			}
+			if m.signed != nil {
+				return m.broadcast()
+			}
+			m.sign()
+			return nil
+		}
+	}
+	if m.action == actionRaw {
+		var cmd tea.Cmd
+		m.hex, cmd = m.hex.Update(msg)
+		return cmd
+	}
+	before := m.rate.Value()
+	var cmd tea.Cmd
+	m.rate, cmd = m.rate.Update(msg)
+	if m.rate.Value() != before {
+		m.signed, m.err = nil, ""
+	}
+	return cmd
+}
+
+// sign signs the replacement for review.
+func (m *state) sign() {
+	m.err = ""
+	rate, err := strconv.ParseInt(strings.TrimSpace(m.rate.Value()), 10, 64)
+	if err != nil || rate < 1 {
+		m.err = "Fee rate must be a whole number of sat/vB."
+		return
+	}
+	w, err := m.ctx.ActiveWallet()
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	txid := m.list[m.cursor].Txid
+	if m.action == actionBump {
+		m.signed, err = m.ctx.Payments.Bump(w, txid, btcutil.Amount(rate))
+	} else {
+		m.signed, err = m.ctx.Payments.Cancel(w, txid, btcutil.Amount(rate))
+	}
+	if err != nil {
+		m.err = err.Error()
+	}
+}
+
+func (m *state) broadcast() tea.Cmd {
+	w, err := m.ctx.ActiveWallet()
+	if err != nil {
+		m.err = err.Error()
+		return nil
+	}
+	m.sending = true
+	signed := m.signed
+	return func() tea.Msg {
+		return broadcastMsg{err: m.ctx.Payments.Broadcast(w, signed, "")}
+	}
+}
+
+// broadcastRaw decodes the transaction given by the user and queues it for broadcast.
+func (m *state) broadcastRaw() tea.Cmd {
+	tx, err := service.DecodeTx(strings.TrimSpace(m.hex.Value()))
+	if err != nil {
+		m.err = "Not a transaction in hex: " + err.Error()
+		return nil
+	}
+	m.err = ""
+	m.sending = true
+	return func() tea.Msg {
+		return broadcastMsg{err: m.ctx.Payments.Queue().Send("", tx)}
+	}
+}
+
+func statusColor(s spend.Status) *color.Color {
+	switch s {
+	case spend.Confirmed:
+		return color.New(color.FgGreen)
+	case spend.Replaced:
+		return color.New(color.FgHiBlack)
+	}
+	return color.New(color.FgYellow)
+}
+
+func (m *state) View() string {
+	v := framework.View()
+	v.L("History").L("")
+	if len(m.list) == 0 {
+		v.L("No transactions sent yet.")
+	}
+	for i, t := range m.list {
+		cursor := " "
+		if m.cursor == i {
+			cursor = color.New(color.FgHiCyan).Sprint(">")
+		}
+		what := "Sent " + bip21.FormatAmount(t.Sent()) + " BTC"
+		if t.Cancel {
+			what = "Cancelled " + t.Replaces[:8]
+		} else if t.Parent != "" {
+			what = "Paid the fee of incoming " + t.Parent[:8]
+		} else if r := t.Recipients(); len(r) > 0 {
+			what += " to " + r[0].Address
+			if len(r) > 1 {
+				what += fmt.Sprintf(" and %d more", len(r)-1)
+			}
+		}
+		status := string(t.Status)
+		if t.Status == spend.Confirmed {
+			status += fmt.Sprintf(" in %d", t.Height)
+		}
+		v.L("%s %s  %s  %s", cursor, t.CreatedAt.Local().Format("2006-01-02 15:04"),
+			statusColor(t.Status).Sprintf("%-9s", status), what)
+		details := fmt.Sprintf("%s  fee %d sats (%.1f sat/vB)", t.Txid, int64(t.Fee), t.FeeRate())
+		if t.ReplacedBy != "" {
+			details += "  replaced by " + t.ReplacedBy[:8]
+		}
+		if l := m.labels[t.Txid]; l != "" {
+			details += "  " + l
+		}
+		v.L("    %s", color.New(color.FgHiBlack).Sprint(details))
+		if q, ok := m.queued[t.Txid]; ok && t.Status == spend.Pending {
+			v.L("    %s", queueStatus(q))
+		}
+	}
+	if len(m.raw) > 0 {
+		v.L("").L("Raw transactions")
+	}
+	for i, q := range m.raw {
+		cursor := " "
+		if m.cursor == len(m.list)+i {
+			cursor = color.New(color.FgHiCyan).Sprint(">")
+		}
+		v.L("%s %s  %s", cursor, q.CreatedAt.Local().Format("2006-01-02 15:04"), q.Txid)
+		v.L("    %s", queueStatus(q))
+	}
+	if m.action == actionRaw {
+		v.L("").L("Broadcast a raw transaction until it is seen in a block")
+		v.L(m.hex.View())
+		if m.sending {
+			v.Warn("Broadcasting...")
+		}
+		return v.Err(m.err).Help("ENTER to broadcast, ESC to go back.").Build()
+	}
+	if m.action != actionNone {
+		m.replaceView(v)
+		return v.Err(m.err).Build()
+	}
+	return v.Err(m.err).
+		Help("B to bump the fee of a pending transaction, C to cancel it, R to broadcast a raw transaction, X to stop broadcasting one.").
+		QuitHint().
+		Build()
+}
+
+// queueStatus describes the rebroadcasts of q.
+func queueStatus(q walletdb.QueuedTx) string {
+	switch {
+	case q.Height != 0:
+		return color.GreenString("seen in block %d", q.Height)
+	case q.Stopped != "":
+		return color.New(color.FgHiBlack).Sprint("no longer broadcast, " + q.Stopped)
+	case q.Attempts == 0:
+		return color.YellowString("waiting for peers to broadcast")
+	}
+	times := "once"
+	if q.Attempts > 1 {
+		times = fmt.Sprintf("%d times", q.Attempts)
+	}
+	status := fmt.Sprintf("broadcast %s, last at %s to %d peers, next around %s", times,
+		q.LastAttempt.Local().Format("15:04"), q.Peers, q.NextAttempt.Local().Format("15:04"))
+	if q.LastError != "" {
+		return color.YellowString("%s, last error: %s", status, q.LastError)
+	}
+	return color.New(color.FgHiBlack).Sprint(status)
+}
+
+func (m *state) replaceView(v *framework.ViewBuilder) {
+	t := m.list[m.cursor]
+	v.L("")
+	if m.action == actionBump {
+		v.L("Bump the fee of %s", t.Txid)
+	} else {
+		v.L("Cancel %s by paying its coins back to the wallet", t.Txid)
+	}
+	v.L(m.rate.View())
+	if m.signed != nil {
+		v.L("New fee: %d sats (%.1f sat/vB), %d inputs", int64(m.signed.Fee), m.signed.FeeRate(), len(m.signed.Inputs))
+		if m.sending {
+			v.Warn("Broadcasting...")
+		} else {
+			v.L(color.New(color.FgHiCyan).Sprint("Press ENTER to broadcast the replacement."))
+		}
+	}
+	if m.action == actionCancel {
+		v.Warn("A cancellation only works if it confirms before the original payment.")
+	}
+	v.Help("ENTER to continue, ESC to go back.")
+}
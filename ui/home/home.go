@@ -24,6 +24,13 @@ var Menu = []menuItem{
 	{label: "Syncronize blockchain", page: page.Sync},
 	{label: "Receive", page: page.Receive},
 	{label: "Send", page: page.Send},
+	{label: "Coins", page: page.Coins},
+	{label: "History", page: page.History},
+	{label: "Speed up incoming payment", page: page.Cpfp},
+	{label: "Sweep paper wallet", page: page.Sweep},
+	{label: "Contacts", page: page.Contacts},
+	{label: "Peers", page: page.Peers},
+	{label: "Backup and restore", page: page.Backup},
 }
 
 func New(ctx *framework.AppContext, params interface{}) framework.Page {
@@ -50,7 +57,7 @@ func (m *state) rebuildMenu() {
 }
 
 func (m *state) Init() tea.Cmd {
-	wallet, err := m.ctx.WalletRepo.GetActiveWallet(m.ctx.Passphrase)
+	wallet, err := m.ctx.ActiveWallet()
 	if err != nil {
 		return func() tea.Msg { return errorMsg{err: err} }
 	}
@@ -78,6 +85,7 @@ func (m *state) View() string {
 		v.L("Wallet: %s", m.w.Name)
 	}
 	return v.L(m.selector.Render()).
+		Help("CTRL+L to lock the wallet.").
 		QuitHint().
 		Build()
 }
@@ -12,4 +12,11 @@ var (
 	UnlockWallet   = "unlock"
 	Receive        = "receive"
 	Send           = "send"
+	Peers          = "peers"
+	Backup         = "backup"
+	Contacts       = "contacts"
+	Coins          = "coins"
+	History        = "history"
+	Cpfp           = "cpfp"
+	Sweep          = "sweep"
 )
@@ -3,29 +3,53 @@ package passphrase
 import (
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
 	"github.com/satelliondao/satellion/mnemonic"
 	"github.com/satelliondao/satellion/ui/framework"
 	"github.com/satelliondao/satellion/ui/router"
+	"github.com/satelliondao/satellion/wallet"
 )
 
+type step int
+
+const (
+	stepPassphrase step = iota
+	stepPassword
+	stepConfirm
+)
+
+// state asks for the optional BIP39 passphrase of a new wallet, then for the password
+// that encrypts it on this device. Wallets stored before seeds were encrypted skip the
+// passphrase: they only need a password.
 type state struct {
-	ctx        *framework.AppContext
-	passInput  textinput.Model
-	confirm    textinput.Model
-	err        string
-	confirming bool
-	walletName string
-	mnemonic   *mnemonic.Mnemonic
+	ctx         *framework.AppContext
+	passInput   textinput.Model
+	password    textinput.Model
+	confirm     textinput.Model
+	step        step
+	fingerprint string
+	err         string
+	walletName  string
+	mnemonic    *mnemonic.Mnemonic
+	legacy      *router.SetPasswordProps
 }
 
 func New(ctx *framework.AppContext, p interface{}) framework.Page {
 	m := state{ctx: ctx}
-	if props, ok := p.(*router.VerifyMnemonicProps); ok {
+	switch props := p.(type) {
+	case *router.VerifyMnemonicProps:
 		m.walletName = props.WalletName
 		m.mnemonic = props.Mnemonic
+	case *router.SetPasswordProps:
+		m.legacy = props
+		m.step = stepPassword
+	}
+	m.passInput = PassphraseInput("25th word (optional)")
+	m.password = PassphraseInput("Wallet password")
+	m.confirm = PassphraseInput("Confirm password")
+	if m.mnemonic != nil {
+		m.fingerprint = wallet.MasterFingerprint(m.mnemonic, "")
 	}
-	m.passInput = PassphraseInput("Imagine a passphrase")
-	m.confirm = PassphraseInput("Confirm passphrase")
 	return m
 }
 
@@ -41,7 +65,6 @@ func PassphraseInput(placeholder string) textinput.Model {
 }
 
 func (m state) Init() tea.Cmd {
-	m.passInput.Focus()
 	return textinput.Blink
 }
 
@@ -53,44 +76,80 @@ func (m state) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyCtrlC, tea.KeyEsc:
 			return m, tea.Quit
 		case tea.KeyEnter:
-			if !m.confirming {
-				m.confirming = true
-				return m, nil
-			}
-			return m.handleConfirmInput()
+			return m.handleEnter()
 		}
 	}
 
-	if m.confirming {
-		m.confirm, cmd = m.confirm.Update(msg)
-	} else {
+	switch m.step {
+	case stepPassphrase:
+		before := m.passInput.Value()
 		m.passInput, cmd = m.passInput.Update(msg)
+		if m.passInput.Value() != before && m.mnemonic != nil {
+			m.fingerprint = wallet.MasterFingerprint(m.mnemonic, m.passInput.Value())
+		}
+	case stepPassword:
+		m.password, cmd = m.password.Update(msg)
+	case stepConfirm:
+		m.confirm, cmd = m.confirm.Update(msg)
 	}
-
 	return m, cmd
 }
 
-func (m state) handleConfirmInput() (tea.Model, tea.Cmd) {
-	if m.confirm.Value() != m.passInput.Value() {
-		m.err = "Passphrases do not match."
+func (m state) handleEnter() (tea.Model, tea.Cmd) {
+	m.err = ""
+	switch m.step {
+	case stepPassphrase:
+		m.step = stepPassword
+		return m, nil
+	case stepPassword:
+		if m.password.Value() == "" {
+			m.err = "Password cannot be empty."
+			return m, nil
+		}
+		m.step = stepConfirm
+		return m, nil
+	}
+	if m.confirm.Value() != m.password.Value() {
+		m.err = "Passwords do not match."
+		m.confirm.SetValue("")
 		return m, nil
 	}
-	if err := m.ctx.WalletService.AddWallet(m.walletName, *m.mnemonic, m.passInput.Value()); err != nil {
+	var err error
+	if m.legacy != nil {
+		err = m.ctx.WalletService.SealLegacy(m.legacy.Passphrase, m.password.Value())
+	} else {
+		err = m.ctx.WalletService.AddWallet(m.walletName, *m.mnemonic, m.passInput.Value(), m.password.Value())
+	}
+	if err != nil {
 		m.err = err.Error()
 		return m, nil
 	}
-	m.ctx.Passphrase = m.passInput.Value()
+	m.ctx.Unlock(m.password.Value())
+	m.passInput.Reset()
+	m.password.Reset()
+	m.confirm.Reset()
 	return m, router.Home()
 }
 
 func (m state) View() string {
 	v := framework.View()
-	if m.confirming {
-		v.L("Confirm your passphrase:").
+	switch m.step {
+	case stepPassphrase:
+		v.L("Enter a BIP39 passphrase, the \"25th word\" (optional):").
+			L(m.passInput.View()).
+			L("").
+			L("Master key fingerprint: %s", color.New(color.Bold).Sprint(m.fingerprint)).
+			Help("The passphrase changes every address of the wallet. Write it down with your mnemonic:\nwithout it, funds cannot be recovered. Check the fingerprint to make sure you typed it right.")
+	case stepPassword:
+		if m.legacy != nil {
+			v.Warn("The seed of this wallet is stored unencrypted.")
+		}
+		v.L("Choose a password to encrypt the wallet on this device:").
+			L(m.password.View()).
+			Help("The password is only used to unlock the wallet here. It does not change your addresses.")
+	case stepConfirm:
+		v.L("Confirm your password:").
 			L(m.confirm.View())
-	} else {
-		v.L("Enter a passphrase (optional):").
-			L(m.passInput.View())
 	}
 	return v.Err(m.err).
 		QuitHint().
@@ -0,0 +1,217 @@
+package peers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+	"github.com/satelliondao/satellion/neutrino"
+	"github.com/satelliondao/satellion/ui/framework"
+	"github.com/satelliondao/satellion/ui/router"
+)
+
+type tickMsg time.Time
+
+type state struct {
+	ctx      *framework.AppContext
+	peers    []neutrino.PeerInfo
+	selector *framework.ChoiceSelector
+	input    textinput.Model
+	adding   bool
+	info     string
+	err      string
+}
+
+func New(ctx *framework.AppContext, params interface{}) framework.Page {
+	return &state{
+		ctx:      ctx,
+		selector: framework.NewChoiceSelector(nil),
+		input:    addressInput(),
+	}
+}
+
+func (s *state) Init() tea.Cmd {
+	if err := s.ctx.ChainService.Start(); err != nil {
+		s.err = err.Error()
+		return nil
+	}
+	s.refresh()
+	return s.tick()
+}
+
+func (s *state) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if s.adding {
+		return s.updateAdding(msg)
+	}
+	nav := framework.HandleNav(msg, router.Home())
+	if nav != nil {
+		return s, nav
+	}
+
+	switch v := msg.(type) {
+	case tickMsg:
+		s.refresh()
+		return s, s.tick()
+	case tea.KeyMsg:
+		if s.selector.Update(msg).Consumed {
+			return s, nil
+		}
+		s.err, s.info = "", ""
+		switch v.String() {
+		case "a":
+			s.adding = true
+			s.input.SetValue("")
+			s.input.Focus()
+			return s, textinput.Blink
+		case "d":
+			s.removeSelected()
+		case "b":
+			s.withSelected("Banned %s", s.ctx.ChainService.BanPeer)
+		case "u":
+			s.withSelected("Unbanned %s", s.ctx.ChainService.UnbanPeer)
+		case "c":
+			s.toggleConnectOnly()
+		}
+		s.refresh()
+	}
+	return s, nil
+}
+
+func (s *state) updateAdding(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if v, ok := msg.(tea.KeyMsg); ok {
+		switch v.Type {
+		case tea.KeyCtrlC:
+			return s, tea.Quit
+		case tea.KeyEsc:
+			s.adding = false
+			return s, nil
+		case tea.KeyEnter:
+			s.adding = false
+			s.addPeer(s.input.Value())
+			s.refresh()
+			return s, nil
+		}
+	}
+	var cmd tea.Cmd
+	s.input, cmd = s.input.Update(msg)
+	return s, cmd
+}
+
+func (s *state) addPeer(addr string) {
+	if addr == "" {
+		return
+	}
+	if err := s.ctx.ChainService.AddPeer(addr); err != nil {
+		s.err = err.Error()
+		return
+	}
+	if s.ctx.Config.AddPeer(addr) {
+		if err := s.ctx.Config.Save(s.ctx.Config); err != nil {
+			s.err = err.Error()
+			return
+		}
+	}
+	s.info = fmt.Sprintf("Connecting to %s", addr)
+}
+
+func (s *state) removeSelected() {
+	selected := s.selector.Selected()
+	if selected == nil {
+		return
+	}
+	addr := selected.Value.(string)
+	if err := s.ctx.ChainService.RemovePeer(addr); err != nil {
+		s.err = err.Error()
+	}
+	if s.ctx.Config.RemovePeer(addr) {
+		if err := s.ctx.Config.Save(s.ctx.Config); err != nil {
+			s.err = err.Error()
+			return
+		}
+	}
+	s.info = fmt.Sprintf("Removed %s", addr)
+}
+
+func (s *state) withSelected(done string, action func(addr string) error) {
+	selected := s.selector.Selected()
+	if selected == nil {
+		return
+	}
+	addr := selected.Value.(string)
+	if err := action(addr); err != nil {
+		s.err = err.Error()
+		return
+	}
+	s.info = fmt.Sprintf(done, addr)
+}
+
+func (s *state) toggleConnectOnly() {
+	s.ctx.Config.ConnectOnly = !s.ctx.Config.ConnectOnly
+	if err := s.ctx.Config.Save(s.ctx.Config); err != nil {
+		s.err = err.Error()
+		return
+	}
+	s.info = "Connection mode changes apply after restart"
+}
+
+func (s *state) refresh() {
+	s.peers = s.ctx.ChainService.Peers()
+	choices := make([]framework.Choice, len(s.peers))
+	for i, p := range s.peers {
+		status := fmt.Sprintf("height %d, %s", p.Height, p.Latency.Round(time.Millisecond))
+		if p.Banned {
+			status = color.New(color.FgRed).Sprint("banned")
+		}
+		choices[i] = framework.Choice{Label: fmt.Sprintf("%-28s %s", p.Addr, status), Value: p.Addr}
+	}
+	s.selector.SetChoices(choices)
+}
+
+func (s *state) tick() tea.Cmd {
+	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+func (s *state) View() string {
+	mode := "configured peers and discovered peers"
+	if s.ctx.Config.ConnectOnly {
+		mode = "only configured peers"
+	}
+	v := framework.View().
+		L(color.New(color.FgHiBlue).Sprintf("Peers")).
+		L("Connecting to: %s", mode).
+		L("")
+	if s.selector.IsEmpty() {
+		v.L("No peers connected")
+	} else {
+		v.L(s.selector.Render())
+	}
+	if selected := s.selector.Selected(); selected != nil {
+		p := s.peers[s.selector.SelectedIndex()]
+		v.L("User agent: %s", p.UserAgent).
+			L("Services: %s", p.Services)
+	}
+	if s.adding {
+		v.L("").
+			L("Peer address (host:port):").
+			L(s.input.View()).
+			Help("Enter to connect, ESC to cancel")
+		return v.Err(s.err).Build()
+	}
+	if s.info != "" {
+		v.L(color.New(color.FgGreen).Sprint(s.info))
+	}
+	return v.Err(s.err).
+		Help("A to add, D to remove, B to ban, U to unban, C to toggle connect-only mode").
+		QuitHint().
+		Build()
+}
+
+func addressInput() textinput.Model {
+	i := textinput.New()
+	i.Placeholder = "host:port"
+	i.CharLimit = 100
+	i.Width = 40
+	return i
+}
@@ -3,32 +3,76 @@ package receive
 import (
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/ansi"
 	"github.com/fatih/color"
+	"github.com/satelliondao/satellion/bip21"
+	"github.com/satelliondao/satellion/bip329"
+	"github.com/satelliondao/satellion/hwi"
+	"github.com/satelliondao/satellion/qrcode"
 	"github.com/satelliondao/satellion/ui/framework"
 	"github.com/satelliondao/satellion/ui/router"
 	"github.com/satelliondao/satellion/wallet"
 )
 
+// focus is the input being edited, if any.
+type focus int
+
+const (
+	focusNone focus = iota
+	focusAmount
+	focusLabel
+	focusMessage
+)
+
 type state struct {
-	ctx     *framework.AppContext
-	err     string
-	address *wallet.Address
-	wallet  *wallet.Wallet
+	ctx      *framework.AppContext
+	err      string
+	address  *wallet.Address
+	wallet   *wallet.Wallet
+	verified string
+	amount   textinput.Model
+	label    textinput.Model
+	message  textinput.Model
+	focus    focus
+	code     *qrcode.Code
+	exported string
 }
 
 type errorMsg struct {
 	err string
 }
 
+type addressMsg struct {
+	address *wallet.Address
+}
+
+type verifiedMsg struct {
+	address string
+}
+
 func New(ctx *framework.AppContext, params interface{}) framework.Page {
 	s := &state{ctx: ctx}
+	s.amount = textinput.New()
+	s.amount.Placeholder = "Amount in BTC (optional)"
+	s.amount.CharLimit = 20
+	s.amount.Width = 24
+	s.label = textinput.New()
+	s.label.Placeholder = "Label, kept with the address (optional)"
+	s.label.CharLimit = 64
+	s.label.Width = 40
+	s.message = textinput.New()
+	s.message.Placeholder = "Message (optional)"
+	s.message.CharLimit = 128
+	s.message.Width = 24
 	return s
 }
 
 func (s *state) Init() tea.Cmd {
-	w, err := s.ctx.WalletRepo.GetActiveWallet(s.ctx.Passphrase)
+	w, err := s.ctx.ActiveWallet()
 	if err != nil || w == nil {
 		s.err = fmt.Errorf("wallet not available").Error()
 		return nil
@@ -39,11 +83,39 @@ func (s *state) Init() tea.Cmd {
 		s.err = err.Error()
 		return nil
 	}
-	s.address = addr
+	s.setAddress(addr)
 	return nil
 }
 
+// setAddress shows addr with its stored label.
+func (s *state) setAddress(addr *wallet.Address) {
+	s.address = addr
+	s.label.Reset()
+	l, err := s.ctx.WalletRepo.Label(s.wallet.Name, bip329.Addr, addr.Address.String())
+	if err != nil {
+		s.err = err.Error()
+		return
+	}
+	s.label.SetValue(l.Label)
+}
+
+// saveLabel stores the label input as the label of the address.
+func (s *state) saveLabel() {
+	ref := s.address.Address.String()
+	l, err := s.ctx.WalletRepo.Label(s.wallet.Name, bip329.Addr, ref)
+	if err == nil && l.Label != strings.TrimSpace(s.label.Value()) {
+		l.Label = strings.TrimSpace(s.label.Value())
+		err = s.ctx.WalletRepo.SetLabels(s.wallet.Name, l)
+	}
+	if err != nil {
+		s.err = err.Error()
+	}
+}
+
 func (s *state) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if key, ok := msg.(tea.KeyMsg); ok && s.focus != focusNone && key.Type != tea.KeyCtrlC {
+		return s, s.updateInput(key)
+	}
 	nav := framework.HandleNav(msg, router.Home())
 	if nav != nil {
 		return s, nav
@@ -52,38 +124,227 @@ func (s *state) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch v := msg.(type) {
 	case errorMsg:
 		s.err = v.err
+		s.verified = ""
+		return s, nil
+	case addressMsg:
+		s.err = ""
+		s.setAddress(v.address)
+		return s, nil
+	case verifiedMsg:
+		s.err = ""
+		s.verified = v.address
 		return s, nil
 	case tea.KeyMsg:
-		if strings.ToLower(v.String()) == "r" {
+		switch strings.ToLower(v.String()) {
+		case "tab":
+			return s, s.setFocus(focusAmount)
+		case "p":
+			s.exportPNG()
+			return s, nil
+		case "r":
+			s.verified = ""
+			s.exported = ""
 			return s, s.regenerateAddress()
+		case "d":
+			if s.wallet != nil && s.wallet.WatchOnly() && s.address != nil {
+				s.err = ""
+				s.verified = ""
+				return s, s.showOnDevice()
+			}
 		}
 	}
 	return s, nil
 }
 
+// updateInput passes key to the focused input. Tab moves to the next input, enter and
+// esc stop editing.
+func (s *state) updateInput(key tea.KeyMsg) tea.Cmd {
+	switch key.Type {
+	case tea.KeyTab:
+		return s.setFocus((s.focus + 1) % (focusMessage + 1))
+	case tea.KeyEnter, tea.KeyEsc:
+		return s.setFocus(focusNone)
+	}
+	s.exported = ""
+	var cmd tea.Cmd
+	switch s.focus {
+	case focusAmount:
+		s.amount, cmd = s.amount.Update(key)
+	case focusLabel:
+		s.label, cmd = s.label.Update(key)
+	case focusMessage:
+		s.message, cmd = s.message.Update(key)
+	}
+	return cmd
+}
+
+func (s *state) setFocus(f focus) tea.Cmd {
+	if s.focus == focusLabel {
+		s.saveLabel()
+	}
+	s.focus = f
+	s.amount.Blur()
+	s.label.Blur()
+	s.message.Blur()
+	switch f {
+	case focusAmount:
+		return s.amount.Focus()
+	case focusLabel:
+		return s.label.Focus()
+	case focusMessage:
+		return s.message.Focus()
+	}
+	return nil
+}
+
+// qrText returns what the QR code encodes: the address alone, upper-cased so that it
+// fits the denser alphanumeric mode, or a BIP21 URI when an amount, label or message is
+// set.
+func (s *state) qrText() (string, error) {
+	addr := s.address.Address.String()
+	amount := strings.TrimSpace(s.amount.Value())
+	label := strings.TrimSpace(s.label.Value())
+	message := strings.TrimSpace(s.message.Value())
+	if amount == "" && label == "" && message == "" {
+		return strings.ToUpper(addr), nil
+	}
+	uri := &bip21.URI{Address: addr, Label: label, Message: message}
+	if amount != "" {
+		var err error
+		if uri.Amount, err = bip21.ParseAmount(amount); err != nil {
+			return "", err
+		}
+	}
+	return uri.String(), nil
+}
+
+// qrCode encodes the current address and request, reusing the last code when unchanged.
+func (s *state) qrCode() (*qrcode.Code, error) {
+	text, err := s.qrText()
+	if err != nil {
+		return nil, err
+	}
+	if s.code == nil || s.code.Text() != text {
+		if s.code, err = qrcode.Encode(text); err != nil {
+			return nil, err
+		}
+	}
+	return s.code, nil
+}
+
+func (s *state) exportPNG() {
+	s.exported = ""
+	if s.address == nil {
+		return
+	}
+	code, err := s.qrCode()
+	if err == nil {
+		path := qrcode.DefaultPath(s.address.Address.String() + "-" + time.Now().Format("20060102-150405"))
+		if err = code.WritePNG(path); err == nil {
+			s.err = ""
+			s.exported = path
+			return
+		}
+	}
+	s.err = err.Error()
+}
+
+// showOnDevice displays the address on the hardware wallet and checks it matches.
+func (s *state) showOnDevice() tea.Cmd {
+	w, index := s.wallet, s.address.DeriviationIndex
+	return func() tea.Msg {
+		params, err := s.ctx.Config.ChainParams()
+		if err != nil {
+			return errorMsg{err: err.Error()}
+		}
+		addr, err := hwi.New(s.ctx.Config.HWIPath, params).VerifyAddress(w, 0, index)
+		if err != nil {
+			return errorMsg{err: err.Error()}
+		}
+		return verifiedMsg{address: addr}
+	}
+}
+
 func (s *state) regenerateAddress() tea.Cmd {
 	return func() tea.Msg {
 		addr, err := s.wallet.NewReceiveAddress()
 		if err != nil {
 			return errorMsg{err: err.Error()}
 		}
-		s.address = addr
 		err = s.ctx.WalletRepo.Save(s.wallet)
 		if err != nil {
 			return errorMsg{err: err.Error()}
 		}
-		return nil
+		return addressMsg{address: addr}
 	}
 }
 
 func (s *state) View() string {
-	return framework.View().
-		L("Address:").
+	if s.address == nil {
+		return framework.View().Err(s.err).QuitHint().Build()
+	}
+	code, err := s.qrCode()
+	if err != nil {
+		return s.view("", err.Error())
+	}
+	// The code gets whatever rows the rest of the page leaves free.
+	rows := s.rows(s.view("", ""))
+	qr := code.Render(s.ctx.Width, s.ctx.Height-rows)
+	if s.ctx.Height > 0 && s.ctx.Height <= rows {
+		qr = ""
+	}
+	if qr == "" {
+		qr = color.New(color.FgHiBlack).Sprint("Enlarge the terminal to show the QR code.")
+	}
+	return s.view(qr, "")
+}
+
+// rows returns how many terminal rows page takes, counting wrapped lines.
+func (s *state) rows(page string) int {
+	rows := 0
+	for _, line := range strings.Split(page, "\n") {
+		rows++
+		if w := ansi.StringWidth(line); s.ctx.Width > 0 && w > s.ctx.Width {
+			rows += (w - 1) / s.ctx.Width
+		}
+	}
+	return rows
+}
+
+func (s *state) view(qr, qrErr string) string {
+	v := framework.View()
+	v.L("Address:").
 		L(color.New(color.FgGreen).Sprintf(s.address.Address.String())).
-		L("Derivation Index: %d", s.address.DeriviationIndex).
+		L("Derivation path: %s", wallet.AddressPath(0, s.address.DeriviationIndex)).
+		L("Master fingerprint: %s", s.wallet.Fingerprint).
 		L("").
-		Err(s.err).
-		Help("R to generate new address").
+		L(s.amount.View()).
+		L(s.label.View()).
+		L(s.message.View()).
+		L("")
+	if qrErr == "" && s.code != nil && bip21.IsURI(s.code.Text()) {
+		v.L("Payment URI: %s", s.code.Text()).L("")
+	}
+	if qr != "" {
+		v.L("%s", qr)
+	}
+	help := "R to generate new address. Cross-check it with `sat verify-address`."
+	if s.wallet.WatchOnly() {
+		help = "R to generate new address, D to show it on the hardware wallet."
+		if s.verified != "" {
+			v.L(color.New(color.FgGreen).Sprint("The hardware wallet shows the same address."))
+		}
+	}
+	if s.exported != "" {
+		v.L("QR code saved to %s", s.exported)
+	}
+	errText := s.err
+	if qrErr != "" {
+		errText = qrErr
+	}
+	return v.Err(errText).
+		Help(help).
+		Help("TAB to request an amount with a label and message, P to save the QR code as PNG.").
 		QuitHint().
 		Build()
 }
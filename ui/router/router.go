@@ -1,6 +1,7 @@
 package router
 
 import (
+	"github.com/btcsuite/btcd/wire"
 	tea "github.com/charmbracelet/bubbletea"
 	"github.com/satelliondao/satellion/mnemonic"
 	"github.com/satelliondao/satellion/ui/framework"
@@ -12,6 +13,12 @@ type VerifyMnemonicProps struct {
 	Mnemonic   *mnemonic.Mnemonic
 }
 
+// SetPasswordProps opens the passphrase page to encrypt a wallet stored before seeds were
+// encrypted. Passphrase is the BIP39 passphrase it was unlocked with.
+type SetPasswordProps struct {
+	Passphrase string
+}
+
 func Home() tea.Cmd {
 	return framework.Navigate(page.Home)
 }
@@ -28,6 +35,43 @@ func Send() tea.Cmd {
 	return framework.Navigate(page.Send)
 }
 
+// SendProps opens the send page funded by hand-picked Inputs.
+type SendProps struct {
+	Inputs []wire.OutPoint
+}
+
+func SendFrom(inputs []wire.OutPoint) tea.Cmd {
+	return framework.NavigateWithParams(page.Send, &SendProps{Inputs: inputs})
+}
+
+func Peers() tea.Cmd {
+	return framework.Navigate(page.Peers)
+}
+
+func Backup() tea.Cmd {
+	return framework.Navigate(page.Backup)
+}
+
+func Contacts() tea.Cmd {
+	return framework.Navigate(page.Contacts)
+}
+
+func Coins() tea.Cmd {
+	return framework.Navigate(page.Coins)
+}
+
+func History() tea.Cmd {
+	return framework.Navigate(page.History)
+}
+
+func Cpfp() tea.Cmd {
+	return framework.Navigate(page.Cpfp)
+}
+
+func Sweep() tea.Cmd {
+	return framework.Navigate(page.Sweep)
+}
+
 func VerifyMnemonic(walletName string, mnemonic *mnemonic.Mnemonic) tea.Cmd {
 	return framework.NavigateWithParams(page.VerifyMnemonic, &VerifyMnemonicProps{WalletName: walletName, Mnemonic: mnemonic})
 }
@@ -36,6 +80,10 @@ func Passphrase(walletName string, mnemonic *mnemonic.Mnemonic) tea.Cmd {
 	return framework.NavigateWithParams(page.Passphrase, &VerifyMnemonicProps{WalletName: walletName, Mnemonic: mnemonic})
 }
 
+func SetPassword(passphrase string) tea.Cmd {
+	return framework.NavigateWithParams(page.Passphrase, &SetPasswordProps{Passphrase: passphrase})
+}
+
 func ListWallets() tea.Cmd {
 	return framework.Navigate(page.ListWallets)
 }
@@ -1,35 +1,511 @@
 package send
 
 import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+	"github.com/satelliondao/satellion/bip21"
+	"github.com/satelliondao/satellion/coinselect"
+	"github.com/satelliondao/satellion/contacts"
+	"github.com/satelliondao/satellion/spend"
 	"github.com/satelliondao/satellion/ui/framework"
 	"github.com/satelliondao/satellion/ui/router"
 )
 
+const (
+	fieldRecipient = iota
+	fieldAmount
+	fieldFeeRate
+	fieldLabel
+	fieldCount
+)
+
+// maxAmount is typed as the amount to send everything left, without change.
+const maxAmount = "max"
+
+// row is a recipient of a batch payment, with the contact it was picked from.
+type row struct {
+	out     spend.Output
+	contact *contacts.Contact
+}
+
+// state collects a payment: the recipient address, or a pasted bitcoin: URI that fills
+// in the other fields, the amount, the fee rate and a label for the payment. The
+// recipient can also be picked from the contacts. More recipients are added as rows of
+// a batch, typed one by one or loaded from a CSV file. The payment is funded by the
+// coins picked on the coins page, or by the coin selector, and is signed for review
+// before it is broadcast.
 type state struct {
-	ctx *framework.AppContext
+	ctx       *framework.AppContext
+	inputs    []textinput.Model
+	focus     int
+	message   string
+	lightning bool
+	rows      []row
+	file      *textinput.Model
+	payment   []row
+	label     string
+	picked    []wire.OutPoint
+	signed    *spend.Transaction
+	sending   bool
+	sent      string
+	contacts  []contacts.Contact
+	picker    *framework.ChoiceSelector
+	contact   *contacts.Contact
+	warnings  []string
+	err       string
 }
 
 func New(ctx *framework.AppContext, params interface{}) framework.Page {
-	s := &state{ctx: ctx}
+	s := &state{ctx: ctx, inputs: make([]textinput.Model, fieldCount)}
+	feeRate := fmt.Sprintf("Fee rate in sat/vB (default %d)", int64(coinselect.DefaultFeeRate))
+	for i, placeholder := range []string{"Address or bitcoin: URI", "Amount in BTC, or max", feeRate, "Label (optional)"} {
+		in := textinput.New()
+		in.Placeholder = placeholder
+		in.CharLimit = 128
+		in.Width = 64
+		s.inputs[i] = in
+	}
+	// Payment URIs with a lightning invoice run well past an address.
+	s.inputs[fieldRecipient].CharLimit = 2048
+	s.inputs[fieldAmount].CharLimit = 20
+	s.inputs[fieldAmount].Width = 24
+	s.inputs[fieldFeeRate].CharLimit = 6
+	s.inputs[fieldFeeRate].Width = 40
+	s.inputs[fieldRecipient].Focus()
+	if props, ok := params.(*router.SendProps); ok {
+		s.picked = props.Inputs
+	}
 	return s
 }
 
 func (m *state) Init() tea.Cmd {
-	return nil
+	list, err := m.ctx.WalletRepo.Contacts()
+	if err != nil {
+		m.err = err.Error()
+	}
+	m.contacts = list
+	return textinput.Blink
 }
 
 func (m *state) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	if m.picker != nil {
+		return m, m.updatePicker(msg)
+	}
+	if m.file != nil {
+		return m, m.updateFile(msg)
+	}
+	if sent, ok := msg.(sentMsg); ok {
+		m.sending = false
+		if sent.txid != "" {
+			m.sent, m.payment, m.signed, m.rows = sent.txid, nil, nil, nil
+		}
+		if sent.err != nil {
+			m.err = sent.err.Error()
+		}
+		return m, nil
+	}
+	if m.sending || m.sent != "" {
+		return m, framework.HandleNav(msg, router.Home())
+	}
 	nav := framework.HandleNav(msg, router.Home())
 	if nav != nil {
 		return m, nav
 	}
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.Type {
+		case tea.KeyEnter:
+			return m, m.handleEnter()
+		case tea.KeyCtrlO:
+			m.openPicker()
+			return m, nil
+		case tea.KeyCtrlA:
+			return m, m.addRow()
+		case tea.KeyCtrlD:
+			if len(m.rows) > 0 {
+				m.rows, m.payment, m.signed = m.rows[:len(m.rows)-1], nil, nil
+			}
+			return m, nil
+		case tea.KeyCtrlF:
+			file := textinput.New()
+			file.Placeholder = "Path of a CSV file of address,amount lines"
+			file.CharLimit = 1024
+			file.Width = 64
+			m.file = &file
+			return m, m.file.Focus()
+		case tea.KeyTab, tea.KeyDown:
+			return m, m.setFocus((m.focus + 1) % fieldCount)
+		case tea.KeyShiftTab, tea.KeyUp:
+			return m, m.setFocus((m.focus + fieldCount - 1) % fieldCount)
+		}
+	}
+	var cmd tea.Cmd
+	before := m.inputs[m.focus].Value()
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+	if m.inputs[m.focus].Value() != before {
+		m.payment, m.signed = nil, nil
+		m.err = ""
+		if m.focus == fieldRecipient {
+			m.contact = nil
+			m.fillFromURI()
+			m.checkRecipient()
+		}
+	}
+	return m, cmd
+}
+
+// fillFromURI replaces a pasted payment URI with its address and fills in the fields it
+// sets.
+func (m *state) fillFromURI() {
+	text := strings.TrimSpace(m.inputs[fieldRecipient].Value())
+	m.message, m.lightning = "", false
+	if !bip21.IsURI(text) {
+		return
+	}
+	params, err := m.ctx.Config.ChainParams()
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	uri, err := bip21.Parse(text, params)
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	m.inputs[fieldRecipient].SetValue(uri.Address)
+	if uri.Amount > 0 {
+		m.inputs[fieldAmount].SetValue(bip21.FormatAmount(uri.Amount))
+	}
+	if uri.Label != "" {
+		m.inputs[fieldLabel].SetValue(uri.Label)
+	}
+	m.message = uri.Message
+	m.lightning = uri.Lightning != ""
+}
 
-	return m, nil
+func (m *state) openPicker() {
+	if len(m.contacts) == 0 {
+		m.err = "No contacts yet. Add them from the Contacts page."
+		return
+	}
+	choices := make([]framework.Choice, len(m.contacts))
+	for i, c := range m.contacts {
+		to := c.Address
+		if c.Descriptor != "" {
+			to = "fresh address from descriptor"
+		}
+		choices[i] = framework.Choice{Label: fmt.Sprintf("%s  %s", c.Name, to), Value: i}
+	}
+	m.picker = framework.NewChoiceSelector(choices)
+}
+
+func (m *state) updatePicker(msg tea.Msg) tea.Cmd {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.Type {
+		case tea.KeyCtrlC:
+			return tea.Quit
+		case tea.KeyEsc:
+			m.picker = nil
+			return nil
+		}
+	}
+	res := m.picker.Update(msg)
+	if res.Action != framework.ActionSelection {
+		return nil
+	}
+	m.picker = nil
+	c := &m.contacts[res.Selected.Value.(int)]
+	addr, err := c.PaymentAddress(m.ctx.WalletRepo.Params())
+	if err != nil {
+		m.err = err.Error()
+		return nil
+	}
+	m.err, m.payment, m.signed, m.message, m.lightning = "", nil, nil, "", false
+	m.inputs[fieldRecipient].SetValue(addr)
+	if m.inputs[fieldLabel].Value() == "" {
+		m.inputs[fieldLabel].SetValue(c.Name)
+	}
+	m.contact = c
+	m.checkRecipient()
+	return m.setFocus(fieldAmount)
+}
+
+// checkRecipient names the contact paid at the recipient address, and warns when the
+// address was paid before or only looks like the address of a contact.
+func (m *state) checkRecipient() {
+	m.warnings = nil
+	addr := strings.TrimSpace(m.inputs[fieldRecipient].Value())
+	if addr == "" || bip21.IsURI(addr) {
+		return
+	}
+	if c := contacts.Find(addr, m.contacts); c != nil {
+		m.contact = c
+	}
+	if m.contact == nil {
+		if c := contacts.Lookalike(addr, m.contacts); c != nil {
+			m.warnings = append(m.warnings, fmt.Sprintf(
+				"This address looks like the address of %s but is different. Malware may have\nreplaced the address you copied: compare every character.", c.Name))
+		}
+	}
+	paid, err := m.ctx.WalletRepo.Payments(addr)
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	if paid.Count > 0 {
+		m.warnings = append(m.warnings, fmt.Sprintf(
+			"This address was already paid on %s. Reusing addresses hurts the privacy of both\nparties: ask the recipient for a new one.", paid.Last.Format("2006-01-02")))
+	}
+}
+
+func (m *state) setFocus(field int) tea.Cmd {
+	m.inputs[m.focus].Blur()
+	m.focus = field
+	return m.inputs[m.focus].Focus()
+}
+
+// addRow moves the recipient and amount being typed to the rows of the batch.
+func (m *state) addRow() tea.Cmd {
+	m.err = ""
+	r, err := m.validate()
+	if err != nil {
+		m.err = err.Error()
+		return nil
+	}
+	m.rows = append(m.rows, *r)
+	m.payment, m.signed, m.contact, m.warnings, m.message, m.lightning = nil, nil, nil, nil, "", false
+	m.inputs[fieldRecipient].Reset()
+	m.inputs[fieldAmount].Reset()
+	return m.setFocus(fieldRecipient)
+}
+
+func (m *state) updateFile(msg tea.Msg) tea.Cmd {
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.Type {
+		case tea.KeyCtrlC:
+			return tea.Quit
+		case tea.KeyEsc:
+			m.file = nil
+			return m.inputs[m.focus].Focus()
+		case tea.KeyEnter:
+			m.loadFile(strings.TrimSpace(m.file.Value()))
+			return nil
+		}
+	}
+	var cmd tea.Cmd
+	*m.file, cmd = m.file.Update(msg)
+	return cmd
+}
+
+// loadFile adds the recipients of a CSV file to the batch.
+func (m *state) loadFile(path string) {
+	f, err := os.Open(path)
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	defer f.Close()
+	list, err := spend.ParseBatch(f)
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	for _, o := range list {
+		m.rows = append(m.rows, row{out: o, contact: contacts.Find(o.Address, m.contacts)})
+	}
+	m.file, m.payment, m.signed, m.err = nil, nil, nil, ""
+}
+
+func (m *state) handleEnter() tea.Cmd {
+	if m.focus < fieldCount-1 {
+		return m.setFocus(m.focus + 1)
+	}
+	if m.signed != nil {
+		return m.broadcast()
+	}
+	m.err = ""
+	payment := append([]row{}, m.rows...)
+	if len(m.rows) == 0 || strings.TrimSpace(m.inputs[fieldRecipient].Value()) != "" {
+		r, err := m.validate()
+		if err != nil {
+			m.err = err.Error()
+			return nil
+		}
+		payment = append(payment, *r)
+	}
+	feeRate, err := m.feeRate()
+	if err != nil {
+		m.err = err.Error()
+		return nil
+	}
+	w, err := m.ctx.ActiveWallet()
+	if err != nil {
+		m.err = err.Error()
+		return nil
+	}
+	signed, err := m.ctx.Payments.Pay(w, paymentOutputs(payment), m.picked, feeRate)
+	if err != nil {
+		m.err = err.Error()
+		return nil
+	}
+	m.payment, m.signed = payment, signed
+	m.label = strings.TrimSpace(m.inputs[fieldLabel].Value())
+	return nil
+}
+
+// feeRate returns the fee rate in sat/vB, the default one when the field is empty.
+func (m *state) feeRate() (btcutil.Amount, error) {
+	text := strings.TrimSpace(m.inputs[fieldFeeRate].Value())
+	if text == "" {
+		return coinselect.DefaultFeeRate, nil
+	}
+	rate, err := strconv.ParseInt(text, 10, 64)
+	if err != nil || rate < 1 {
+		return 0, fmt.Errorf("fee rate must be a whole number of sat/vB, at least 1")
+	}
+	return btcutil.Amount(rate), nil
+}
+
+type sentMsg struct {
+	txid string
+	err  error
+}
+
+// broadcast sends the reviewed transaction. The next address of each contact paid
+// through a descriptor is used up.
+func (m *state) broadcast() tea.Cmd {
+	w, err := m.ctx.ActiveWallet()
+	if err != nil {
+		m.err = err.Error()
+		return nil
+	}
+	m.sending, m.err = true, ""
+	signed, label, payment := m.signed, m.label, m.payment
+	return func() tea.Msg {
+		if err := m.ctx.Payments.Broadcast(w, signed, label); err != nil {
+			return sentMsg{err: err}
+		}
+		for _, r := range payment {
+			if r.contact == nil || r.contact.Descriptor == "" {
+				continue
+			}
+			next := *r.contact
+			next.NextIndex++
+			if err := m.ctx.WalletRepo.SaveContact(next); err != nil {
+				return sentMsg{txid: signed.Txid, err: err}
+			}
+		}
+		return sentMsg{txid: signed.Txid}
+	}
+}
+
+// validate checks the recipient and amount being typed. The amount is zero when
+// sending everything left.
+func (m *state) validate() (*row, error) {
+	params, err := m.ctx.Config.ChainParams()
+	if err != nil {
+		return nil, err
+	}
+	out := spend.Output{Address: strings.TrimSpace(m.inputs[fieldRecipient].Value())}
+	if text := strings.TrimSpace(m.inputs[fieldAmount].Value()); !strings.EqualFold(text, maxAmount) {
+		if out.Amount, err = bip21.ParseAmount(text); err != nil {
+			return nil, err
+		}
+	}
+	if err := spend.CheckOutputs([]spend.Output{out}, params); err != nil {
+		return nil, err
+	}
+	return &row{out: out, contact: m.contact}, nil
 }
 
 func (m *state) View() string {
 	v := framework.View()
-	v.L("Send")
-	return v.Build()
+	v.L("Send").L("")
+	if len(m.picked) > 0 {
+		v.L("Paying from %d picked coins.", len(m.picked)).L("")
+	}
+	if len(m.rows) > 0 {
+		v.L("Batch:")
+		for i, r := range m.rows {
+			v.L("  %d. %s  %s", i+1, formatAmount(r.out.Amount), r.out.Address)
+		}
+		v.L("")
+	}
+	for _, in := range m.inputs {
+		v.L(in.View())
+	}
+	v.L("")
+	if m.file != nil {
+		v.L("Load recipients:").L(m.file.View())
+		return v.Err(m.err).Help("ENTER to load, ESC to go back.").Build()
+	}
+	if m.picker != nil {
+		v.L("Pay a contact:").L(m.picker.Render())
+		return v.Help("ENTER to pick, ESC to go back.").Build()
+	}
+	if m.contact != nil {
+		v.L("Contact: %s", color.New(color.Bold).Sprint(m.contact.Name))
+		if m.contact.Notes != "" {
+			v.L("%s", color.New(color.FgHiBlack).Sprint(m.contact.Notes))
+		}
+	}
+	for _, w := range m.warnings {
+		v.Warn("%s", w)
+	}
+	if m.message != "" {
+		v.L("Message from the recipient: %s", m.message)
+	}
+	if m.lightning {
+		v.Warn("The request also offers a lightning invoice. This wallet pays the on-chain address.")
+	}
+	if m.payment != nil {
+		for _, o := range m.signed.Recipients() {
+			v.L("Pay %s BTC to %s", color.New(color.Bold).Sprint(bip21.FormatAmount(o.Amount)), o.Address)
+		}
+		if spend.Rest(paymentOutputs(m.payment)) >= 0 {
+			v.L("Everything left is sent, without change.")
+		}
+		v.L("Spending %d coins, fee %d sats (%.1f sat/vB)", len(m.signed.Inputs), int64(m.signed.Fee), m.signed.FeeRate())
+		for _, o := range m.signed.Outputs {
+			if o.Change {
+				v.L("Change: %s BTC to %s", bip21.FormatAmount(o.Amount), o.Address)
+			}
+		}
+		if m.sending {
+			v.Warn("Broadcasting...")
+		} else {
+			v.L(color.New(color.FgHiCyan).Sprint("Press ENTER to broadcast."))
+		}
+	}
+	if m.sent != "" {
+		v.L(color.New(color.FgGreen).Sprint("✓ Sent")).L("Transaction: %s", m.sent).
+			L("It can be bumped or cancelled from the history page until it confirms.")
+	}
+	return v.Err(m.err).
+		Help("Paste an address or a bitcoin: URI, or CTRL+O to pick a contact. TAB to move between\nfields, ENTER to continue. CTRL+A adds the recipient to a batch, CTRL+D removes the last\none, CTRL+F loads a batch from a CSV file. Type max as the amount to send everything.").
+		QuitHint().
+		Build()
+}
+
+// formatAmount formats the amount of a batch row, which is everything left when zero.
+func formatAmount(a btcutil.Amount) string {
+	if a == 0 {
+		return fmt.Sprintf("%-12s", maxAmount)
+	}
+	return fmt.Sprintf("%-12s", bip21.FormatAmount(a)+" BTC")
+}
+
+func paymentOutputs(rows []row) []spend.Output {
+	outputs := make([]spend.Output, len(rows))
+	for i, r := range rows {
+		outputs[i] = r.out
+	}
+	return outputs
 }
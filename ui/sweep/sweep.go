@@ -0,0 +1,270 @@
+package sweep
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/charmbracelet/bubbles/textinput"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/fatih/color"
+	"github.com/satelliondao/satellion/bip21"
+	"github.com/satelliondao/satellion/coinselect"
+	"github.com/satelliondao/satellion/neutrino"
+	"github.com/satelliondao/satellion/sweep"
+	"github.com/satelliondao/satellion/ui/framework"
+	"github.com/satelliondao/satellion/ui/router"
+	"github.com/satelliondao/satellion/wallet"
+)
+
+const (
+	fieldKeys = iota
+	fieldStart
+	fieldFeeRate
+	fieldLabel
+	fieldCount
+)
+
+// state sweeps the coins of external keys, such as those of a paper wallet, to a fresh
+// receive address. The keys are scanned for in the compact filters from a start height,
+// and are wiped when leaving the page: they are never stored.
+type state struct {
+	ctx      *framework.AppContext
+	inputs   []textinput.Model
+	focus    int
+	keys     []*sweep.Key
+	utxos    []wallet.Utxo
+	signed   *sweep.Sweep
+	scanning bool
+	sending  bool
+	sent     string
+	err      string
+}
+
+type scannedMsg struct {
+	utxos []wallet.Utxo
+	err   error
+}
+
+type sentMsg struct {
+	txid string
+	err  error
+}
+
+func New(ctx *framework.AppContext, params interface{}) framework.Page {
+	s := &state{ctx: ctx, inputs: make([]textinput.Model, fieldCount)}
+	feeRate := fmt.Sprintf("Fee rate in sat/vB (default %d)", int64(coinselect.DefaultFeeRate))
+	for i, placeholder := range []string{"WIF keys or descriptors such as wpkh(WIF)", "Start height, a block before the keys were first paid", feeRate, "Label (optional)"} {
+		in := textinput.New()
+		in.Placeholder = placeholder
+		in.CharLimit = 128
+		in.Width = 64
+		s.inputs[i] = in
+	}
+	s.inputs[fieldKeys].CharLimit = 4096
+	s.inputs[fieldKeys].EchoMode = textinput.EchoPassword
+	s.inputs[fieldStart].CharLimit = 10
+	s.inputs[fieldFeeRate].CharLimit = 6
+	s.inputs[fieldKeys].Focus()
+	return s
+}
+
+func (m *state) Init() tea.Cmd {
+	return textinput.Blink
+}
+
+func (m *state) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch v := msg.(type) {
+	case scannedMsg:
+		m.scanning = false
+		if v.err != nil {
+			m.forget()
+			m.err = framework.ErrorText(v.err)
+			return m, nil
+		}
+		m.utxos = v.utxos
+		m.sign()
+		return m, nil
+	case sentMsg:
+		m.sending = false
+		if v.err != nil {
+			m.err = v.err.Error()
+			return m, nil
+		}
+		m.sent = v.txid
+		m.forget()
+		return m, nil
+	}
+	if m.scanning || m.sending || m.sent != "" {
+		return m, m.leave(msg)
+	}
+	if nav := m.leave(msg); nav != nil {
+		return m, nav
+	}
+	if key, ok := msg.(tea.KeyMsg); ok {
+		switch key.Type {
+		case tea.KeyEnter:
+			return m, m.handleEnter()
+		case tea.KeyTab, tea.KeyDown:
+			return m, m.setFocus((m.focus + 1) % fieldCount)
+		case tea.KeyShiftTab, tea.KeyUp:
+			return m, m.setFocus((m.focus + fieldCount - 1) % fieldCount)
+		}
+	}
+	var cmd tea.Cmd
+	before := m.inputs[m.focus].Value()
+	m.inputs[m.focus], cmd = m.inputs[m.focus].Update(msg)
+	if m.inputs[m.focus].Value() != before {
+		m.err = ""
+		if m.focus == fieldKeys || m.focus == fieldStart {
+			m.forget()
+		}
+		m.signed = nil
+	}
+	return m, cmd
+}
+
+// leave wipes the keys when navigating away.
+func (m *state) leave(msg tea.Msg) tea.Cmd {
+	nav := framework.HandleNav(msg, router.Home())
+	if nav != nil {
+		m.forget()
+		m.inputs[fieldKeys].Reset()
+	}
+	return nav
+}
+
+// forget wipes the parsed keys and what was found for them.
+func (m *state) forget() {
+	sweep.Zero(m.keys)
+	m.keys, m.utxos, m.signed = nil, nil, nil
+}
+
+func (m *state) setFocus(field int) tea.Cmd {
+	m.inputs[m.focus].Blur()
+	m.focus = field
+	return m.inputs[m.focus].Focus()
+}
+
+func (m *state) handleEnter() tea.Cmd {
+	if m.focus < fieldCount-1 {
+		return m.setFocus(m.focus + 1)
+	}
+	if m.signed != nil {
+		return m.broadcast()
+	}
+	if m.keys != nil {
+		m.sign()
+		return nil
+	}
+	m.err = ""
+	params, err := m.ctx.Config.ChainParams()
+	if err != nil {
+		m.err = err.Error()
+		return nil
+	}
+	start, err := strconv.ParseInt(strings.TrimSpace(m.inputs[fieldStart].Value()), 10, 32)
+	if err != nil || start < 0 {
+		m.err = "Start height must be a block height."
+		return nil
+	}
+	if _, err := m.feeRate(); err != nil {
+		m.err = err.Error()
+		return nil
+	}
+	keys, err := sweep.Parse(m.inputs[fieldKeys].Value(), params)
+	if err != nil {
+		m.err = err.Error()
+		return nil
+	}
+	m.keys, m.scanning = keys, true
+	addresses := sweep.Addresses(keys)
+	return func() tea.Msg {
+		utxos, _, err := neutrino.NewBalance(m.ctx.ChainService).ScanScripts(addresses, start)
+		return scannedMsg{utxos: utxos, err: err}
+	}
+}
+
+// sign signs the sweep of the coins found for review.
+func (m *state) sign() {
+	m.err = ""
+	feeRate, err := m.feeRate()
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	w, err := m.ctx.ActiveWallet()
+	if err != nil {
+		m.err = err.Error()
+		return
+	}
+	if m.signed, err = m.ctx.Payments.Sweep(w, m.keys, m.utxos, feeRate); err != nil {
+		m.err = err.Error()
+	}
+}
+
+// feeRate returns the fee rate in sat/vB, the default one when the field is empty.
+func (m *state) feeRate() (btcutil.Amount, error) {
+	text := strings.TrimSpace(m.inputs[fieldFeeRate].Value())
+	if text == "" {
+		return coinselect.DefaultFeeRate, nil
+	}
+	rate, err := strconv.ParseInt(text, 10, 64)
+	if err != nil || rate < 1 {
+		return 0, fmt.Errorf("fee rate must be a whole number of sat/vB, at least 1")
+	}
+	return btcutil.Amount(rate), nil
+}
+
+func (m *state) broadcast() tea.Cmd {
+	w, err := m.ctx.ActiveWallet()
+	if err != nil {
+		m.err = err.Error()
+		return nil
+	}
+	m.sending, m.err = true, ""
+	signed, label := m.signed, strings.TrimSpace(m.inputs[fieldLabel].Value())
+	return func() tea.Msg {
+		if err := m.ctx.Payments.BroadcastSweep(w, signed, label); err != nil {
+			return sentMsg{err: err}
+		}
+		return sentMsg{txid: signed.Tx.TxHash().String()}
+	}
+}
+
+func (m *state) View() string {
+	v := framework.View()
+	v.L("Sweep paper wallet").L("")
+	for _, in := range m.inputs {
+		v.L(in.View())
+	}
+	v.L("")
+	if m.scanning {
+		v.Warn("Scanning compact filters from block %s...", strings.TrimSpace(m.inputs[fieldStart].Value()))
+	}
+	if m.keys != nil && !m.scanning {
+		v.L("Found %d coins:", len(m.utxos))
+		for _, u := range m.utxos {
+			v.L("  %s BTC  %s  block %d", bip21.FormatAmount(u.Value), u.Address, u.Height)
+		}
+	}
+	if m.signed != nil {
+		v.L("")
+		v.L("Sweep %s BTC to %s", color.New(color.Bold).Sprint(bip21.FormatAmount(m.signed.Amount)), m.signed.To)
+		v.L("Fee %d sats", int64(m.signed.Fee))
+		if m.sending {
+			v.Warn("Broadcasting...")
+		} else {
+			v.L(color.New(color.FgHiCyan).Sprint("Press ENTER to broadcast."))
+		}
+	}
+	if m.sent != "" {
+		v.L(color.New(color.FgGreen).Sprint("✓ Swept")).L("Transaction: %s", m.sent).
+			L("The coins show up in the wallet once it confirms and the balance is scanned again.")
+	}
+	return v.Err(m.err).
+		Help("The keys are never stored. Anyone who saw the paper wallet can still spend what is\nsent to it later. TAB to move between fields, ENTER to continue.").
+		QuitHint().
+		Build()
+}
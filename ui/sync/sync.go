@@ -1,6 +1,7 @@
 package sync
 
 import (
+	"fmt"
 	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
@@ -19,7 +20,9 @@ type state struct {
 	timestamp  time.Time
 	peers      int
 	isComplete bool
-	balance    *balance.State
+	// scanned is the height of the last balance scan.
+	scanned int32
+	balance *balance.State
 }
 
 func New(ctx *framework.AppContext, params interface{}) framework.Page {
@@ -66,9 +69,11 @@ func (s *state) handleTick() tea.Cmd {
 	s.peers = block.Peers
 	s.height = block.Height
 	s.timestamp = block.Timestamp
-	if s.isSynced() {
-		s.isComplete = true
-		return s.balance.StartScan()
+	// Once synced the page keeps ticking to show the transactions relayed by peers, and
+	// scans again when a block may have confirmed some of them.
+	if s.isSynced() && s.scanned < s.height {
+		s.isComplete, s.scanned = true, s.height
+		return tea.Batch(s.balance.StartScan(), s.tick())
 	}
 	return s.tick()
 }
@@ -86,13 +91,67 @@ func (s *state) tick() tea.Cmd {
 	return tea.Tick(time.Second, func(t time.Time) tea.Msg { return tickMsg(t) })
 }
 
+func (s *state) proxyView(v *framework.ViewBuilder) {
+	proxy := s.ctx.ChainService.Proxy()
+	if !proxy.Enabled() {
+		v.L("Proxy: not used, connecting over clearnet")
+		return
+	}
+	mode := "with clearnet fallback"
+	if proxy.Only {
+		mode = "proxy only"
+	}
+	if proxy.StreamIsolation {
+		mode += ", stream isolation"
+	}
+	v.L("Proxy: %s (%s)", proxy.Address, mode)
+	if proxy.Fallbacks > 0 {
+		v.Warn("%d connections or lookups bypassed the proxy", proxy.Fallbacks)
+	}
+}
+
+func (s *state) filterHeaderView(v *framework.ViewBuilder) {
+	if s.ctx.Config.HeaderQuorum() == 0 {
+		v.L("Filter headers: cross-peer check disabled")
+		return
+	}
+	check, disagreements := s.ctx.ChainService.FilterHeaderStatus()
+	switch {
+	case check == nil:
+		v.L("Filter headers: not verified yet")
+	case check.Trusted():
+		v.L("Filter headers: height %d confirmed by %d of %d peers",
+			check.Height, len(check.Agreed), len(check.Agreed)+len(check.Disagreed)+len(check.Unanswered))
+	default:
+		v.Warn("Filter headers: height %d not trusted, %s", check.Height, check.Problem())
+	}
+	for _, d := range disagreements {
+		var outcome string
+		switch {
+		case !d.Settled:
+			outcome = "unsettled: " + d.Reason
+		case d.OursWrong:
+			outcome = fmt.Sprintf("block %d proved our filter wrong", d.Diverged)
+		case d.Banned:
+			outcome = fmt.Sprintf("block %d proved its filter wrong, banned", d.Diverged)
+		case d.TheirsWrong:
+			outcome = fmt.Sprintf("block %d proved its filter wrong", d.Diverged)
+		default:
+			outcome = fmt.Sprintf("both filters of block %d match it", d.Diverged)
+		}
+		v.Warn("  %s served filter header %s at height %d: %s", d.Peer, d.Theirs, d.Height, outcome)
+	}
+}
+
 func (s *state) View() string {
 	v := framework.View().
 		L(color.New(color.FgHiBlue).Sprintf("Blockchain Sync")).
 		L("Height: %d", s.height).
 		L("Peers: %d", s.peers).
-		L("Last block: %s", s.timestamp.Local()).
-		L("")
+		L("Last block: %s", s.timestamp.Local())
+	s.proxyView(v)
+	s.filterHeaderView(v)
+	v.L("")
 	if s.isComplete {
 		v.L(color.New(color.FgGreen).Sprintf("✓ Synced")).
 			L(s.balance.View()).
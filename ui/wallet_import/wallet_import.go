@@ -1,29 +1,28 @@
 package wallet_import
 
 import (
+	"fmt"
+
 	"github.com/charmbracelet/bubbles/textinput"
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/satelliondao/satellion/mnemonic"
 	"github.com/satelliondao/satellion/ui/framework"
 	"github.com/satelliondao/satellion/ui/router"
 )
 
 type state struct {
-	ctx                 *framework.AppContext
-	nameInput           textinput.Model
-	mnemonicInput       textinput.Model
-	passphraseInput     textinput.Model
-	nameCompleted       bool
-	mnemonicCompleted   bool
-	passphraseCompleted bool
-	err                 string
+	ctx           *framework.AppContext
+	nameInput     textinput.Model
+	mnemonicInput textinput.Model
+	nameCompleted bool
+	err           string
 }
 
 func New(ctx *framework.AppContext, params interface{}) framework.Page {
 	return &state{
-		ctx:             ctx,
-		nameInput:       nameInput(),
-		mnemonicInput:   mnemonicInput(),
-		passphraseInput: passphraseInput("Enter a passphrase (optional)"),
+		ctx:           ctx,
+		nameInput:     nameInput(),
+		mnemonicInput: mnemonicInput(),
 	}
 }
 
@@ -47,10 +46,8 @@ func (m state) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 
 		if !m.nameCompleted {
 			m.nameInput, cmd = m.nameInput.Update(msg)
-		} else if !m.mnemonicCompleted {
+		} else {
 			m.mnemonicInput, cmd = m.mnemonicInput.Update(msg)
-		} else if !m.passphraseCompleted {
-			m.passphraseInput, cmd = m.passphraseInput.Update(msg)
 		}
 		return m, cmd
 	}
@@ -69,27 +66,17 @@ func (m state) handleEnter() (tea.Model, tea.Cmd) {
 		return m, nil
 	}
 
-	if !m.mnemonicCompleted {
-		if m.mnemonicInput.Value() == "" {
-			m.err = "Mnemonic cannot be empty"
-			return m, nil
-		}
-		m.mnemonicCompleted = true
-		m.passphraseInput.Focus()
+	if m.mnemonicInput.Value() == "" {
+		m.err = "Mnemonic cannot be empty"
 		return m, nil
 	}
-
-	if !m.passphraseCompleted {
-		if err := m.ctx.WalletService.ImportWallet(m.nameInput.Value(), m.mnemonicInput.Value(), m.passphraseInput.Value()); err != nil {
-			m.err = err.Error()
-			return m, nil
-		}
-
-		m.ctx.Passphrase = m.passphraseInput.Value()
-		return m, router.Home()
+	validator := mnemonic.NewValidator()
+	if err := validator.Validate(m.mnemonicInput.Value()); err != nil {
+		m.err = fmt.Sprintf("Invalid mnemonic: %v", err)
+		return m, nil
 	}
-
-	return m, nil
+	words := mnemonic.New(validator.Normalize(m.mnemonicInput.Value()))
+	return m, router.Passphrase(m.nameInput.Value(), &words)
 }
 
 func (m state) View() string {
@@ -99,17 +86,11 @@ func (m state) View() string {
 		v.L("Import existing wallet").
 			L("Enter wallet name:").
 			L(m.nameInput.View())
-	} else if !m.mnemonicCompleted {
+	} else {
 		v.L("Import wallet").
 			L("Wallet name: %s", m.nameInput.Value()).
 			L("Enter your 12-word mnemonic phrase:").
 			L(m.mnemonicInput.View())
-	} else if !m.passphraseCompleted {
-		v.L("Import wallet").
-			L("Wallet name: %s", m.nameInput.Value()).
-			L("Mnemonic: %s", m.mnemonicInput.Value()).
-			L("Enter passphrase:").
-			L(m.passphraseInput.View())
 	}
 
 	if m.err != "" {
@@ -135,13 +116,3 @@ func mnemonicInput() textinput.Model {
 	i.Width = 50
 	return i
 }
-
-func passphraseInput(placeholder string) textinput.Model {
-	i := textinput.New()
-	i.Placeholder = placeholder
-	i.EchoMode = textinput.EchoPassword
-	i.EchoCharacter = '•'
-	i.CharLimit = 128
-	i.Width = 24
-	return i
-}
@@ -2,6 +2,7 @@ package wallet_list
 
 import (
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/satelliondao/satellion/secret"
 	"github.com/satelliondao/satellion/ui/framework"
 	"github.com/satelliondao/satellion/ui/router"
 	"github.com/satelliondao/satellion/wallet"
@@ -46,13 +47,21 @@ func (m *state) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 func (m *state) View() string {
 	v := framework.View()
 
+	password := m.ctx.Password()
+	defer secret.Wipe(password)
 	for i, w := range m.wallets {
-		mn, err := m.ctx.WalletRepo.Get(w.Name, "")
-		mnemonicText := "<not found>"
-		if err == nil {
+		mnemonicText := "<locked>"
+		if w.WatchOnly() {
+			mnemonicText = "<watch-only, keys on the hardware signer>"
+		} else if mn, err := m.ctx.WalletRepo.Get(w.Name, password); err == nil {
 			mnemonicText = mn.Mnemonic.String()
+			mn.Wipe()
 		}
-		v.L("%d. %s\n   %s\n", i+1, w.Name, mnemonicText)
+		fingerprint := "unknown until a password is set"
+		if w.Sealed || w.WatchOnly() {
+			fingerprint = w.Fingerprint
+		}
+		v.L("%d. %s [%s]\n   %s\n", i+1, w.Name, fingerprint, mnemonicText)
 	}
 
 	if len(m.wallets) == 0 {
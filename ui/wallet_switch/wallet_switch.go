@@ -63,6 +63,7 @@ func (m *state) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.err = err.Error()
 				return m, nil
 			}
+			m.ctx.Lock()
 			return m, router.UnlockWallet()
 		}
 	}
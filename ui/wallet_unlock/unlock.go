@@ -26,7 +26,7 @@ var choices = []framework.Choice{
 func New(ctx *framework.AppContext, params interface{}) framework.Page {
 	return &state{
 		ctx:      ctx,
-		input:    passphrase.PassphraseInput("Enter your passphrase"),
+		input:    passphrase.PassphraseInput("Enter your password"),
 		selector: framework.NewChoiceSelector(choices),
 	}
 }
@@ -57,7 +57,11 @@ func (m *state) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 							m.err = err.Error()
 							return m, nil
 						}
-						m.ctx.Passphrase = pass
+						m.input.Reset()
+						m.ctx.Unlock(pass)
+						if w, err := m.ctx.ActiveWallet(); err == nil && !w.Sealed && !w.WatchOnly() {
+							return m, router.SetPassword(pass)
+						}
 						return m, router.Home()
 					case "switch":
 						m.input.SetValue("")
@@ -83,7 +87,7 @@ func (m *state) View() string {
 	if err != nil {
 		v.L("No active wallet found\n")
 	} else {
-		v.L("🔒 Wallet Unlock: enter passphrase to unlock wallet %s\n", color.New(color.Bold).Sprintf("%s", name))
+		v.L("🔒 Wallet Unlock: enter password to unlock wallet %s\n", color.New(color.Bold).Sprintf("%s", name))
 	}
 	v.L(m.input.View())
 	v.Err(m.err)
@@ -21,14 +21,15 @@ func NewAddress(
 	pubKey *btcec.PublicKey,
 	change bool,
 	deriviationIndex uint32,
+	params *chaincfg.Params,
 ) *Address {
 	// Compute the taproot output key (tweaked public key)
 	outputKey, err := computeTaprootOutputKey(pubKey)
 	if err != nil {
 		panic(fmt.Errorf("failed to compute taproot output key: %w", err))
 	}
-	// This produces addresses starting with "bc1p" for mainnet
-	taprootAddr, err := btcutil.NewAddressTaproot(outputKey, &chaincfg.MainNetParams)
+	// This produces addresses starting with "bc1p" for mainnet, "tb1p" for testnet and signet
+	taprootAddr, err := btcutil.NewAddressTaproot(outputKey, params)
 	if err != nil {
 		panic(fmt.Errorf("failed to create taproot address: %w", err))
 	}
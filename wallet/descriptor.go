@@ -0,0 +1,162 @@
+package wallet
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/btcsuite/btcd/btcutil/hdkeychain"
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// AddressPath returns the full derivation path of an address, e.g. m/86'/0'/0'/0/5.
+func AddressPath(change, index uint32) string {
+	return fmt.Sprintf("%s/%d/%d", AccountPath, change, index)
+}
+
+// AccountXpub returns the public BIP86 account key of the wallet.
+func (w *Wallet) AccountXpub() (*hdkeychain.ExtendedKey, error) {
+	if w.WatchOnly() {
+		return w.AccountKey, nil
+	}
+	if w.RootKey == nil {
+		return nil, ErrLocked
+	}
+	key := w.RootKey
+	for _, step := range []uint32{86, 0, 0} {
+		child, err := key.Derive(hdkeychain.HardenedKeyStart + step)
+		if key != w.RootKey {
+			key.Zero()
+		}
+		if err != nil {
+			return nil, err
+		}
+		key = child
+	}
+	defer key.Zero()
+	pub, err := key.Neuter()
+	if err != nil {
+		return nil, err
+	}
+	// Neuter shares the chain code with the private key, which is zeroed on return.
+	return hdkeychain.NewKeyFromString(pub.String())
+}
+
+// AccountID returns the hex SHA-256 of the account key xpub. It identifies a wallet as
+// surely as the xpub does, without revealing its addresses.
+func AccountID(xpub *hdkeychain.ExtendedKey) string {
+	sum := sha256.Sum256([]byte(xpub.String()))
+	return hex.EncodeToString(sum[:])
+}
+
+// Descriptor returns the checksummed output descriptor of the receive (0) or change (1)
+// addresses of the wallet, e.g. tr([73c5da0a/86'/0'/0']xpub.../0/*)#checksum.
+func (w *Wallet) Descriptor(change uint32) (string, error) {
+	xpub, err := w.AccountXpub()
+	if err != nil {
+		return "", err
+	}
+	desc := fmt.Sprintf("tr([%s%s]%s/%d/*)", w.Fingerprint, strings.TrimPrefix(AccountPath, "m"), xpub.String(), change)
+	return desc + "#" + DescriptorChecksum(desc), nil
+}
+
+// Descriptor is a BIP86 taproot descriptor as written by Wallet.Descriptor.
+type Descriptor struct {
+	Fingerprint string
+	AccountKey  *hdkeychain.ExtendedKey
+	Change      uint32
+	// Params is the network the addresses are encoded for.
+	Params *chaincfg.Params
+}
+
+// ParseDescriptor parses a descriptor written by Wallet.Descriptor. The checksum is
+// verified when present. Hardened steps may be written with ' or h. The key must be for
+// the network of params.
+func ParseDescriptor(s string, params *chaincfg.Params) (*Descriptor, error) {
+	s = strings.TrimSpace(s)
+	if i := strings.LastIndexByte(s, '#'); i >= 0 {
+		if want := DescriptorChecksum(s[:i]); s[i+1:] != want {
+			return nil, fmt.Errorf("descriptor checksum %q does not match, expected %q", s[i+1:], want)
+		}
+		s = s[:i]
+	}
+	if !strings.HasPrefix(s, "tr([") || !strings.HasSuffix(s, "/*)") {
+		return nil, fmt.Errorf("expected a descriptor of the form tr([fingerprint%s]xpub/0/*)", strings.TrimPrefix(AccountPath, "m"))
+	}
+	body := strings.TrimSuffix(strings.TrimPrefix(s, "tr(["), "/*)")
+	end := strings.IndexByte(body, ']')
+	if end < 0 {
+		return nil, fmt.Errorf("descriptor key origin is not closed")
+	}
+	origin, key := body[:end], body[end+1:]
+	fingerprint, path, _ := strings.Cut(origin, "/")
+	if "m/"+strings.ReplaceAll(path, "h", "'") != AccountPath {
+		return nil, fmt.Errorf("descriptor path m/%s is not the BIP86 account %s", path, AccountPath)
+	}
+	xpub, change, ok := strings.Cut(key, "/")
+	if !ok || (change != "0" && change != "1") {
+		return nil, fmt.Errorf("descriptor must end in /0/* or /1/*")
+	}
+	w, err := NewWatchOnly(xpub, fingerprint, params)
+	if err != nil {
+		return nil, err
+	}
+	d := &Descriptor{Fingerprint: fingerprint, AccountKey: w.AccountKey, Params: params}
+	if change == "1" {
+		d.Change = 1
+	}
+	return d, nil
+}
+
+// Address derives the address at index.
+func (d *Descriptor) Address(index uint32) (*Address, error) {
+	w := &Wallet{AccountKey: d.AccountKey, Fingerprint: d.Fingerprint, Params: d.Params}
+	return w.DeriveTaprootAddress(d.Change, index)
+}
+
+const (
+	descInputCharset    = "0123456789()[],'/*abcdefgh@:$%{}IJKLMNOPQRSTUVWXYZ&+-.;<=>?!^_|~ijklmnopqrstuvwxyzABCDEFGH`#\"\\ "
+	descChecksumCharset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+)
+
+// DescriptorChecksum computes the BIP380 checksum of desc.
+func DescriptorChecksum(desc string) string {
+	c := uint64(1)
+	cls, clsCount := 0, 0
+	for _, ch := range desc {
+		pos := strings.IndexRune(descInputCharset, ch)
+		if pos < 0 {
+			return ""
+		}
+		c = descPolymod(c, pos&31)
+		cls = cls*3 + pos>>5
+		if clsCount++; clsCount == 3 {
+			c = descPolymod(c, cls)
+			cls, clsCount = 0, 0
+		}
+	}
+	if clsCount > 0 {
+		c = descPolymod(c, cls)
+	}
+	for i := 0; i < 8; i++ {
+		c = descPolymod(c, 0)
+	}
+	c ^= 1
+	out := make([]byte, 8)
+	for j := range out {
+		out[j] = descChecksumCharset[(c>>(5*(7-j)))&31]
+	}
+	return string(out)
+}
+
+func descPolymod(c uint64, val int) uint64 {
+	c0 := c >> 35
+	c = ((c & 0x7ffffffff) << 5) ^ uint64(val)
+	for i, g := range []uint64{0xf5dee51989, 0xa9fdca3312, 0x1bab10e32d, 0x3706b1677a, 0x644d626ffd} {
+		if c0>>i&1 == 1 {
+			c ^= g
+		}
+	}
+	return c
+}
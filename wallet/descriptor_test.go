@@ -0,0 +1,48 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/satelliondao/satellion/mnemonic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDescriptorChecksum(t *testing.T) {
+	// Example from BIP380.
+	assert.Equal(t, "89f8spxm", DescriptorChecksum("raw(deadbeef)"))
+}
+
+func TestDescriptor_RoundTrip(t *testing.T) {
+	m := mnemonic.New([]string{
+		"abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "about",
+	})
+	w := New(&m, "", "", &chaincfg.MainNetParams)
+	desc, err := w.Descriptor(0)
+	require.NoError(t, err)
+	assert.Contains(t, desc, "tr([73c5da0a/86'/0'/0']xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ/0/*)#")
+
+	d, err := ParseDescriptor(desc, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	addr, err := d.Address(0)
+	require.NoError(t, err)
+	// BIP86 test vector for m/86'/0'/0'/0/0.
+	assert.Equal(t, "bc1p5cyxnuxmeuwuvkwfem96lqzszd02n6xdcjrs20cac6yqjjwudpxqkedrcr", addr.Address.String())
+
+	change, err := w.Descriptor(1)
+	require.NoError(t, err)
+	d, err = ParseDescriptor(change, &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	addr, err = d.Address(0)
+	require.NoError(t, err)
+	assert.Equal(t, "bc1p3qkhfews2uk44qtvauqyr2ttdsw7svhkl9nkm9s9c3x4ax5h60wqwruhk7", addr.Address.String())
+
+	_, err = ParseDescriptor(desc[:len(desc)-1]+"x", &chaincfg.MainNetParams)
+	assert.Error(t, err, "bad checksum")
+	_, err = ParseDescriptor("tr([73c5da0a/84h/0h/0h]xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ/0/*)", &chaincfg.MainNetParams)
+	assert.Error(t, err, "wrong purpose")
+	_, err = ParseDescriptor("tr([73c5da0a/86h/0h/0h]xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ/0/*)", &chaincfg.MainNetParams)
+	assert.NoError(t, err, "h is accepted for hardened steps and the checksum is optional")
+	assert.Equal(t, "m/86'/0'/0'/1/7", AddressPath(1, 7))
+}
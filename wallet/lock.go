@@ -0,0 +1,104 @@
+package wallet
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+)
+
+// KDFParams are the argon2id cost parameters of a lock.
+type KDFParams struct {
+	Time      uint32 `json:"time"`
+	MemoryKiB uint32 `json:"memory_kib"`
+	Threads   uint8  `json:"threads"`
+}
+
+// DefaultKDFParams follow the second recommended option of RFC 9106.
+var DefaultKDFParams = KDFParams{Time: 3, MemoryKiB: 64 * 1024, Threads: 4}
+
+// LockParams are used for every new lock. They may be tuned from the config at startup.
+var LockParams = DefaultKDFParams
+
+const (
+	lockSaltSize = 16
+	lockKeySize  = 32
+)
+
+// NewLock returns an argon2id verifier for seed, encoded in the PHC string format.
+// The seed is hashed first so that locks can also be derived from legacy ones.
+func NewLock(seed []byte, params KDFParams) (string, error) {
+	h := sha256.Sum256(seed)
+	return lockFromSeedHash(h[:], params)
+}
+
+// UpgradeLegacyLock converts a legacy hex(sha256(seed)) lock into an argon2id verifier.
+func UpgradeLegacyLock(legacy string, params KDFParams) (string, error) {
+	h, err := hex.DecodeString(legacy)
+	if err != nil || len(h) != sha256.Size {
+		return "", fmt.Errorf("invalid legacy lock")
+	}
+	return lockFromSeedHash(h, params)
+}
+
+// IsLegacyLock reports whether lock is an unsalted seed hash from before argon2id locks.
+func IsLegacyLock(lock string) bool {
+	return !strings.HasPrefix(lock, "$argon2id$")
+}
+
+// VerifyLock reports whether seed matches lock.
+func VerifyLock(lock string, seed []byte) (bool, error) {
+	h := sha256.Sum256(seed)
+	if IsLegacyLock(lock) {
+		return subtle.ConstantTimeCompare([]byte(lock), []byte(hex.EncodeToString(h[:]))) == 1, nil
+	}
+	params, salt, key, err := parseLock(lock)
+	if err != nil {
+		return false, err
+	}
+	got := argon2.IDKey(h[:], salt, params.Time, params.MemoryKiB, params.Threads, uint32(len(key)))
+	return subtle.ConstantTimeCompare(got, key) == 1, nil
+}
+
+func lockFromSeedHash(h []byte, params KDFParams) (string, error) {
+	if params.Time == 0 || params.MemoryKiB == 0 || params.Threads == 0 {
+		return "", fmt.Errorf("invalid lock parameters %+v", params)
+	}
+	salt := make([]byte, lockSaltSize)
+	if _, err := rand.Read(salt); err != nil {
+		return "", err
+	}
+	key := argon2.IDKey(h, salt, params.Time, params.MemoryKiB, params.Threads, lockKeySize)
+	return fmt.Sprintf("$argon2id$v=%d$m=%d,t=%d,p=%d$%s$%s", argon2.Version,
+		params.MemoryKiB, params.Time, params.Threads,
+		base64.RawStdEncoding.EncodeToString(salt), base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+func parseLock(lock string) (KDFParams, []byte, []byte, error) {
+	var params KDFParams
+	parts := strings.Split(lock, "$")
+	if len(parts) != 6 || parts[1] != "argon2id" {
+		return params, nil, nil, fmt.Errorf("invalid lock format")
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return params, nil, nil, fmt.Errorf("unsupported argon2 version %q", parts[2])
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &params.MemoryKiB, &params.Time, &params.Threads); err != nil {
+		return params, nil, nil, fmt.Errorf("invalid lock parameters: %w", err)
+	}
+	salt, err := base64.RawStdEncoding.DecodeString(parts[4])
+	if err != nil {
+		return params, nil, nil, fmt.Errorf("invalid lock salt: %w", err)
+	}
+	key, err := base64.RawStdEncoding.DecodeString(parts[5])
+	if err != nil || len(key) == 0 {
+		return params, nil, nil, fmt.Errorf("invalid lock key")
+	}
+	return params, salt, key, nil
+}
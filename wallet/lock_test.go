@@ -0,0 +1,58 @@
+package wallet
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testLockParams = KDFParams{Time: 1, MemoryKiB: 1024, Threads: 1}
+
+func TestLock_Verify(t *testing.T) {
+	seed := []byte("seed")
+	lock, err := NewLock(seed, testLockParams)
+	require.NoError(t, err)
+	assert.False(t, IsLegacyLock(lock))
+	h := sha256.Sum256(seed)
+	assert.NotContains(t, lock, hex.EncodeToString(h[:]), "locks must not reveal the seed hash")
+
+	ok, err := VerifyLock(lock, seed)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	ok, err = VerifyLock(lock, []byte("other"))
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	again, err := NewLock(seed, testLockParams)
+	require.NoError(t, err)
+	assert.NotEqual(t, lock, again, "locks are salted")
+}
+
+func TestLock_UpgradeLegacy(t *testing.T) {
+	seed := []byte("seed")
+	h := sha256.Sum256(seed)
+	legacy := hex.EncodeToString(h[:])
+	require.True(t, IsLegacyLock(legacy))
+	ok, err := VerifyLock(legacy, seed)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	upgraded, err := UpgradeLegacyLock(legacy, testLockParams)
+	require.NoError(t, err)
+	ok, err = VerifyLock(upgraded, seed)
+	require.NoError(t, err)
+	assert.True(t, ok)
+
+	_, err = UpgradeLegacyLock("not-hex", testLockParams)
+	assert.Error(t, err)
+}
+
+func TestLock_RejectsMalformed(t *testing.T) {
+	_, err := VerifyLock("$argon2id$v=19$m=1024,t=1,p=1$!!$!!", []byte("seed"))
+	assert.Error(t, err)
+	_, err = NewLock([]byte("seed"), KDFParams{})
+	assert.Error(t, err)
+}
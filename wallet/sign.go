@@ -0,0 +1,49 @@
+package wallet
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ErrWatchOnly is returned when a watch-only wallet is asked to sign. Its keys live on
+// an external signer.
+var ErrWatchOnly = errors.New("watch-only wallets sign with their external signer")
+
+// SignTransaction signs every input of tx with a BIP86 key path signature. The inputs
+// of tx spend utxos, in the same order.
+func (w *Wallet) SignTransaction(tx *wire.MsgTx, utxos []Utxo) error {
+	if w.WatchOnly() {
+		return ErrWatchOnly
+	}
+	if len(tx.TxIn) != len(utxos) {
+		return fmt.Errorf("transaction has %d inputs, %d outputs given", len(tx.TxIn), len(utxos))
+	}
+	prevOuts := txscript.NewMultiPrevOutFetcher(nil)
+	for i, u := range utxos {
+		if tx.TxIn[i].PreviousOutPoint != u.OutPoint {
+			return fmt.Errorf("input %d spends %s, not %s", i, tx.TxIn[i].PreviousOutPoint, u.OutPoint)
+		}
+		prevOuts.AddPrevOut(u.OutPoint, wire.NewTxOut(int64(u.Value), u.PkScript))
+	}
+	hashes := txscript.NewTxSigHashes(tx, prevOuts)
+	for i, u := range utxos {
+		change := uint32(0)
+		if u.Change {
+			change = 1
+		}
+		_, key, err := w.deriveReceiveKeyPair(change, u.Index)
+		if err != nil {
+			return fmt.Errorf("failed to derive key of %s: %w", u.Path(), err)
+		}
+		witness, err := txscript.TaprootWitnessSignature(tx, hashes, i, int64(u.Value), u.PkScript, txscript.SigHashDefault, key)
+		key.Zero()
+		if err != nil {
+			return fmt.Errorf("failed to sign input %d: %w", i, err)
+		}
+		tx.TxIn[i].Witness = witness
+	}
+	return nil
+}
@@ -0,0 +1,49 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satelliondao/satellion/mnemonic"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignTransaction(t *testing.T) {
+	m := mnemonic.New([]string{
+		"abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "about",
+	})
+	w := New(&m, "", "", &chaincfg.MainNetParams)
+	var utxos []Utxo
+	tx := wire.NewMsgTx(2)
+	for i, change := range []uint32{0, 1} {
+		addr, err := w.DeriveTaprootAddress(change, uint32(i+2))
+		require.NoError(t, err)
+		script, err := addr.DeriveTaprootScriptPubKey()
+		require.NoError(t, err)
+		u := Utxo{OutPoint: wire.OutPoint{Index: uint32(i)}, Value: btcutil.Amount(10_000 * (i + 1)), PkScript: script, Change: change == 1, Index: uint32(i + 2)}
+		utxos = append(utxos, u)
+		tx.AddTxIn(wire.NewTxIn(&u.OutPoint, nil, nil))
+	}
+	tx.AddTxOut(wire.NewTxOut(25_000, utxos[0].PkScript))
+	require.NoError(t, w.SignTransaction(tx, utxos))
+
+	prevOuts := txscript.NewMultiPrevOutFetcher(nil)
+	for _, u := range utxos {
+		prevOuts.AddPrevOut(u.OutPoint, wire.NewTxOut(int64(u.Value), u.PkScript))
+	}
+	hashes := txscript.NewTxSigHashes(tx, prevOuts)
+	for i, u := range utxos {
+		vm, err := txscript.NewEngine(u.PkScript, tx, i, txscript.StandardVerifyFlags, nil, hashes, int64(u.Value), prevOuts)
+		require.NoError(t, err)
+		assert.NoError(t, vm.Execute(), "input %d", i)
+	}
+
+	assert.Error(t, w.SignTransaction(tx, utxos[:1]))
+	watchOnly, err := NewWatchOnly("xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ", "73c5da0a", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	assert.ErrorIs(t, watchOnly.SignTransaction(tx, utxos), ErrWatchOnly)
+}
@@ -0,0 +1,40 @@
+package wallet
+
+import (
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Utxo is an unspent output paying one of the wallet addresses.
+type Utxo struct {
+	OutPoint wire.OutPoint  `json:"outpoint"`
+	Value    btcutil.Amount `json:"value"`
+	PkScript []byte         `json:"pk_script"`
+	Address  string         `json:"address"`
+	Change   bool           `json:"change"`
+	Index    uint32         `json:"index"`
+	// Height is the height of the block confirming the output.
+	Height int32 `json:"height"`
+}
+
+// Ref returns the outpoint of u as txid:vout, the reference of output labels in BIP329.
+func (u Utxo) Ref() string {
+	return u.OutPoint.String()
+}
+
+// Path returns the derivation path of the key spending u.
+func (u Utxo) Path() string {
+	change := uint32(0)
+	if u.Change {
+		change = 1
+	}
+	return AddressPath(change, u.Index)
+}
+
+// Confirmations returns the confirmations of u with tip as the best block height.
+func (u Utxo) Confirmations(tip int32) int32 {
+	if tip < u.Height {
+		return 0
+	}
+	return tip - u.Height + 1
+}
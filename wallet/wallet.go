@@ -1,50 +1,128 @@
 package wallet
 
 import (
-	"crypto/sha256"
 	"encoding/hex"
+	"errors"
 	"fmt"
 	"time"
 
 	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcutil"
 	"github.com/btcsuite/btcd/btcutil/hdkeychain"
 	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/satelliondao/satellion/mnemonic"
+	"github.com/satelliondao/satellion/secret"
 )
 
+// AccountPath is the BIP86 account all wallet addresses are derived from.
+const AccountPath = "m/86'/0'/0'"
+
+// GapLimit is how many addresses past the last one handed out are searched for payments.
+const GapLimit = 20
+
+// Wallet is an HD wallet. Wallets listed without their password are locked:
+// Mnemonic and RootKey are nil and only metadata is available. Watch-only wallets
+// have no seed at all, only the AccountKey of an external signer.
 type Wallet struct {
-	Mnemonic         *mnemonic.Mnemonic
-	RootKey          *hdkeychain.ExtendedKey
+	Mnemonic *mnemonic.Mnemonic
+	RootKey  *hdkeychain.ExtendedKey
+	// AccountKey is the public BIP86 account key of a watch-only wallet.
+	AccountKey       *hdkeychain.ExtendedKey
 	NextChangeIndex  uint32
 	NextReceiveIndex uint32
 	Name             string
 	IsDefault        bool
-	Lock             string
-	CreatedAt        time.Time
+	// Lock is the verifier of wallets stored before seeds were encrypted with a password.
+	Lock string
+	// Fingerprint is the BIP32 fingerprint of the master key, which depends on the BIP39 passphrase.
+	Fingerprint string
+	// AccountID is the hash of the account key, see AccountID. Unlike the four byte
+	// fingerprint it tells wallets apart, and it is kept for locked wallets.
+	AccountID string
+	// Params is the network addresses and extended keys are encoded for.
+	Params *chaincfg.Params
+	// Sealed reports whether the mnemonic is stored encrypted with the wallet password.
+	Sealed    bool
+	CreatedAt time.Time
 }
 
 func New(
 	mnemonic *mnemonic.Mnemonic,
 	passphrase string,
 	lock string,
+	params *chaincfg.Params,
 ) *Wallet {
 	seed := mnemonic.Seed(passphrase)
-	rootKey, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	defer secret.Wipe(seed)
+	rootKey, err := hdkeychain.NewMaster(seed, params)
 	if err != nil {
 		panic(fmt.Sprintf("failed to create root key: %v", err))
 	}
 	w := &Wallet{
-		RootKey:  rootKey,
-		Mnemonic: mnemonic,
-		Lock:     lock,
+		RootKey:     rootKey,
+		Mnemonic:    mnemonic,
+		Lock:        lock,
+		Fingerprint: fingerprint(rootKey),
+		Params:      params,
 	}
-	if lock == "" {
-		seedH := sha256.Sum256(seed)
-		w.Lock = hex.EncodeToString(seedH[:])
+	xpub, err := w.AccountXpub()
+	if err != nil {
+		panic(fmt.Sprintf("failed to derive account key: %v", err))
 	}
+	w.AccountID = AccountID(xpub)
 	return w
 }
 
+// NewWatchOnly returns a wallet that derives addresses from xpub, the BIP86 account key
+// exported by an external signer whose master key has the given fingerprint, for the
+// network of params.
+func NewWatchOnly(xpub, masterFingerprint string, params *chaincfg.Params) (*Wallet, error) {
+	key, err := hdkeychain.NewKeyFromString(xpub)
+	if err != nil {
+		return nil, fmt.Errorf("invalid xpub: %w", err)
+	}
+	if key.IsPrivate() {
+		return nil, fmt.Errorf("expected an xpub, got a private key")
+	}
+	if !key.IsForNet(params) {
+		return nil, fmt.Errorf("xpub is not for %s", params.Name)
+	}
+	if key.Depth() != 3 {
+		return nil, fmt.Errorf("xpub is at depth %d, expected the %s account key", key.Depth(), AccountPath)
+	}
+	if fp, err := hex.DecodeString(masterFingerprint); err != nil || len(fp) != 4 {
+		return nil, fmt.Errorf("invalid master fingerprint %q", masterFingerprint)
+	}
+	return &Wallet{AccountKey: key, Fingerprint: masterFingerprint, AccountID: AccountID(key), Params: params}, nil
+}
+
+// WatchOnly reports whether the private keys of the wallet live on an external signer.
+func (w *Wallet) WatchOnly() bool {
+	return w.AccountKey != nil
+}
+
+// MasterFingerprint returns the master key fingerprint of mnemonic with the BIP39 passphrase,
+// so that users can check they typed the passphrase they meant to.
+func MasterFingerprint(mnemonic *mnemonic.Mnemonic, passphrase string) string {
+	seed := mnemonic.Seed(passphrase)
+	defer secret.Wipe(seed)
+	// The fingerprint hashes the master public key, which is the same on every network.
+	rootKey, err := hdkeychain.NewMaster(seed, &chaincfg.MainNetParams)
+	if err != nil {
+		return ""
+	}
+	defer rootKey.Zero()
+	return fingerprint(rootKey)
+}
+
+func fingerprint(rootKey *hdkeychain.ExtendedKey) string {
+	pub, err := rootKey.ECPubKey()
+	if err != nil {
+		return ""
+	}
+	return hex.EncodeToString(btcutil.Hash160(pub.SerializeCompressed())[:4])
+}
+
 func (w *Wallet) ReceiveAddress() (*Address, error) {
 	return w.DeriveTaprootAddress(0, w.NextReceiveIndex)
 }
@@ -64,28 +142,36 @@ func (w *Wallet) NewChangeAddress() (*Address, error) {
 }
 
 func (w *Wallet) deriveReceiveKeyPair(change, index uint32) (*btcec.PublicKey, *btcec.PrivateKey, error) {
+	if w.RootKey == nil {
+		return nil, nil, ErrLocked
+	}
 	purpose, err := w.RootKey.Derive(hdkeychain.HardenedKeyStart + 86)
 	if err != nil {
 		return nil, nil, err
 	}
+	defer purpose.Zero()
 	coin, err := purpose.Derive(hdkeychain.HardenedKeyStart)
 	if err != nil {
 		return nil, nil, err
 	}
+	defer coin.Zero()
 	account, err := coin.Derive(hdkeychain.HardenedKeyStart)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to derive account: %w", err)
 	}
+	defer account.Zero()
 	// Derive change level (0 for receive, 1 for change)
 	changePath, err := account.Derive(change)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to derive change path: %w", err)
 	}
+	defer changePath.Zero()
 	// Derive the final address key at the specified index
 	extendedKey, err := changePath.Derive(index)
 	if err != nil {
 		return nil, nil, fmt.Errorf("failed to derive address key: %w", err)
 	}
+	defer extendedKey.Zero()
 	// Step 6: Extract the public key from the derived extended key
 	pubKey, err := extendedKey.ECPubKey()
 	if err != nil {
@@ -100,11 +186,49 @@ func (w *Wallet) deriveReceiveKeyPair(change, index uint32) (*btcec.PublicKey, *
 
 // deriveTaprootAddress generates a BIP 86 taproot address
 // following BIP 86 derivation path: m/86'/0'/0'/change/index
-// Returns an Address struct with the bech32m-encoded taproot address (bc1p...)
+// Returns an Address struct with the bech32m-encoded taproot address of w.Params,
+// bc1p... on mainnet
 func (w *Wallet) DeriveTaprootAddress(change uint32, index uint32) (*Address, error) {
-	pubKey, _, err := w.deriveReceiveKeyPair(change, index)
+	if w.WatchOnly() {
+		pubKey, err := w.deriveWatchOnlyKey(change, index)
+		if err != nil {
+			return nil, fmt.Errorf("failed to derive public key: %w", err)
+		}
+		return NewAddress(pubKey, change == 1, index, w.Params), nil
+	}
+	pubKey, privKey, err := w.deriveReceiveKeyPair(change, index)
 	if err != nil {
 		return nil, fmt.Errorf("failed to derive receive key pair: %w", err)
 	}
-	return NewAddress(pubKey, change == 1, index), nil
+	privKey.Zero()
+	return NewAddress(pubKey, change == 1, index, w.Params), nil
+}
+
+// deriveWatchOnlyKey derives change/index from the account key of a watch-only wallet.
+func (w *Wallet) deriveWatchOnlyKey(change, index uint32) (*btcec.PublicKey, error) {
+	changeKey, err := w.AccountKey.Derive(change)
+	if err != nil {
+		return nil, err
+	}
+	key, err := changeKey.Derive(index)
+	if err != nil {
+		return nil, err
+	}
+	return key.ECPubKey()
+}
+
+// ErrLocked is returned when keys are derived from a locked or wiped wallet.
+var ErrLocked = errors.New("wallet is locked")
+
+// Wipe zeroes the private key material held by the wallet and drops its mnemonic.
+// The wallet cannot derive addresses afterwards.
+func (w *Wallet) Wipe() {
+	if w.RootKey != nil {
+		w.RootKey.Zero()
+		w.RootKey = nil
+	}
+	if w.Mnemonic != nil {
+		w.Mnemonic.Wipe()
+		w.Mnemonic = nil
+	}
 }
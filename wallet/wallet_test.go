@@ -2,10 +2,13 @@ package wallet
 
 import (
 	"fmt"
+	"strings"
 	"testing"
 
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/satelliondao/satellion/mnemonic"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestBIP86TaprootDerivation(t *testing.T) {
@@ -17,7 +20,7 @@ func TestBIP86TaprootDerivation(t *testing.T) {
 	name := "test-wallet"
 
 	t.Run("BIP86TestVectors", func(t *testing.T) {
-		wallet := New(testMnemonic, passphrase, name)
+		wallet := New(testMnemonic, passphrase, name, &chaincfg.MainNetParams)
 
 		assert.Equal(t, "xprv9s21ZrQH143K3GJpoapnV8SFfukcVBSfeCficPSGfubmSFDxo1kuHnLisriDvSnRRuL2Qrg5ggqHKNVpxR86QEC8w35uxmGoggxtQTPvfUu", wallet.RootKey.String())
 
@@ -41,7 +44,7 @@ func TestBIP86TaprootDerivation(t *testing.T) {
 	})
 
 	t.Run("IndexIncrement", func(t *testing.T) {
-		wallet := New(testMnemonic, passphrase, name)
+		wallet := New(testMnemonic, passphrase, name, &chaincfg.MainNetParams)
 		initialReceiveIndex := wallet.NextReceiveIndex
 		initialChangeIndex := wallet.NextChangeIndex
 
@@ -61,7 +64,7 @@ func TestBIP86TaprootDerivation(t *testing.T) {
 	})
 
 	t.Run("AddressDifferenciation", func(t *testing.T) {
-		wallet := New(testMnemonic, passphrase, name)
+		wallet := New(testMnemonic, passphrase, name, &chaincfg.MainNetParams)
 
 		addr1, err1 := wallet.ReceiveAddress()
 		assert.NoError(t, err1)
@@ -80,7 +83,7 @@ func TestBIP86TaprootDerivation(t *testing.T) {
 	})
 
 	t.Run("ScriptPubKeyGeneration", func(t *testing.T) {
-		wallet := New(testMnemonic, passphrase, name)
+		wallet := New(testMnemonic, passphrase, name, &chaincfg.MainNetParams)
 
 		address, err := wallet.ReceiveAddress()
 		assert.NoError(t, err)
@@ -105,3 +108,86 @@ func TestBIP86TaprootDerivation(t *testing.T) {
 		assert.Equal(t, expectedReceiveOutputKey, fmt.Sprintf("%x", actualReceiveOutputKey), "Should match BIP 86 test vector output key")
 	})
 }
+
+func TestChainParams(t *testing.T) {
+	m := &mnemonic.Mnemonic{Words: []string{
+		"abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "about",
+	}}
+	for _, tc := range []struct {
+		params *chaincfg.Params
+		prefix string
+	}{
+		{&chaincfg.TestNet3Params, "tb1p"},
+		{&chaincfg.SigNetParams, "tb1p"},
+		{&chaincfg.RegressionNetParams, "bcrt1p"},
+	} {
+		w := New(m, "", "", tc.params)
+		for _, change := range []uint32{0, 1} {
+			addr, err := w.DeriveTaprootAddress(change, 0)
+			require.NoError(t, err)
+			assert.True(t, strings.HasPrefix(addr.Address.String(), tc.prefix), addr.Address.String())
+			assert.True(t, addr.Address.IsForNet(tc.params))
+		}
+		xpub, err := w.AccountXpub()
+		require.NoError(t, err)
+		assert.True(t, xpub.IsForNet(tc.params), "descriptors carry a tpub off mainnet")
+		assert.Equal(t, "73c5da0a", w.Fingerprint, "the network does not change the keys")
+	}
+}
+
+func TestMasterFingerprint(t *testing.T) {
+	words := []string{
+		"abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "about",
+	}
+	m := &mnemonic.Mnemonic{Words: words}
+	assert.Equal(t, "73c5da0a", MasterFingerprint(m, ""))
+	assert.Equal(t, "73c5da0a", New(m, "", "", &chaincfg.MainNetParams).Fingerprint)
+	assert.NotEqual(t, "73c5da0a", MasterFingerprint(m, "TREZOR"), "the passphrase changes the master key")
+}
+
+func TestWipe(t *testing.T) {
+	m := mnemonic.New([]string{
+		"abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "about",
+	})
+	words := m.Words
+	w := New(&m, "", "", &chaincfg.MainNetParams)
+	w.Wipe()
+	assert.Nil(t, w.RootKey)
+	assert.Nil(t, w.Mnemonic)
+	for _, word := range words {
+		assert.Empty(t, word)
+	}
+	_, err := w.ReceiveAddress()
+	assert.Error(t, err)
+}
+
+func TestNewWatchOnly(t *testing.T) {
+	// BIP86 test vector account key of "abandon ... about".
+	xpub := "xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ"
+	w, err := NewWatchOnly(xpub, "73c5da0a", &chaincfg.MainNetParams)
+	assert.NoError(t, err)
+	assert.True(t, w.WatchOnly())
+
+	m := mnemonic.New([]string{
+		"abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "abandon", "about",
+	})
+	software := New(&m, "", "", &chaincfg.MainNetParams)
+	for _, change := range []uint32{0, 1} {
+		want, err := software.DeriveTaprootAddress(change, 3)
+		assert.NoError(t, err)
+		got, err := w.DeriveTaprootAddress(change, 3)
+		assert.NoError(t, err)
+		assert.Equal(t, want.Address.String(), got.Address.String())
+	}
+
+	_, err = NewWatchOnly(software.RootKey.String(), "73c5da0a", &chaincfg.MainNetParams)
+	assert.Error(t, err, "private keys are rejected")
+	master, err := software.RootKey.Neuter()
+	assert.NoError(t, err)
+	_, err = NewWatchOnly(master.String(), "73c5da0a", &chaincfg.MainNetParams)
+	assert.Error(t, err, "only account keys are accepted")
+	_, err = NewWatchOnly(xpub, "nope", &chaincfg.MainNetParams)
+	assert.Error(t, err)
+	_, err = NewWatchOnly(xpub, "73c5da0a", &chaincfg.TestNet3Params)
+	assert.Error(t, err, "mainnet keys are rejected on testnet")
+}
@@ -0,0 +1,97 @@
+package walletdb
+
+import (
+	"encoding/json"
+	"sort"
+	"time"
+
+	bdb "github.com/btcsuite/btcwallet/walletdb"
+)
+
+// broadcastsStoreKey is the top-level bucket of the rebroadcast queue, keyed by txid.
+var broadcastsStoreKey = []byte("broadcasts")
+
+// QueuedTx is a transaction broadcast again and again until it is seen in a block.
+type QueuedTx struct {
+	Txid string `json:"txid"`
+	// Raw is the transaction in hex.
+	Raw string `json:"raw"`
+	// Wallet is the name of the wallet that sent the transaction, empty for transactions
+	// given by the user.
+	Wallet   string `json:"wallet,omitempty"`
+	Attempts int    `json:"attempts"`
+	// Peers is the number of peers connected at the last attempt.
+	Peers       int       `json:"peers"`
+	LastAttempt time.Time `json:"last_attempt"`
+	NextAttempt time.Time `json:"next_attempt"`
+	LastError   string    `json:"last_error,omitempty"`
+	// Stopped tells why the transaction is no longer broadcast while unconfirmed, such as
+	// a rejection as invalid or its replacement.
+	Stopped   string    `json:"stopped,omitempty"`
+	StoppedAt time.Time `json:"stopped_at,omitempty"`
+	// ScannedHeight is the last block searched for the transaction, Height the block it
+	// was seen in.
+	ScannedHeight int32     `json:"scanned_height"`
+	Height        int32     `json:"height,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// Active reports whether the transaction is still broadcast.
+func (q QueuedTx) Active() bool {
+	return q.Height == 0 && q.Stopped == ""
+}
+
+// SaveQueued stores txs in the rebroadcast queue, replacing those of the same txids.
+func (s *WalletDB) SaveQueued(txs ...QueuedTx) error {
+	return s.db.Update(func(tx bdb.ReadWriteTx) error {
+		bucket, err := tx.CreateTopLevelBucket(broadcastsStoreKey)
+		if err != nil {
+			return err
+		}
+		for _, q := range txs {
+			raw, err := json.Marshal(q)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(q.Txid), raw); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func() {})
+}
+
+// Queue returns the rebroadcast queue, oldest first.
+func (s *WalletDB) Queue() ([]QueuedTx, error) {
+	var list []QueuedTx
+	err := s.db.View(func(tx bdb.ReadTx) error {
+		bucket := tx.ReadBucket(broadcastsStoreKey)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var q QueuedTx
+			if err := json.Unmarshal(v, &q); err != nil {
+				return err
+			}
+			list = append(list, q)
+			return nil
+		})
+	}, func() {})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list, nil
+}
+
+// RemoveQueued drops txid from the rebroadcast queue.
+func (s *WalletDB) RemoveQueued(txid string) error {
+	return s.db.Update(func(tx bdb.ReadWriteTx) error {
+		bucket := tx.ReadWriteBucket(broadcastsStoreKey)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.Delete([]byte(txid))
+	}, func() {})
+}
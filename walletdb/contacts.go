@@ -0,0 +1,128 @@
+package walletdb
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+	"strings"
+	"time"
+
+	bdb "github.com/btcsuite/btcwallet/walletdb"
+	"github.com/satelliondao/satellion/contacts"
+)
+
+var (
+	// contactsStoreKey is the top-level bucket of the address book, keyed by contact
+	// name. Contacts are shared by every wallet.
+	contactsStoreKey = []byte("contacts")
+	// paymentsStoreKey is the top-level bucket recording the addresses paid from any
+	// wallet, keyed by address.
+	paymentsStoreKey = []byte("payments")
+)
+
+var ErrContactNotFound = errors.New("contact not found")
+
+// Contacts returns the address book sorted by name.
+func (s *WalletDB) Contacts() ([]contacts.Contact, error) {
+	var list []contacts.Contact
+	err := s.db.View(func(tx bdb.ReadTx) error {
+		bucket := tx.ReadBucket(contactsStoreKey)
+		if bucket == nil {
+			return nil
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var c contacts.Contact
+			if err := json.Unmarshal(v, &c); err != nil {
+				return err
+			}
+			list = append(list, c)
+			return nil
+		})
+	}, func() {})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(list, func(i, j int) bool { return strings.ToLower(list[i].Name) < strings.ToLower(list[j].Name) })
+	return list, nil
+}
+
+// SaveContact stores c under its name, replacing the contact of the same name. The
+// caller validates c for the configured network.
+func (s *WalletDB) SaveContact(c contacts.Contact) error {
+	if c.CreatedAt.IsZero() {
+		c.CreatedAt = time.Now()
+	}
+	raw, err := json.Marshal(c)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx bdb.ReadWriteTx) error {
+		bucket, err := tx.CreateTopLevelBucket(contactsStoreKey)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(c.Name), raw)
+	}, func() {})
+}
+
+// DeleteContact removes the contact called name.
+func (s *WalletDB) DeleteContact(name string) error {
+	return s.db.Update(func(tx bdb.ReadWriteTx) error {
+		bucket := tx.ReadWriteBucket(contactsStoreKey)
+		if bucket == nil || bucket.Get([]byte(name)) == nil {
+			return ErrContactNotFound
+		}
+		return bucket.Delete([]byte(name))
+	}, func() {})
+}
+
+// Payment records the payments sent to an address.
+type Payment struct {
+	Count int       `json:"count"`
+	First time.Time `json:"first"`
+	Last  time.Time `json:"last"`
+}
+
+// RecordPayment notes that address was paid at.
+func (s *WalletDB) RecordPayment(address string, at time.Time) error {
+	return s.db.Update(func(tx bdb.ReadWriteTx) error {
+		bucket, err := tx.CreateTopLevelBucket(paymentsStoreKey)
+		if err != nil {
+			return err
+		}
+		var p Payment
+		if raw := bucket.Get([]byte(address)); len(raw) > 0 {
+			if err := json.Unmarshal(raw, &p); err != nil {
+				return err
+			}
+		}
+		if p.Count == 0 {
+			p.First = at
+		}
+		p.Count++
+		p.Last = at
+		raw, err := json.Marshal(p)
+		if err != nil {
+			return err
+		}
+		return bucket.Put([]byte(address), raw)
+	}, func() {})
+}
+
+// Payments returns the payments recorded to address, with a zero Count when it was
+// never paid.
+func (s *WalletDB) Payments(address string) (Payment, error) {
+	var p Payment
+	err := s.db.View(func(tx bdb.ReadTx) error {
+		bucket := tx.ReadBucket(paymentsStoreKey)
+		if bucket == nil {
+			return nil
+		}
+		raw := bucket.Get([]byte(address))
+		if len(raw) == 0 {
+			return nil
+		}
+		return json.Unmarshal(raw, &p)
+	}, func() {})
+	return p, err
+}
@@ -0,0 +1,108 @@
+package walletdb
+
+import (
+	"encoding/json"
+	"sort"
+
+	bdb "github.com/btcsuite/btcwallet/walletdb"
+	"github.com/satelliondao/satellion/bip329"
+)
+
+// labelsKey is the bucket, nested in the bucket of a wallet, holding its labels keyed by
+// bip329.Label.Key.
+var labelsKey = []byte("labels")
+
+// Labels returns the labels of wname sorted by type and reference.
+func (s *WalletDB) Labels(wname string) ([]bip329.Label, error) {
+	var labels []bip329.Label
+	err := s.db.View(func(tx bdb.ReadTx) error {
+		bucket, err := readLabels(tx, wname)
+		if bucket == nil || err != nil {
+			return err
+		}
+		return bucket.ForEach(func(k, v []byte) error {
+			var l bip329.Label
+			if err := json.Unmarshal(v, &l); err != nil {
+				return err
+			}
+			labels = append(labels, l)
+			return nil
+		})
+	}, func() {})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(labels, func(i, j int) bool { return labels[i].Key() < labels[j].Key() })
+	return labels, nil
+}
+
+// Label returns the label of wname for ref, or a label with only its type and
+// reference set when there is none.
+func (s *WalletDB) Label(wname string, typ bip329.Type, ref string) (bip329.Label, error) {
+	l := bip329.Label{Type: typ, Ref: ref}
+	err := s.db.View(func(tx bdb.ReadTx) error {
+		bucket, err := readLabels(tx, wname)
+		if bucket == nil || err != nil {
+			return err
+		}
+		raw := bucket.Get([]byte(l.Key()))
+		if len(raw) == 0 {
+			return nil
+		}
+		return json.Unmarshal(raw, &l)
+	}, func() {})
+	return l, err
+}
+
+// SetLabels stores labels for wname, replacing the labels of the same items. A label
+// with no text and no spendable flag removes the item's label.
+func (s *WalletDB) SetLabels(wname string, labels ...bip329.Label) error {
+	for _, l := range labels {
+		if err := l.Validate(); err != nil {
+			return err
+		}
+	}
+	return s.db.Update(func(tx bdb.ReadWriteTx) error {
+		entries, err := entriesBucket(tx)
+		if err != nil {
+			return err
+		}
+		wallet := entries.NestedReadWriteBucket([]byte(wname))
+		if wallet == nil {
+			return ErrWalletNotFound
+		}
+		bucket, err := wallet.CreateBucketIfNotExists(labelsKey)
+		if err != nil {
+			return err
+		}
+		for _, l := range labels {
+			if l.Label == "" && l.Spendable == nil {
+				if err := bucket.Delete([]byte(l.Key())); err != nil {
+					return err
+				}
+				continue
+			}
+			raw, err := json.Marshal(l)
+			if err != nil {
+				return err
+			}
+			if err := bucket.Put([]byte(l.Key()), raw); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, func() {})
+}
+
+// readLabels returns the labels bucket of wname, or nil when it has no labels yet.
+func readLabels(tx bdb.ReadTx, wname string) (bdb.ReadBucket, error) {
+	entries := readEntries(tx)
+	if entries == nil {
+		return nil, ErrWalletNotFound
+	}
+	wallet := entries.NestedReadBucket([]byte(wname))
+	if wallet == nil {
+		return nil, ErrWalletNotFound
+	}
+	return wallet.NestedReadBucket(labelsKey), nil
+}
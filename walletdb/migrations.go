@@ -0,0 +1,130 @@
+package walletdb
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	bdb "github.com/btcsuite/btcwallet/walletdb"
+	"github.com/satelliondao/satellion/wallet"
+)
+
+// migrations lists every schema change in order. Append new entries and never edit
+// released ones: existing databases replay them to reach the latest version.
+var migrations = []migration{
+	{version: 1, name: "nest wallet records under the wallets bucket", apply: nestWalletRecords},
+	{version: 2, name: "replace seed hash locks with argon2id verifiers", apply: upgradeLegacyLocks},
+}
+
+var legacyWalletPrefix = []byte("wallet_")
+
+// nestWalletRecords moves wallet_<name> top-level buckets into wallets/entries/<name>.
+func nestWalletRecords(tx bdb.ReadWriteTx) error {
+	var legacy [][]byte
+	err := tx.ForEachBucket(func(k []byte) error {
+		if bytes.HasPrefix(k, legacyWalletPrefix) {
+			legacy = append(legacy, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	entries, err := entriesBucket(tx)
+	if err != nil {
+		return err
+	}
+	for _, key := range legacy {
+		raw := tx.ReadWriteBucket(key).Get(key)
+		if len(raw) > 0 {
+			name := bytes.TrimPrefix(key, legacyWalletPrefix)
+			bucket, err := entries.CreateBucketIfNotExists(name)
+			if err != nil {
+				return fmt.Errorf("failed to create bucket for wallet %s: %w", name, err)
+			}
+			if err := bucket.Put(walletRecordKey, raw); err != nil {
+				return err
+			}
+		}
+		if err := tx.DeleteTopLevelBucket(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// upgradeLegacyLocks rewraps every hex(sha256(seed)) lock into an argon2id verifier of the same hash.
+func upgradeLegacyLocks(tx bdb.ReadWriteTx) error {
+	entries, err := entriesBucket(tx)
+	if err != nil {
+		return err
+	}
+	var names [][]byte
+	err = entries.ForEach(func(k, v []byte) error {
+		if v == nil {
+			names = append(names, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, name := range names {
+		if err := upgradeRecordLock(entries.NestedReadWriteBucket(name), walletRecordKey, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// scrubLegacyLocks upgrades the seed hash locks of a database in any past layout without
+// migrating it otherwise. Upgrade runs it on the copy kept before migrating, which would
+// still hold the unsalted hashes the migration replaced.
+func scrubLegacyLocks(tx bdb.ReadWriteTx) error {
+	var legacy [][]byte
+	err := tx.ForEachBucket(func(k []byte) error {
+		if bytes.HasPrefix(k, legacyWalletPrefix) {
+			legacy = append(legacy, append([]byte(nil), k...))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, key := range legacy {
+		name := bytes.TrimPrefix(key, legacyWalletPrefix)
+		if err := upgradeRecordLock(tx.ReadWriteBucket(key), key, name); err != nil {
+			return err
+		}
+	}
+	if idx := tx.ReadWriteBucket(walletStoreKey); idx != nil && idx.NestedReadWriteBucket(walletEntriesKey) != nil {
+		return upgradeLegacyLocks(tx)
+	}
+	return nil
+}
+
+// upgradeRecordLock rewraps the seed hash lock of the wallet record stored under key.
+func upgradeRecordLock(bucket bdb.ReadWriteBucket, key, name []byte) error {
+	raw := bucket.Get(key)
+	if len(raw) == 0 {
+		return nil
+	}
+	var entity map[string]json.RawMessage
+	if err := json.Unmarshal(raw, &entity); err != nil {
+		return fmt.Errorf("failed to read wallet %s: %w", name, err)
+	}
+	var lock string
+	if err := json.Unmarshal(entity["lock"], &lock); err != nil || !wallet.IsLegacyLock(lock) {
+		return nil
+	}
+	upgraded, err := wallet.UpgradeLegacyLock(lock, wallet.LockParams)
+	if err != nil {
+		return fmt.Errorf("failed to upgrade lock of wallet %s: %w", name, err)
+	}
+	entity["lock"], _ = json.Marshal(upgraded)
+	out, err := json.Marshal(entity)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(key, out)
+}
@@ -0,0 +1,146 @@
+package walletdb
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+
+	bdb "github.com/btcsuite/btcwallet/walletdb"
+)
+
+var (
+	metaStoreKey     = []byte("meta")
+	schemaVersionKey = []byte("schema_version")
+)
+
+// migration upgrades the database from version-1 to version.
+type migration struct {
+	version int
+	name    string
+	apply   func(tx bdb.ReadWriteTx) error
+}
+
+// LatestVersion is the schema version written by this build.
+func LatestVersion() int {
+	return migrations[len(migrations)-1].version
+}
+
+// SchemaVersion returns the schema version of db. Databases created before
+// versioning was introduced report version 0.
+func SchemaVersion(db bdb.DB) (int, error) {
+	var version int
+	err := db.View(func(tx bdb.ReadTx) error {
+		version = readSchemaVersion(tx)
+		return nil
+	}, func() {})
+	return version, err
+}
+
+// Upgrade brings db to the latest schema version. Migrations run in order inside a
+// single transaction, so a failure leaves the database untouched. When a migration is
+// needed, the database is first copied to backupPath. Once the migration succeeded, the
+// seed hash locks of the copy are upgraded too. When that fails the copy is kept, as it
+// is the only backup, and the error tells the user it still holds the fast hashes.
+func Upgrade(db bdb.DB, backupPath string) error {
+	version, empty, err := inspect(db)
+	if err != nil {
+		return err
+	}
+	latest := LatestVersion()
+	if version > latest {
+		return fmt.Errorf("wallet database schema version %d is newer than supported version %d", version, latest)
+	}
+	if version == latest {
+		return nil
+	}
+	if empty {
+		return db.Update(func(tx bdb.ReadWriteTx) error {
+			return writeSchemaVersion(tx, latest)
+		}, func() {})
+	}
+	if err := backup(db, backupPath); err != nil {
+		return fmt.Errorf("failed to back up wallet database: %w", err)
+	}
+	err = db.Update(func(tx bdb.ReadWriteTx) error {
+		for _, m := range migrations {
+			if m.version <= version {
+				continue
+			}
+			if err := m.apply(tx); err != nil {
+				return fmt.Errorf("migration %d (%s) failed: %w", m.version, m.name, err)
+			}
+		}
+		return writeSchemaVersion(tx, latest)
+	}, func() {})
+	if err != nil {
+		return err
+	}
+	if err := scrubBackup(backupPath); err != nil {
+		return fmt.Errorf("the seed hash locks of the backup %s could not be upgraded and "+
+			"are fast to brute force, keep it somewhere safe or delete it: %w", backupPath, err)
+	}
+	return nil
+}
+
+// inspect returns the schema version of db and whether it holds no data at all.
+func inspect(db bdb.DB) (int, bool, error) {
+	var version int
+	empty := true
+	err := db.View(func(tx bdb.ReadTx) error {
+		version = readSchemaVersion(tx)
+		return tx.ForEachBucket(func(k []byte) error {
+			empty = false
+			return nil
+		})
+	}, func() {})
+	return version, empty, err
+}
+
+func readSchemaVersion(tx bdb.ReadTx) int {
+	meta := tx.ReadBucket(metaStoreKey)
+	if meta == nil {
+		return 0
+	}
+	raw := meta.Get(schemaVersionKey)
+	if len(raw) != 4 {
+		return 0
+	}
+	return int(binary.BigEndian.Uint32(raw))
+}
+
+func writeSchemaVersion(tx bdb.ReadWriteTx, version int) error {
+	meta := tx.ReadWriteBucket(metaStoreKey)
+	if meta == nil {
+		b, err := tx.CreateTopLevelBucket(metaStoreKey)
+		if err != nil {
+			return err
+		}
+		meta = b
+	}
+	raw := make([]byte, 4)
+	binary.BigEndian.PutUint32(raw, uint32(version))
+	return meta.Put(schemaVersionKey, raw)
+}
+
+func backup(db bdb.DB, path string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := db.Copy(f); err != nil {
+		f.Close()
+		os.Remove(path)
+		return err
+	}
+	return f.Close()
+}
+
+// scrubBackup upgrades the seed hash locks of the database copy at path.
+func scrubBackup(path string) error {
+	db, err := Connect(path)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	return db.Update(scrubLegacyLocks, func() {})
+}
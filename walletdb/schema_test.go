@@ -0,0 +1,198 @@
+package walletdb
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/btcsuite/btcd/chaincfg"
+	bdb "github.com/btcsuite/btcwallet/walletdb"
+	"github.com/satelliondao/satellion/mnemonic"
+	"github.com/satelliondao/satellion/wallet"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var fixtureWords = []string{
+	"abandon", "abandon", "abandon", "abandon", "abandon", "abandon",
+	"abandon", "abandon", "abandon", "abandon", "abandon", "about",
+}
+
+var fixtureCreatedAt = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func init() {
+	wallet.LockParams = wallet.KDFParams{Time: 1, MemoryKiB: 1024, Threads: 1}
+}
+
+// fixtureLock is the unsalted seed hash lock written before argon2id verifiers.
+func fixtureLock() string {
+	m := mnemonic.New(fixtureWords)
+	h := sha256.Sum256(m.Seed(""))
+	return hex.EncodeToString(h[:])
+}
+
+func fixtureEntity(t *testing.T, name string) []byte {
+	m := mnemonic.New(fixtureWords)
+	w := wallet.New(&m, "", fixtureLock(), &chaincfg.MainNetParams)
+	w.Name = name
+	w.NextReceiveIndex = 4
+	w.NextChangeIndex = 2
+	w.CreatedAt = fixtureCreatedAt
+	raw, err := json.Marshal(NewWalletEntity(w))
+	require.NoError(t, err)
+	return raw
+}
+
+// fixtures write a database in the layout of every past schema version. Each holds
+// the wallets "alice" and "bob", with "bob" active.
+var fixtures = map[int]func(t *testing.T, tx bdb.ReadWriteTx){
+	// Unversioned layout: one wallet_<name> top-level bucket per wallet.
+	0: func(t *testing.T, tx bdb.ReadWriteTx) {
+		for _, name := range []string{"alice", "bob"} {
+			key := []byte("wallet_" + name)
+			b, err := tx.CreateTopLevelBucket(key)
+			require.NoError(t, err)
+			require.NoError(t, b.Put(key, fixtureEntity(t, name)))
+		}
+		idx, err := tx.CreateTopLevelBucket(walletStoreKey)
+		require.NoError(t, err)
+		require.NoError(t, idx.Put([]byte(ActiveWalletKey), []byte("bob")))
+	},
+	// Wallets nested under wallets/entries/<name>, still locked by the seed hash.
+	1: func(t *testing.T, tx bdb.ReadWriteTx) {
+		idx, err := tx.CreateTopLevelBucket(walletStoreKey)
+		require.NoError(t, err)
+		require.NoError(t, idx.Put([]byte(ActiveWalletKey), []byte("bob")))
+		entries, err := idx.CreateBucket([]byte("entries"))
+		require.NoError(t, err)
+		for _, name := range []string{"alice", "bob"} {
+			b, err := entries.CreateBucket([]byte(name))
+			require.NoError(t, err)
+			require.NoError(t, b.Put([]byte("wallet"), fixtureEntity(t, name)))
+		}
+	},
+}
+
+func openTestDB(t *testing.T) (bdb.DB, string) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "wallets.db")
+	db, err := Connect(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return db, dir
+}
+
+func TestUpgrade_FromEveryVersion(t *testing.T) {
+	for version := 0; version < LatestVersion(); version++ {
+		fixture, ok := fixtures[version]
+		require.True(t, ok, "missing fixture for schema version %d", version)
+		t.Run(fmt.Sprintf("v%d", version), func(t *testing.T) {
+			db, dir := openTestDB(t)
+			require.NoError(t, db.Update(func(tx bdb.ReadWriteTx) error {
+				fixture(t, tx)
+				if version > 0 {
+					return writeSchemaVersion(tx, version)
+				}
+				return nil
+			}, func() {}))
+
+			backupPath := filepath.Join(dir, "wallets.db.bak")
+			require.NoError(t, Upgrade(db, backupPath))
+
+			got, err := SchemaVersion(db)
+			require.NoError(t, err)
+			assert.Equal(t, LatestVersion(), got)
+
+			repo := New(db, &chaincfg.MainNetParams)
+			count, err := repo.WalletCount()
+			require.NoError(t, err)
+			assert.Equal(t, 2, count)
+			active, err := repo.GetActiveWallet(nil)
+			require.NoError(t, err)
+			assert.Equal(t, "bob", active.Name)
+			assert.Equal(t, fixtureWords, active.Mnemonic.Words)
+			assert.Equal(t, uint32(4), active.NextReceiveIndex)
+			assert.Equal(t, uint32(2), active.NextChangeIndex)
+			assert.Equal(t, fixtureCreatedAt, active.CreatedAt)
+			assert.False(t, wallet.IsLegacyLock(active.Lock))
+			ok, err := wallet.VerifyLock(active.Lock, active.Mnemonic.Seed(""))
+			require.NoError(t, err)
+			assert.True(t, ok)
+
+			backupDB, err := Connect(backupPath)
+			require.NoError(t, err)
+			defer backupDB.Close()
+			backupVersion, err := SchemaVersion(backupDB)
+			require.NoError(t, err)
+			assert.Equal(t, version, backupVersion, "backup must hold the database before migration")
+			locks := backupLocks(t, backupDB)
+			require.Len(t, locks, 2)
+			for _, lock := range locks {
+				assert.False(t, wallet.IsLegacyLock(lock), "backup must not keep seed hash locks")
+			}
+		})
+	}
+}
+
+// backupLocks returns the locks of the wallets in db, in the layout of any version.
+func backupLocks(t *testing.T, db bdb.DB) []string {
+	var records [][]byte
+	require.NoError(t, db.View(func(tx bdb.ReadTx) error {
+		for _, name := range []string{"alice", "bob"} {
+			key := []byte("wallet_" + name)
+			if b := tx.ReadBucket(key); b != nil {
+				records = append(records, b.Get(key))
+			}
+		}
+		if entries := readEntries(tx); entries != nil {
+			return entries.ForEach(func(k, v []byte) error {
+				records = append(records, entries.NestedReadBucket(k).Get(walletRecordKey))
+				return nil
+			})
+		}
+		return nil
+	}, func() {}))
+	var locks []string
+	for _, raw := range records {
+		var entity WalletEntity
+		require.NoError(t, json.Unmarshal(raw, &entity))
+		locks = append(locks, entity.Lock)
+	}
+	return locks
+}
+
+func TestUpgrade_FreshDatabaseSkipsBackup(t *testing.T) {
+	db, dir := openTestDB(t)
+	backupPath := filepath.Join(dir, "wallets.db.bak")
+	require.NoError(t, Upgrade(db, backupPath))
+	version, err := SchemaVersion(db)
+	require.NoError(t, err)
+	assert.Equal(t, LatestVersion(), version)
+	_, err = os.Stat(backupPath)
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUpgrade_UpToDateIsNoop(t *testing.T) {
+	db, dir := openTestDB(t)
+	require.NoError(t, Upgrade(db, filepath.Join(dir, "first.bak")))
+	w := wallet.New(mnemonic.NewRandom(), "", "", &chaincfg.MainNetParams)
+	w.Name = "carol"
+	require.NoError(t, New(db, &chaincfg.MainNetParams).Save(w))
+	require.NoError(t, Upgrade(db, filepath.Join(dir, "second.bak")))
+	_, err := os.Stat(filepath.Join(dir, "second.bak"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestUpgrade_RejectsNewerVersion(t *testing.T) {
+	db, dir := openTestDB(t)
+	require.NoError(t, db.Update(func(tx bdb.ReadWriteTx) error {
+		return writeSchemaVersion(tx, LatestVersion()+1)
+	}, func() {}))
+	err := Upgrade(db, filepath.Join(dir, "wallets.db.bak"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,95 @@
+package walletdb
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+
+	"github.com/satelliondao/satellion/secret"
+	"github.com/satelliondao/satellion/wallet"
+	"golang.org/x/crypto/argon2"
+	"golang.org/x/crypto/chacha20poly1305"
+)
+
+var ErrInvalidPassword = errors.New("invalid password")
+
+// sealedSeed is a wallet mnemonic and BIP39 passphrase encrypted with a key derived
+// from the wallet password by argon2id.
+type sealedSeed struct {
+	KDF        wallet.KDFParams `json:"kdf"`
+	Salt       []byte           `json:"salt"`
+	Nonce      []byte           `json:"nonce"`
+	Ciphertext []byte           `json:"ciphertext"`
+}
+
+type seedSecret struct {
+	Mnemonic   []string `json:"mnemonic"`
+	Passphrase string   `json:"passphrase"`
+}
+
+// seal encrypts words and passphrase with password. The fingerprint is authenticated
+// so that a seal cannot be moved to another wallet record.
+func seal(words []string, passphrase string, password []byte, fingerprint string, params wallet.KDFParams) (*sealedSeed, error) {
+	plaintext, err := json.Marshal(seedSecret{Mnemonic: words, Passphrase: passphrase})
+	if err != nil {
+		return nil, err
+	}
+	defer secret.Wipe(plaintext)
+	s := &sealedSeed{KDF: params, Salt: make([]byte, 16), Nonce: make([]byte, chacha20poly1305.NonceSizeX)}
+	if _, err := rand.Read(s.Salt); err != nil {
+		return nil, err
+	}
+	if _, err := rand.Read(s.Nonce); err != nil {
+		return nil, err
+	}
+	key := s.key(password)
+	defer secret.Wipe(key)
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	s.Ciphertext = aead.Seal(nil, s.Nonce, plaintext, []byte(fingerprint))
+	return s, nil
+}
+
+// open decrypts the seal, returning ErrInvalidPassword when password does not match.
+func (s *sealedSeed) open(password []byte, fingerprint string) (*seedSecret, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	key := s.key(password)
+	defer secret.Wipe(key)
+	return s.openWithKey(key, fingerprint)
+}
+
+// openWithKey is like open with the key derived from the password.
+func (s *sealedSeed) openWithKey(key []byte, fingerprint string) (*seedSecret, error) {
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	aead, err := chacha20poly1305.NewX(key)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := aead.Open(nil, s.Nonce, s.Ciphertext, []byte(fingerprint))
+	if err != nil {
+		return nil, ErrInvalidPassword
+	}
+	defer secret.Wipe(plaintext)
+	var seed seedSecret
+	if err := json.Unmarshal(plaintext, &seed); err != nil {
+		return nil, err
+	}
+	return &seed, nil
+}
+
+func (s *sealedSeed) validate() error {
+	if s.KDF.Time == 0 || s.KDF.MemoryKiB == 0 || s.KDF.Threads == 0 || len(s.Nonce) != chacha20poly1305.NonceSizeX {
+		return errors.New("invalid seal parameters")
+	}
+	return nil
+}
+
+func (s *sealedSeed) key(password []byte) []byte {
+	return argon2.IDKey(password, s.Salt, s.KDF.Time, s.KDF.MemoryKiB, s.KDF.Threads, chacha20poly1305.KeySize)
+}
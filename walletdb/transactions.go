@@ -0,0 +1,184 @@
+package walletdb
+
+import (
+	"encoding/json"
+	"errors"
+	"sort"
+
+	bdb "github.com/btcsuite/btcwallet/walletdb"
+	"github.com/satelliondao/satellion/spend"
+	"github.com/satelliondao/satellion/wallet"
+)
+
+// transactionsKey is the bucket, nested in the bucket of a wallet, holding the
+// transactions it sent keyed by txid.
+var transactionsKey = []byte("transactions")
+
+var ErrTransactionNotFound = errors.New("transaction not found")
+
+// SaveTransactions stores txs sent by wname, replacing the records of the same txids.
+func (s *WalletDB) SaveTransactions(wname string, txs ...spend.Transaction) error {
+	return s.db.Update(func(tx bdb.ReadWriteTx) error {
+		bucket, err := writeTransactions(tx, wname)
+		if err != nil {
+			return err
+		}
+		return putTransactions(bucket, txs)
+	}, func() {})
+}
+
+// Transactions returns the transactions sent by wname, newest first.
+func (s *WalletDB) Transactions(wname string) ([]spend.Transaction, error) {
+	var list []spend.Transaction
+	err := s.db.View(func(tx bdb.ReadTx) error {
+		var err error
+		list, err = readTransactions(tx, wname)
+		return err
+	}, func() {})
+	if err != nil {
+		return nil, err
+	}
+	sort.SliceStable(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+	return list, nil
+}
+
+// Transaction returns the transaction txid sent by wname.
+func (s *WalletDB) Transaction(wname, txid string) (spend.Transaction, error) {
+	var t spend.Transaction
+	err := s.db.View(func(tx bdb.ReadTx) error {
+		bucket, err := readTransactionsBucket(tx, wname)
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			return ErrTransactionNotFound
+		}
+		raw := bucket.Get([]byte(txid))
+		if len(raw) == 0 {
+			return ErrTransactionNotFound
+		}
+		return json.Unmarshal(raw, &t)
+	}, func() {})
+	return t, err
+}
+
+// PendingSpends returns the outputs spent by the pending transactions of wname, keyed by
+// txid:vout. The coin selector must not spend them again.
+func (s *WalletDB) PendingSpends(wname string) (map[string]bool, error) {
+	list, err := s.Transactions(wname)
+	if err != nil {
+		return nil, err
+	}
+	spent := make(map[string]bool)
+	for _, t := range list {
+		if t.Status != spend.Pending {
+			continue
+		}
+		for _, in := range t.Inputs {
+			spent[in.Ref()] = true
+		}
+	}
+	return spent, nil
+}
+
+// ReconcileTransactions updates the transactions of wname with a scan: those in confirmed,
+// which maps txids to the height of their block, are confirmed, and pending ones with an
+// input missing from unspent were replaced. The replacement is named when it is one of
+// the confirmed transactions of the wallet. Unconfirmed inputs, spent by children paying
+// for their parent, are never in a scan and are not checked.
+func (s *WalletDB) ReconcileTransactions(wname string, confirmed map[string]int32, unspent []wallet.Utxo) error {
+	return s.db.Update(func(tx bdb.ReadWriteTx) error {
+		bucket, err := writeTransactions(tx, wname)
+		if err != nil {
+			return err
+		}
+		list, err := readTransactions(tx, wname)
+		if err != nil {
+			return err
+		}
+		spentBy := make(map[string]string)
+		for i := range list {
+			t := &list[i]
+			if height, ok := confirmed[t.Txid]; ok {
+				t.Status, t.Height = spend.Confirmed, height
+				for _, in := range t.Inputs {
+					spentBy[in.Ref()] = t.Txid
+				}
+			}
+		}
+		available := make(map[string]bool, len(unspent))
+		for _, u := range unspent {
+			available[u.Ref()] = true
+		}
+		for i := range list {
+			t := &list[i]
+			if t.Status != spend.Pending {
+				continue
+			}
+			for _, in := range t.Inputs {
+				if in.Height != 0 && !available[in.Ref()] {
+					t.Status, t.ReplacedBy = spend.Replaced, spentBy[in.Ref()]
+					break
+				}
+			}
+		}
+		return putTransactions(bucket, list)
+	}, func() {})
+}
+
+func putTransactions(bucket bdb.ReadWriteBucket, txs []spend.Transaction) error {
+	for _, t := range txs {
+		raw, err := json.Marshal(t)
+		if err != nil {
+			return err
+		}
+		if err := bucket.Put([]byte(t.Txid), raw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeTransactions returns the transactions bucket of wname, creating it.
+func writeTransactions(tx bdb.ReadWriteTx, wname string) (bdb.ReadWriteBucket, error) {
+	entries, err := entriesBucket(tx)
+	if err != nil {
+		return nil, err
+	}
+	wallet := entries.NestedReadWriteBucket([]byte(wname))
+	if wallet == nil {
+		return nil, ErrWalletNotFound
+	}
+	return wallet.CreateBucketIfNotExists(transactionsKey)
+}
+
+// readTransactionsBucket returns the transactions bucket of wname, or nil when it sent
+// none yet.
+func readTransactionsBucket(tx bdb.ReadTx, wname string) (bdb.ReadBucket, error) {
+	entries := readEntries(tx)
+	if entries == nil {
+		return nil, ErrWalletNotFound
+	}
+	wallet := entries.NestedReadBucket([]byte(wname))
+	if wallet == nil {
+		return nil, ErrWalletNotFound
+	}
+	return wallet.NestedReadBucket(transactionsKey), nil
+}
+
+func readTransactions(tx bdb.ReadTx, wname string) ([]spend.Transaction, error) {
+	bucket, err := readTransactionsBucket(tx, wname)
+	if bucket == nil || err != nil {
+		return nil, err
+	}
+	var list []spend.Transaction
+	err = bucket.ForEach(func(k, v []byte) error {
+		var t spend.Transaction
+		if err := json.Unmarshal(v, &t); err != nil {
+			return err
+		}
+		list = append(list, t)
+		return nil
+	})
+	return list, err
+}
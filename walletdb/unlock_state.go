@@ -0,0 +1,56 @@
+package walletdb
+
+import (
+	"encoding/json"
+	"time"
+
+	bdb "github.com/btcsuite/btcwallet/walletdb"
+)
+
+var unlockStateKey = []byte("unlock_state")
+
+// UnlockState tracks failed unlock attempts of a wallet so that back-off survives restarts.
+type UnlockState struct {
+	Failures    int       `json:"failures"`
+	LastFailure time.Time `json:"last_failure"`
+}
+
+// UnlockState returns the failed unlock attempts recorded for wname.
+func (s *WalletDB) UnlockState(wname string) (UnlockState, error) {
+	var state UnlockState
+	err := s.db.View(func(tx bdb.ReadTx) error {
+		entries := readEntries(tx)
+		if entries == nil {
+			return ErrWalletNotFound
+		}
+		bucket := entries.NestedReadBucket([]byte(wname))
+		if bucket == nil {
+			return ErrWalletNotFound
+		}
+		raw := bucket.Get(unlockStateKey)
+		if len(raw) == 0 {
+			return nil
+		}
+		return json.Unmarshal(raw, &state)
+	}, func() {})
+	return state, err
+}
+
+// SaveUnlockState records the failed unlock attempts of wname.
+func (s *WalletDB) SaveUnlockState(wname string, state UnlockState) error {
+	return s.db.Update(func(tx bdb.ReadWriteTx) error {
+		entries, err := entriesBucket(tx)
+		if err != nil {
+			return err
+		}
+		bucket := entries.NestedReadWriteBucket([]byte(wname))
+		if bucket == nil {
+			return ErrWalletNotFound
+		}
+		raw, err := json.Marshal(state)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(unlockStateKey, raw)
+	}, func() {})
+}
@@ -0,0 +1,105 @@
+package walletdb
+
+import (
+	"encoding/json"
+	"time"
+
+	bdb "github.com/btcsuite/btcwallet/walletdb"
+	"github.com/satelliondao/satellion/bip329"
+	"github.com/satelliondao/satellion/wallet"
+)
+
+var (
+	// utxosKey is the bucket, nested in the bucket of a wallet, holding the result of its
+	// last scan under utxoSetKey.
+	utxosKey   = []byte("utxos")
+	utxoSetKey = []byte("set")
+)
+
+// UtxoSet is the unspent outputs of a wallet as of a scan up to Height.
+type UtxoSet struct {
+	Height    int32         `json:"height"`
+	ScannedAt time.Time     `json:"scanned_at"`
+	Utxos     []wallet.Utxo `json:"utxos"`
+}
+
+// SaveUtxos replaces the unspent outputs of wname with set.
+func (s *WalletDB) SaveUtxos(wname string, set UtxoSet) error {
+	raw, err := json.Marshal(set)
+	if err != nil {
+		return err
+	}
+	return s.db.Update(func(tx bdb.ReadWriteTx) error {
+		entries, err := entriesBucket(tx)
+		if err != nil {
+			return err
+		}
+		wallet := entries.NestedReadWriteBucket([]byte(wname))
+		if wallet == nil {
+			return ErrWalletNotFound
+		}
+		bucket, err := wallet.CreateBucketIfNotExists(utxosKey)
+		if err != nil {
+			return err
+		}
+		return bucket.Put(utxoSetKey, raw)
+	}, func() {})
+}
+
+// Utxos returns the unspent outputs of wname found by its last scan, an empty set when
+// it was never scanned.
+func (s *WalletDB) Utxos(wname string) (UtxoSet, error) {
+	var set UtxoSet
+	err := s.db.View(func(tx bdb.ReadTx) error {
+		entries := readEntries(tx)
+		if entries == nil {
+			return ErrWalletNotFound
+		}
+		wallet := entries.NestedReadBucket([]byte(wname))
+		if wallet == nil {
+			return ErrWalletNotFound
+		}
+		bucket := wallet.NestedReadBucket(utxosKey)
+		if bucket == nil {
+			return nil
+		}
+		raw := bucket.Get(utxoSetKey)
+		if len(raw) == 0 {
+			return nil
+		}
+		return json.Unmarshal(raw, &set)
+	}, func() {})
+	return set, err
+}
+
+// Frozen returns the outputs of wname that must not be spent, keyed by txid:vout. They
+// are the outputs labelled unspendable in BIP329 terms, so freezing travels with label
+// exports to and from other wallets.
+func (s *WalletDB) Frozen(wname string) (map[string]bool, error) {
+	labels, err := s.Labels(wname)
+	if err != nil {
+		return nil, err
+	}
+	frozen := make(map[string]bool)
+	for _, l := range labels {
+		if l.Type == bip329.Output && l.Spendable != nil && !*l.Spendable {
+			frozen[l.Ref] = true
+		}
+	}
+	return frozen, nil
+}
+
+// SetFrozen freezes or unfreezes the output ref, given as txid:vout, of wname, keeping
+// its label.
+func (s *WalletDB) SetFrozen(wname, ref string, frozen bool) error {
+	l, err := s.Label(wname, bip329.Output, ref)
+	if err != nil {
+		return err
+	}
+	l.Spendable = nil
+	if frozen {
+		spendable := false
+		l.Spendable = &spendable
+	}
+	return s.SetLabels(wname, l)
+}
@@ -6,16 +6,35 @@ import (
 	"github.com/satelliondao/satellion/wallet"
 )
 
+// WalletEntity is the stored form of a wallet. Sealed wallets keep their mnemonic in
+// Sealed, or in the enclave backend named by SeedStore; Mnemonic and Lock are only set
+// for wallets stored before seeds were encrypted. Watch-only wallets only have AccountXpub.
 type WalletEntity struct {
-	Name             string    `json:"name"`
-	Mnemonic         []string  `json:"mnemonic"`
-	Lock             string    `json:"lock"`
-	NextChangeIndex  uint32    `json:"next_change_index"`
-	NextReceiveIndex uint32    `json:"next_receive_index"`
-	CreatedAt        time.Time `json:"created_at"`
+	Name             string      `json:"name"`
+	Mnemonic         []string    `json:"mnemonic,omitempty"`
+	Lock             string      `json:"lock,omitempty"`
+	Fingerprint      string      `json:"fingerprint,omitempty"`
+	Sealed           *sealedSeed `json:"sealed,omitempty"`
+	SeedStore        string      `json:"seed_store,omitempty"`
+	AccountXpub      string      `json:"account_xpub,omitempty"`
+	AccountID        string      `json:"account_id,omitempty"`
+	NextChangeIndex  uint32      `json:"next_change_index"`
+	NextReceiveIndex uint32      `json:"next_receive_index"`
+	CreatedAt        time.Time   `json:"created_at"`
 }
 
 func NewWalletEntity(w *wallet.Wallet) *WalletEntity {
+	if w.WatchOnly() {
+		return &WalletEntity{
+			Name:             w.Name,
+			Fingerprint:      w.Fingerprint,
+			AccountXpub:      w.AccountKey.String(),
+			AccountID:        w.AccountID,
+			NextChangeIndex:  w.NextChangeIndex,
+			NextReceiveIndex: w.NextReceiveIndex,
+			CreatedAt:        w.CreatedAt,
+		}
+	}
 	if w.RootKey == nil {
 		panic("root key is nil")
 	}
@@ -26,8 +45,15 @@ func NewWalletEntity(w *wallet.Wallet) *WalletEntity {
 		Name:             w.Name,
 		Mnemonic:         w.Mnemonic.Words,
 		Lock:             w.Lock,
+		Fingerprint:      w.Fingerprint,
+		AccountID:        w.AccountID,
 		NextChangeIndex:  w.NextChangeIndex,
 		NextReceiveIndex: w.NextReceiveIndex,
 		CreatedAt:        w.CreatedAt,
 	}
 }
+
+// isSealed reports whether the mnemonic is encrypted, in the database or in an enclave.
+func (e *WalletEntity) isSealed() bool {
+	return e.Sealed != nil || e.SeedStore != ""
+}
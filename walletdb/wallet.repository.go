@@ -1,15 +1,22 @@
 package walletdb
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
-	"strings"
+	"os"
+	"path/filepath"
 
+	"github.com/btcsuite/btcd/chaincfg"
 	"github.com/btcsuite/btcwallet/walletdb"
 	bdb "github.com/btcsuite/btcwallet/walletdb"
+	"github.com/satelliondao/satellion/enclave"
 	"github.com/satelliondao/satellion/mnemonic"
+	"github.com/satelliondao/satellion/secret"
 	"github.com/satelliondao/satellion/wallet"
+	"golang.org/x/crypto/chacha20poly1305"
 )
 
 const ActiveWalletKey = "active_wallet"
@@ -18,64 +25,480 @@ var ErrWalletNotFound = errors.New("wallet not found")
 
 type WalletDB struct {
 	db walletdb.DB
+	// seedBackend is the enclave backend new seals are stored in, or "" for wallets.db.
+	seedBackend string
+	enclaveDir  string
+	memory      *enclave.MemoryStore
+	// params is the network the wallets derive addresses and keys for.
+	params *chaincfg.Params
 }
 
-func New(db walletdb.DB) *WalletDB {
-	return &WalletDB{db: db}
+func New(db walletdb.DB, params *chaincfg.Params) *WalletDB {
+	return &WalletDB{db: db, params: params}
 }
 
-func (s *WalletDB) getKey(wname string) []byte {
-	return []byte("wallet_" + wname)
+// Params returns the network the wallets derive addresses and keys for.
+func (s *WalletDB) Params() *chaincfg.Params {
+	return s.params
+}
+
+// UseEnclave stores the seals of wallets sealed from now on in an enclave backend instead
+// of wallets.db. The file backend keeps one store per wallet under dir, encrypted with the
+// wallet password. Seals already stored stay where they are and remain readable, except
+// for those in the keyring, which is cleared on logout: they are moved into wallets.db,
+// and the keyring and memory backends are refused, as seeds would not survive there.
+func (s *WalletDB) UseEnclave(backend, dir string) error {
+	switch backend {
+	case enclave.BackendKeyring, enclave.BackendMemory:
+		return fmt.Errorf("the %s enclave does not keep seeds across logouts: use %q or leave it empty for wallets.db", backend, enclave.BackendFile)
+	}
+	if err := s.useEnclave(backend, dir); err != nil {
+		return err
+	}
+	return s.moveSeals(enclave.BackendKeyring)
+}
+
+// useEnclave is UseEnclave without the restriction to durable backends, for tests.
+func (s *WalletDB) useEnclave(backend, dir string) error {
+	switch backend {
+	case "", enclave.BackendFile, enclave.BackendKeyring, enclave.BackendMemory:
+	default:
+		return fmt.Errorf("unknown enclave backend %q", backend)
+	}
+	s.seedBackend = backend
+	s.enclaveDir = dir
+	return nil
+}
+
+// moveSeals moves the seals stored in backend into wallets.db. They are encrypted already,
+// so no password is needed. Seals missing from the backend are left for Get to report.
+func (s *WalletDB) moveSeals(backend string) error {
+	wallets, err := s.GetAll()
+	if err != nil {
+		return err
+	}
+	for _, w := range wallets {
+		if !w.Sealed {
+			continue
+		}
+		entity, err := s.entity(w.Name)
+		if err != nil {
+			return err
+		}
+		if entity.SeedStore != backend {
+			continue
+		}
+		store, err := s.seedEnclave(backend, w.Name, nil)
+		if err != nil {
+			return err
+		}
+		sealed, err := readSeal(store, entity)
+		if err != nil {
+			continue
+		}
+		moved := entity
+		moved.Sealed, moved.SeedStore = sealed, ""
+		err = s.db.Update(func(tx bdb.ReadWriteTx) error {
+			entries, err := entriesBucket(tx)
+			if err != nil {
+				return err
+			}
+			return putEntity(entries.NestedReadWriteBucket([]byte(w.Name)), &moved)
+		}, func() {})
+		if err != nil {
+			return err
+		}
+		if err := s.removeSeal(entity); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// seedEnclave opens the enclave holding the seal of wallet wname in backend.
+func (s *WalletDB) seedEnclave(backend, wname string, password []byte) (enclave.Enclave, error) {
+	switch backend {
+	case enclave.BackendMemory:
+		if s.memory == nil {
+			s.memory = enclave.NewMemoryStore()
+		}
+		return s.memory, nil
+	case enclave.BackendFile:
+		store, err := enclave.UnlockFileStore(s.walletEnclaveDir(wname), password)
+		return store, fileStoreError(wname, err)
+	}
+	return enclave.Open(backend, "", nil)
+}
+
+// fileStoreError maps the errors of opening the file store of wname.
+func fileStoreError(wname string, err error) error {
+	switch {
+	case errors.Is(err, enclave.ErrWrongPassword):
+		return ErrInvalidPassword
+	case errors.Is(err, enclave.ErrStoreMissing):
+		return fmt.Errorf("seed store of wallet %s is missing: %w", wname, err)
+	}
+	return err
+}
+
+// walletEnclaveDir is the file store of wname. Names are hashed so that they are safe as
+// directory names.
+func (s *WalletDB) walletEnclaveDir(wname string) string {
+	h := sha256.Sum256([]byte(wname))
+	return filepath.Join(s.enclaveDir, hex.EncodeToString(h[:16]))
+}
+
+func seedKey(wname string) string {
+	return "seed/" + wname
+}
+
+// storeSeal writes sealed to the configured enclave backend. A file store is written
+// to a pending directory, returned, which replaceStore moves in place of the store of
+// the wallet once wallets.db refers to it: until then the previous seed stays intact.
+func (s *WalletDB) storeSeal(wname string, password []byte, sealed *sealedSeed) (string, error) {
+	raw, err := json.Marshal(sealed)
+	if err != nil {
+		return "", err
+	}
+	if s.seedBackend != enclave.BackendFile {
+		store, err := s.seedEnclave(s.seedBackend, wname, password)
+		if err != nil {
+			return "", err
+		}
+		return "", store.Save(seedKey(wname), raw)
+	}
+	pending := s.walletEnclaveDir(wname) + ".new"
+	if err := os.RemoveAll(pending); err != nil {
+		return "", err
+	}
+	store, err := enclave.NewFileStore(pending, password)
+	if err == nil {
+		err = store.Save(seedKey(wname), raw)
+	}
+	if err != nil {
+		os.RemoveAll(pending)
+		return "", err
+	}
+	return pending, nil
+}
+
+// replaceStore moves the file store written to pending by storeSeal in place of the
+// store of wname.
+func (s *WalletDB) replaceStore(wname, pending string) error {
+	dir := s.walletEnclaveDir(wname)
+	old := dir + ".old"
+	if err := os.RemoveAll(old); err != nil {
+		return err
+	}
+	if err := os.Rename(dir, old); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	if err := os.Rename(pending, dir); err != nil {
+		os.Rename(old, dir)
+		return err
+	}
+	return os.RemoveAll(old)
+}
+
+// loadSeal reads the seal of a wallet stored in an enclave backend.
+func (s *WalletDB) loadSeal(entity WalletEntity, password []byte) (*sealedSeed, error) {
+	store, err := s.seedEnclave(entity.SeedStore, entity.Name, password)
+	if err != nil {
+		return nil, err
+	}
+	return readSeal(store, entity)
+}
+
+func readSeal(store enclave.Enclave, entity WalletEntity) (*sealedSeed, error) {
+	raw, err := store.Load(seedKey(entity.Name))
+	var notFound *enclave.NotFoundError
+	if errors.As(err, &notFound) {
+		return nil, fmt.Errorf("seed of wallet %s is missing from the %s enclave", entity.Name, entity.SeedStore)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var sealed sealedSeed
+	if err := json.Unmarshal(raw, &sealed); err != nil {
+		return nil, err
+	}
+	return &sealed, nil
+}
+
+// removeSeal deletes the enclave copy of the seal of entity, if any.
+func (s *WalletDB) removeSeal(entity WalletEntity) error {
+	switch entity.SeedStore {
+	case "":
+		return nil
+	case enclave.BackendFile:
+		return os.RemoveAll(s.walletEnclaveDir(entity.Name))
+	}
+	store, err := s.seedEnclave(entity.SeedStore, entity.Name, nil)
+	if err != nil {
+		return err
+	}
+	var notFound *enclave.NotFoundError
+	if err := store.Delete(seedKey(entity.Name)); err != nil && !errors.As(err, &notFound) {
+		return err
+	}
+	return nil
+}
+
+var (
+	walletEntriesKey = []byte("entries")
+	walletRecordKey  = []byte("wallet")
+)
+
+// entriesBucket returns the bucket holding one nested bucket per wallet, creating it if needed.
+func entriesBucket(tx bdb.ReadWriteTx) (bdb.ReadWriteBucket, error) {
+	idx := tx.ReadWriteBucket(walletStoreKey)
+	if idx == nil {
+		b, err := tx.CreateTopLevelBucket(walletStoreKey)
+		if err != nil {
+			return nil, err
+		}
+		idx = b
+	}
+	return idx.CreateBucketIfNotExists(walletEntriesKey)
+}
+
+func readEntries(tx bdb.ReadTx) bdb.ReadBucket {
+	idx := tx.ReadBucket(walletStoreKey)
+	if idx == nil {
+		return nil
+	}
+	return idx.NestedReadBucket(walletEntriesKey)
 }
 
 func (s *WalletDB) Add(w *wallet.Wallet) error {
 	return s.Save(w)
 }
 
+// Save stores w. The mnemonic of a sealed wallet is left untouched, so locked wallets
+// can be saved to update their metadata.
 func (s *WalletDB) Save(w *wallet.Wallet) error {
-	key := s.getKey(w.Name)
 	return s.db.Update(func(tx bdb.ReadWriteTx) error {
-		bucket := tx.ReadWriteBucket(key)
-		if bucket == nil {
-			b, createErr := tx.CreateTopLevelBucket(key)
-			if createErr != nil {
-				return createErr
+		entries, err := entriesBucket(tx)
+		if err != nil {
+			return err
+		}
+		bucket, err := entries.CreateBucketIfNotExists([]byte(w.Name))
+		if err != nil {
+			return err
+		}
+		var entity *WalletEntity
+		if raw := bucket.Get(walletRecordKey); len(raw) > 0 {
+			existing := &WalletEntity{}
+			if err := json.Unmarshal(raw, existing); err != nil {
+				return err
+			}
+			if existing.isSealed() {
+				existing.NextChangeIndex = w.NextChangeIndex
+				existing.NextReceiveIndex = w.NextReceiveIndex
+				existing.CreatedAt = w.CreatedAt
+				if existing.AccountID == "" {
+					existing.AccountID = w.AccountID
+				}
+				entity = existing
 			}
-			bucket = b
 		}
-		out, marshalErr := json.Marshal(NewWalletEntity(w))
-		if marshalErr != nil {
-			return marshalErr
+		if entity == nil {
+			if w.Mnemonic == nil && !w.WatchOnly() {
+				return fmt.Errorf("wallet %s is locked", w.Name)
+			}
+			entity = NewWalletEntity(w)
+		}
+		return putEntity(bucket, entity)
+	}, func() {})
+}
+
+// Seal stores w with its mnemonic and BIP39 passphrase encrypted by password,
+// replacing any plaintext mnemonic stored before.
+func (s *WalletDB) Seal(w *wallet.Wallet, passphrase string, password []byte) error {
+	if w.WatchOnly() {
+		return fmt.Errorf("wallet %s is watch-only and has no seed to seal", w.Name)
+	}
+	if w.Mnemonic == nil {
+		return fmt.Errorf("wallet %s is locked", w.Name)
+	}
+	sealed, err := seal(w.Mnemonic.Words, passphrase, password, w.Fingerprint, wallet.LockParams)
+	if err != nil {
+		return err
+	}
+	entity := &WalletEntity{
+		Name:             w.Name,
+		Fingerprint:      w.Fingerprint,
+		AccountID:        w.AccountID,
+		Sealed:           sealed,
+		NextChangeIndex:  w.NextChangeIndex,
+		NextReceiveIndex: w.NextReceiveIndex,
+		CreatedAt:        w.CreatedAt,
+	}
+	previous, err := s.entity(w.Name)
+	if err != nil && !errors.Is(err, ErrWalletNotFound) {
+		return err
+	}
+	var pending string
+	if s.seedBackend != "" {
+		if pending, err = s.storeSeal(w.Name, password, sealed); err != nil {
+			return fmt.Errorf("failed to store seed in the %s enclave: %w", s.seedBackend, err)
+		}
+		entity.Sealed = nil
+		entity.SeedStore = s.seedBackend
+	}
+	err = s.db.Update(func(tx bdb.ReadWriteTx) error {
+		entries, err := entriesBucket(tx)
+		if err != nil {
+			return err
+		}
+		bucket, err := entries.CreateBucketIfNotExists([]byte(w.Name))
+		if err != nil {
+			return err
 		}
-		return bucket.Put(key, out)
+		return putEntity(bucket, entity)
 	}, func() {})
+	if err != nil {
+		if pending != "" {
+			os.RemoveAll(pending)
+		}
+		return err
+	}
+	if pending != "" {
+		if err := s.replaceStore(w.Name, pending); err != nil {
+			return fmt.Errorf("failed to move the seed store of wallet %s in place: %w", w.Name, err)
+		}
+	}
+	if previous.SeedStore != "" && previous.SeedStore != entity.SeedStore {
+		if err := s.removeSeal(previous); err != nil {
+			return err
+		}
+	}
+	w.Sealed = true
+	w.Lock = ""
+	return nil
+}
+
+// Get returns the wallet wname unlocked with password. Sealed wallets return
+// ErrInvalidPassword when the password does not match. For wallets stored before
+// seeds were encrypted, password is the BIP39 passphrase.
+func (s *WalletDB) Get(wname string, password []byte) (*wallet.Wallet, error) {
+	w, _, err := s.Unseal(wname, password)
+	return w, err
+}
+
+// Unseal is like Get but also returns the BIP39 passphrase of the wallet.
+func (s *WalletDB) Unseal(wname string, password []byte) (*wallet.Wallet, string, error) {
+	entity, err := s.entity(wname)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.toModel(entity, password)
+}
+
+// SessionKey derives from password the keys that open the seal of wallet wname, so that
+// a session can cache them instead of the password. It returns ErrInvalidPassword when
+// password does not match. Only sealed wallets have one.
+func (s *WalletDB) SessionKey(wname string, password []byte) (*secret.Bytes, error) {
+	entity, err := s.entity(wname)
+	if err != nil {
+		return nil, err
+	}
+	if !entity.isSealed() {
+		return nil, fmt.Errorf("wallet %s has no seed sealed with a password", wname)
+	}
+	sealed, storeKey := entity.Sealed, []byte(nil)
+	if entity.SeedStore != "" {
+		store, err := s.seedEnclave(entity.SeedStore, wname, password)
+		if err != nil {
+			return nil, err
+		}
+		if file, ok := store.(*enclave.FileStore); ok {
+			storeKey = file.Key()
+			defer secret.Wipe(storeKey)
+		}
+		if sealed, err = readSeal(store, entity); err != nil {
+			return nil, err
+		}
+	}
+	if err := sealed.validate(); err != nil {
+		return nil, err
+	}
+	sealKey := sealed.key(password)
+	defer secret.Wipe(sealKey)
+	if _, err := sealed.openWithKey(sealKey, entity.Fingerprint); err != nil {
+		return nil, err
+	}
+	key := make([]byte, 0, len(sealKey)+len(storeKey))
+	key = append(append(key, sealKey...), storeKey...)
+	defer secret.Wipe(key)
+	return secret.New(key), nil
+}
+
+// GetWithSessionKey is like Get with a key returned by SessionKey instead of the password.
+func (s *WalletDB) GetWithSessionKey(wname string, key []byte) (*wallet.Wallet, error) {
+	entity, err := s.entity(wname)
+	if err != nil {
+		return nil, err
+	}
+	if !entity.isSealed() {
+		return nil, fmt.Errorf("wallet %s has no seed sealed with a password", wname)
+	}
+	if len(key) < chacha20poly1305.KeySize {
+		return nil, ErrInvalidPassword
+	}
+	sealKey, storeKey := key[:chacha20poly1305.KeySize], key[chacha20poly1305.KeySize:]
+	sealed := entity.Sealed
+	if entity.SeedStore != "" {
+		var store enclave.Enclave
+		if entity.SeedStore == enclave.BackendFile {
+			store, err = enclave.OpenFileStore(s.walletEnclaveDir(wname), storeKey)
+			err = fileStoreError(wname, err)
+		} else {
+			store, err = s.seedEnclave(entity.SeedStore, wname, nil)
+		}
+		if err != nil {
+			return nil, err
+		}
+		if sealed, err = readSeal(store, entity); err != nil {
+			return nil, err
+		}
+	}
+	seed, err := sealed.openWithKey(sealKey, entity.Fingerprint)
+	if err != nil {
+		return nil, err
+	}
+	return s.unlockedModel(entity, seed.Mnemonic, seed.Passphrase), nil
 }
 
-func (s *WalletDB) Get(wname string, passphrase string) (*wallet.Wallet, error) {
+func (s *WalletDB) entity(wname string) (WalletEntity, error) {
 	var entity WalletEntity
 	err := s.db.View(func(tx bdb.ReadTx) error {
-		key := s.getKey(wname)
-		bucket := tx.ReadBucket(key)
+		entries := readEntries(tx)
+		if entries == nil {
+			return ErrWalletNotFound
+		}
+		bucket := entries.NestedReadBucket([]byte(wname))
 		if bucket == nil {
 			return ErrWalletNotFound
 		}
-		raw := bucket.Get(key)
+		raw := bucket.Get(walletRecordKey)
 		if len(raw) == 0 {
 			return ErrWalletNotFound
 		}
 		return json.Unmarshal(raw, &entity)
 	}, func() {})
-	if err != nil {
-		return nil, err
-	}
-	return s.toModel(entity, passphrase), nil
+	return entity, err
 }
 
 func (s *WalletDB) WalletCount() (int, error) {
 	var count int
 	err := s.db.View(func(tx bdb.ReadTx) error {
-		return tx.ForEachBucket(func(k []byte) error {
-			if strings.HasPrefix(string(k), "wallet_") {
+		entries := readEntries(tx)
+		if entries == nil {
+			return nil
+		}
+		return entries.ForEach(func(k, v []byte) error {
+			if v == nil {
 				count++
 			}
 			return nil
@@ -87,25 +510,38 @@ func (s *WalletDB) WalletCount() (int, error) {
 	return count, nil
 }
 
+// GetAll lists every wallet. Sealed wallets are returned locked.
 func (s *WalletDB) GetAll() ([]wallet.Wallet, error) {
 	var list []wallet.Wallet
 
 	err := s.db.View(func(tx bdb.ReadTx) error {
-		return tx.ForEachBucket(func(k []byte) error {
-			key := string(k)
-			if strings.HasPrefix(key, "wallet_") {
-				raw := tx.ReadBucket(k).Get(k)
-				if len(raw) == 0 {
-					return nil
-				}
+		entries := readEntries(tx)
+		if entries == nil {
+			return nil
+		}
+		return entries.ForEach(func(k, v []byte) error {
+			if v != nil {
+				return nil
+			}
+			raw := entries.NestedReadBucket(k).Get(walletRecordKey)
+			if len(raw) == 0 {
+				return nil
+			}
 
-				entity := WalletEntity{}
-				if err := json.Unmarshal(raw, &entity); err != nil {
-					fmt.Println("failed to unmarshal wallet: ", err)
-					return nil
-				}
-				list = append(list, *s.toModel(entity, ""))
+			entity := WalletEntity{}
+			if err := json.Unmarshal(raw, &entity); err != nil {
+				fmt.Println("failed to unmarshal wallet: ", err)
+				return nil
+			}
+			if entity.isSealed() {
+				list = append(list, *s.lockedModel(entity))
+				return nil
 			}
+			model, _, err := s.toModel(entity, nil)
+			if err != nil {
+				return err
+			}
+			list = append(list, *model)
 			return nil
 		})
 	}, func() {})
@@ -116,13 +552,21 @@ func (s *WalletDB) GetAll() ([]wallet.Wallet, error) {
 }
 
 func (s *WalletDB) Delete(wname string) error {
-	key := s.getKey(wname)
-	return s.db.Update(func(tx bdb.ReadWriteTx) error {
-		if idx := tx.ReadWriteBucket(key); idx != nil {
-			_ = idx.Delete(key)
+	entity, err := s.entity(wname)
+	if err != nil && !errors.Is(err, ErrWalletNotFound) {
+		return err
+	}
+	err = s.db.Update(func(tx bdb.ReadWriteTx) error {
+		entries, err := entriesBucket(tx)
+		if err != nil {
+			return err
 		}
-		return tx.DeleteTopLevelBucket(key)
+		return entries.DeleteNestedBucket([]byte(wname))
 	}, func() {})
+	if err != nil {
+		return err
+	}
+	return s.removeSeal(entity)
 }
 
 func (s *WalletDB) SetDefault(wname string) error {
@@ -139,29 +583,12 @@ func (s *WalletDB) SetDefault(wname string) error {
 	}, func() {})
 }
 
-func (s *WalletDB) GetActiveWallet(passphrase string) (*wallet.Wallet, error) {
+func (s *WalletDB) GetActiveWallet(password []byte) (*wallet.Wallet, error) {
 	walletName, err := s.GetActiveWalletName()
 	if err != nil {
 		return nil, err
 	}
-
-	var entity WalletEntity
-	key := s.getKey(walletName)
-	err = s.db.View(func(tx bdb.ReadTx) error {
-		bucket := tx.ReadBucket(key)
-		if bucket == nil {
-			return ErrWalletNotFound
-		}
-		raw := bucket.Get(key)
-		if len(raw) == 0 {
-			return ErrWalletNotFound
-		}
-		return json.Unmarshal(raw, &entity)
-	}, func() {})
-	if err != nil {
-		return nil, err
-	}
-	return s.toModel(entity, passphrase), nil
+	return s.Get(walletName, password)
 }
 
 func (s *WalletDB) GetActiveWalletName() (string, error) {
@@ -186,12 +613,67 @@ func (s *WalletDB) GetActiveWalletName() (string, error) {
 	return walletName, nil
 }
 
-func (s *WalletDB) toModel(w WalletEntity, passphrase string) *wallet.Wallet {
-	mnemonic := mnemonic.New(w.Mnemonic)
-	model := wallet.New(&mnemonic, passphrase, w.Lock)
+func (s *WalletDB) toModel(w WalletEntity, password []byte) (*wallet.Wallet, string, error) {
+	if w.AccountXpub != "" {
+		model, err := wallet.NewWatchOnly(w.AccountXpub, w.Fingerprint, s.params)
+		if err != nil {
+			return nil, "", fmt.Errorf("wallet %s: %w", w.Name, err)
+		}
+		model.Name = w.Name
+		model.NextChangeIndex = w.NextChangeIndex
+		model.NextReceiveIndex = w.NextReceiveIndex
+		model.CreatedAt = w.CreatedAt
+		return model, "", nil
+	}
+	sealed := w.Sealed
+	if w.SeedStore != "" {
+		var err error
+		if sealed, err = s.loadSeal(w, password); err != nil {
+			return nil, "", err
+		}
+	}
+	if sealed == nil {
+		// Wallets stored before seeds were encrypted take their BIP39 passphrase instead.
+		passphrase := string(password)
+		return s.unlockedModel(w, w.Mnemonic, passphrase), passphrase, nil
+	}
+	secret, err := sealed.open(password, w.Fingerprint)
+	if err != nil {
+		return nil, "", err
+	}
+	return s.unlockedModel(w, secret.Mnemonic, secret.Passphrase), secret.Passphrase, nil
+}
+
+// unlockedModel returns the wallet of w with its mnemonic.
+func (s *WalletDB) unlockedModel(w WalletEntity, words []string, passphrase string) *wallet.Wallet {
+	mnemonic := mnemonic.New(words)
+	model := wallet.New(&mnemonic, passphrase, w.Lock, s.params)
 	model.Name = w.Name
+	model.Sealed = w.isSealed()
 	model.NextChangeIndex = w.NextChangeIndex
 	model.NextReceiveIndex = w.NextReceiveIndex
 	model.CreatedAt = w.CreatedAt
 	return model
 }
+
+// lockedModel returns the metadata of a sealed wallet without decrypting it.
+func (s *WalletDB) lockedModel(w WalletEntity) *wallet.Wallet {
+	return &wallet.Wallet{
+		Name:             w.Name,
+		Fingerprint:      w.Fingerprint,
+		AccountID:        w.AccountID,
+		Params:           s.params,
+		Sealed:           true,
+		NextChangeIndex:  w.NextChangeIndex,
+		NextReceiveIndex: w.NextReceiveIndex,
+		CreatedAt:        w.CreatedAt,
+	}
+}
+
+func putEntity(bucket bdb.ReadWriteBucket, entity *WalletEntity) error {
+	out, err := json.Marshal(entity)
+	if err != nil {
+		return err
+	}
+	return bucket.Put(walletRecordKey, out)
+}
@@ -3,12 +3,20 @@ package walletdb
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	"github.com/satelliondao/satellion/bip329"
+	"github.com/satelliondao/satellion/contacts"
+	"github.com/satelliondao/satellion/enclave"
 	"github.com/satelliondao/satellion/mnemonic"
+	"github.com/satelliondao/satellion/spend"
 	"github.com/satelliondao/satellion/wallet"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func SaveAndVerify(t *testing.T) {
@@ -23,15 +31,15 @@ func SaveAndVerify(t *testing.T) {
 		t.Fatalf("connect failed: %v", err)
 	}
 	defer db.Close()
-	repo := New(db)
+	repo := New(db, &chaincfg.MainNetParams)
 	mnemonic := mnemonic.NewRandom()
 	name := "test-wallet"
-	wallet := wallet.New(mnemonic, "", "")
+	wallet := wallet.New(mnemonic, "", "", &chaincfg.MainNetParams)
 	wallet.Name = name
 	if err := repo.Save(wallet); err != nil {
 		t.Fatalf("save failed: %v", err)
 	}
-	got, err := repo.Get(wallet.Name, "")
+	got, err := repo.Get(wallet.Name, nil)
 	if err != nil {
 		t.Fatalf("get failed: %v", err)
 	}
@@ -52,10 +60,10 @@ func TestCreatedAtPersistence(t *testing.T) {
 		t.Fatalf("connect failed: %v", err)
 	}
 	defer db.Close()
-	repo := New(db)
+	repo := New(db, &chaincfg.MainNetParams)
 	mnemonic := mnemonic.NewRandom()
 	name := "test-wallet-timestamp"
-	originalWallet := wallet.New(mnemonic, "", "")
+	originalWallet := wallet.New(mnemonic, "", "", &chaincfg.MainNetParams)
 	originalWallet.Name = name
 	originalWallet.NextReceiveIndex = 5
 	originalWallet.NextChangeIndex = 3
@@ -64,7 +72,7 @@ func TestCreatedAtPersistence(t *testing.T) {
 	if err := repo.Save(originalWallet); err != nil {
 		t.Fatalf("save failed: %v", err)
 	}
-	retrievedWallet, err := repo.Get(name, "")
+	retrievedWallet, err := repo.Get(name, nil)
 	if err != nil {
 		t.Fatalf("get failed: %v", err)
 	}
@@ -85,7 +93,429 @@ func CatchNotFound(t *testing.T) {
 		t.Fatalf("connect failed: %v", err)
 	}
 	defer db.Close()
-	repo := New(db)
-	_, err = repo.Get("unknown-wallet", "")
+	repo := New(db, &chaincfg.MainNetParams)
+	_, err = repo.Get("unknown-wallet", nil)
 	assert.EqualError(t, err, "wallet not found")
 }
+
+func TestSealedWallet(t *testing.T) {
+	db, _ := openTestDB(t)
+	repo := New(db, &chaincfg.MainNetParams)
+	m := mnemonic.NewRandom()
+	w := wallet.New(m, "25th word", "", &chaincfg.MainNetParams)
+	w.Name = "sealed"
+	if err := repo.Seal(w, "25th word", []byte("password")); err != nil {
+		t.Fatalf("seal failed: %v", err)
+	}
+
+	_, err := repo.Get("sealed", []byte("wrong"))
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+	got, passphrase, err := repo.Unseal("sealed", []byte("password"))
+	assert.NoError(t, err)
+	assert.Equal(t, "25th word", passphrase)
+	assert.Equal(t, m.Words, got.Mnemonic.Words)
+	assert.Equal(t, w.RootKey.String(), got.RootKey.String())
+
+	// Locked wallets can be saved to update metadata without losing the seal.
+	all, err := repo.GetAll()
+	assert.NoError(t, err)
+	assert.Nil(t, all[0].Mnemonic)
+	all[0].NextReceiveIndex = 7
+	assert.NoError(t, repo.Save(&all[0]))
+	got, err = repo.Get("sealed", []byte("password"))
+	assert.NoError(t, err)
+	assert.Equal(t, uint32(7), got.NextReceiveIndex)
+	assert.Equal(t, m.Words, got.Mnemonic.Words)
+}
+
+func TestWalletsForNetwork(t *testing.T) {
+	db, _ := openTestDB(t)
+	repo := New(db, &chaincfg.TestNet3Params)
+	w := wallet.New(mnemonic.NewRandom(), "", "", &chaincfg.TestNet3Params)
+	w.Name = "testnet"
+	require.NoError(t, repo.Seal(w, "", []byte("password")))
+	got, err := repo.Get("testnet", []byte("password"))
+	require.NoError(t, err)
+	addr, err := got.ReceiveAddress()
+	require.NoError(t, err)
+	assert.True(t, strings.HasPrefix(addr.Address.String(), "tb1p"), addr.Address.String())
+	assert.Equal(t, w.AccountID, got.AccountID)
+}
+
+func TestSealedWallet_Enclave(t *testing.T) {
+	for _, backend := range []string{enclave.BackendMemory, enclave.BackendFile} {
+		t.Run(backend, func(t *testing.T) {
+			db, dir := openTestDB(t)
+			repo := New(db, &chaincfg.MainNetParams)
+			require.NoError(t, repo.useEnclave(backend, filepath.Join(dir, "enclave")))
+			m := mnemonic.NewRandom()
+			w := wallet.New(m, "", "", &chaincfg.MainNetParams)
+			w.Name = "vault"
+			require.NoError(t, repo.Seal(w, "", []byte("password")))
+
+			entity, err := repo.entity("vault")
+			require.NoError(t, err)
+			assert.Nil(t, entity.Sealed, "the seal is not kept in wallets.db")
+			assert.Equal(t, backend, entity.SeedStore)
+
+			_, err = repo.Get("vault", []byte("wrong"))
+			assert.ErrorIs(t, err, ErrInvalidPassword)
+			got, err := repo.Get("vault", []byte("password"))
+			require.NoError(t, err)
+			assert.Equal(t, m.Words, got.Mnemonic.Words)
+			all, err := repo.GetAll()
+			require.NoError(t, err)
+			assert.True(t, all[0].Sealed)
+
+			// Sealing again into wallets.db removes the enclave copy.
+			require.NoError(t, repo.UseEnclave("", ""))
+			require.NoError(t, repo.Seal(got, "", []byte("new password")))
+			got, err = repo.Get("vault", []byte("new password"))
+			require.NoError(t, err)
+			assert.Equal(t, m.Words, got.Mnemonic.Words)
+			if backend == enclave.BackendFile {
+				assert.NoDirExists(t, repo.walletEnclaveDir("vault"))
+			} else {
+				assert.False(t, repo.memory.Exists(seedKey("vault")))
+			}
+
+			assert.Error(t, repo.UseEnclave("cloud", ""))
+		})
+	}
+}
+
+func TestUseEnclave_RefusesVolatileBackends(t *testing.T) {
+	db, _ := openTestDB(t)
+	repo := New(db, &chaincfg.MainNetParams)
+	assert.Error(t, repo.UseEnclave(enclave.BackendMemory, ""))
+	assert.Error(t, repo.UseEnclave(enclave.BackendKeyring, ""))
+	assert.Error(t, repo.UseEnclave("cloud", ""))
+	assert.NoError(t, repo.UseEnclave("", ""))
+}
+
+func TestMoveSeals(t *testing.T) {
+	db, _ := openTestDB(t)
+	repo := New(db, &chaincfg.MainNetParams)
+	require.NoError(t, repo.useEnclave(enclave.BackendMemory, ""))
+	m := mnemonic.NewRandom()
+	w := wallet.New(m, "", "", &chaincfg.MainNetParams)
+	w.Name = "vault"
+	require.NoError(t, repo.Seal(w, "", []byte("password")))
+
+	require.NoError(t, repo.moveSeals(enclave.BackendMemory))
+	entity, err := repo.entity("vault")
+	require.NoError(t, err)
+	assert.NotNil(t, entity.Sealed, "the seal is kept in wallets.db")
+	assert.Empty(t, entity.SeedStore)
+	assert.False(t, repo.memory.Exists(seedKey("vault")))
+	got, err := repo.Get("vault", []byte("password"))
+	require.NoError(t, err)
+	assert.Equal(t, m.Words, got.Mnemonic.Words)
+}
+
+func TestSessionKey(t *testing.T) {
+	for _, backend := range []string{"", enclave.BackendFile} {
+		t.Run("backend "+backend, func(t *testing.T) {
+			db, dir := openTestDB(t)
+			repo := New(db, &chaincfg.MainNetParams)
+			require.NoError(t, repo.UseEnclave(backend, filepath.Join(dir, "enclave")))
+			m := mnemonic.NewRandom()
+			w := wallet.New(m, "25th word", "", &chaincfg.MainNetParams)
+			w.Name = "vault"
+			require.NoError(t, repo.Seal(w, "25th word", []byte("password")))
+
+			_, err := repo.SessionKey("vault", []byte("wrong"))
+			assert.ErrorIs(t, err, ErrInvalidPassword)
+			key, err := repo.SessionKey("vault", []byte("password"))
+			require.NoError(t, err)
+			defer key.Wipe()
+			assert.NotContains(t, string(key.Bytes()), "password")
+
+			got, err := repo.GetWithSessionKey("vault", key.Bytes())
+			require.NoError(t, err)
+			assert.Equal(t, m.Words, got.Mnemonic.Words)
+			assert.Equal(t, w.RootKey.String(), got.RootKey.String())
+
+			forged := append([]byte(nil), key.Bytes()...)
+			forged[0] ^= 1
+			_, err = repo.GetWithSessionKey("vault", forged)
+			assert.ErrorIs(t, err, ErrInvalidPassword)
+
+			// Sealing with a new password makes the cached key stale.
+			require.NoError(t, repo.Seal(got, "25th word", []byte("new password")))
+			_, err = repo.GetWithSessionKey("vault", key.Bytes())
+			assert.ErrorIs(t, err, ErrInvalidPassword)
+		})
+	}
+
+	db, _ := openTestDB(t)
+	repo := New(db, &chaincfg.MainNetParams)
+	watched, err := wallet.NewWatchOnly("xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ", "73c5da0a", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	watched.Name = "trezor"
+	require.NoError(t, repo.Save(watched))
+	_, err = repo.SessionKey("trezor", nil)
+	assert.Error(t, err, "watch-only wallets have nothing to unlock")
+}
+
+func TestSeal_ReplacesFileStoreAfterCommit(t *testing.T) {
+	db, dir := openTestDB(t)
+	repo := New(db, &chaincfg.MainNetParams)
+	require.NoError(t, repo.UseEnclave(enclave.BackendFile, filepath.Join(dir, "enclave")))
+	m := mnemonic.NewRandom()
+	w := wallet.New(m, "", "", &chaincfg.MainNetParams)
+	w.Name = "vault"
+	require.NoError(t, repo.Seal(w, "", []byte("password")))
+	require.NoError(t, repo.Seal(w, "", []byte("new password")))
+	_, err := repo.Get("vault", []byte("password"))
+	assert.ErrorIs(t, err, ErrInvalidPassword)
+	got, err := repo.Get("vault", []byte("new password"))
+	require.NoError(t, err)
+	assert.Equal(t, m.Words, got.Mnemonic.Words)
+	assert.NoDirExists(t, repo.walletEnclaveDir("vault")+".new")
+	assert.NoDirExists(t, repo.walletEnclaveDir("vault")+".old")
+
+	// wallets.db refuses an empty name, after the store was written.
+	unnamed := wallet.New(mnemonic.NewRandom(), "", "", &chaincfg.MainNetParams)
+	unnamed.Name = ""
+	require.Error(t, repo.Seal(unnamed, "", []byte("password")))
+	assert.NoDirExists(t, repo.walletEnclaveDir("")+".new", "the pending store is dropped")
+
+	require.NoError(t, os.RemoveAll(repo.walletEnclaveDir("vault")))
+	_, err = repo.Get("vault", []byte("new password"))
+	assert.ErrorIs(t, err, enclave.ErrStoreMissing)
+	assert.NoDirExists(t, repo.walletEnclaveDir("vault"), "no empty store is created")
+}
+
+func TestDelete_RemovesEnclaveSeal(t *testing.T) {
+	db, dir := openTestDB(t)
+	repo := New(db, &chaincfg.MainNetParams)
+	require.NoError(t, repo.UseEnclave(enclave.BackendFile, filepath.Join(dir, "enclave")))
+	w := wallet.New(mnemonic.NewRandom(), "", "", &chaincfg.MainNetParams)
+	w.Name = "gone"
+	require.NoError(t, repo.Seal(w, "", []byte("password")))
+	require.DirExists(t, repo.walletEnclaveDir("gone"))
+
+	require.NoError(t, repo.Delete("gone"))
+	assert.NoDirExists(t, repo.walletEnclaveDir("gone"))
+	_, err := repo.Get("gone", []byte("password"))
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+}
+
+func TestWatchOnlyWallet(t *testing.T) {
+	db, _ := openTestDB(t)
+	repo := New(db, &chaincfg.MainNetParams)
+	w, err := wallet.NewWatchOnly("xpub6BgBgsespWvERF3LHQu6CnqdvfEvtMcQjYrcRzx53QJjSxarj2afYWcLteoGVky7D3UKDP9QyrLprQ3VCECoY49yfdDEHGCtMMj92pReUsQ", "73c5da0a", &chaincfg.MainNetParams)
+	require.NoError(t, err)
+	w.Name = "trezor"
+	w.NextReceiveIndex = 2
+	require.NoError(t, repo.Save(w))
+	assert.Error(t, repo.Seal(w, "", []byte("password")))
+
+	got, err := repo.Get("trezor", []byte("any password"))
+	require.NoError(t, err)
+	assert.True(t, got.WatchOnly())
+	assert.Equal(t, uint32(2), got.NextReceiveIndex)
+	want, err := w.ReceiveAddress()
+	require.NoError(t, err)
+	addr, err := got.ReceiveAddress()
+	require.NoError(t, err)
+	assert.Equal(t, want.Address.String(), addr.Address.String())
+}
+
+func TestLabels(t *testing.T) {
+	db, _ := openTestDB(t)
+	repo := New(db, &chaincfg.MainNetParams)
+	w := wallet.New(mnemonic.NewRandom(), "", "", &chaincfg.MainNetParams)
+	w.Name = "labelled"
+	require.NoError(t, repo.Seal(w, "", []byte("password")))
+	frozen := false
+	require.NoError(t, repo.SetLabels("labelled",
+		bip329.Label{Type: bip329.Addr, Ref: "bc1qaddr", Label: "rent"},
+		bip329.Label{Type: bip329.Output, Ref: "abcd:0", Spendable: &frozen},
+	))
+	assert.ErrorIs(t, repo.SetLabels("missing", bip329.Label{Type: bip329.Tx, Ref: "abcd", Label: "x"}), ErrWalletNotFound)
+	assert.ErrorIs(t, repo.SetLabels("labelled", bip329.Label{Type: "utxo", Ref: "abcd:0"}), bip329.ErrInvalidLabel)
+
+	l, err := repo.Label("labelled", bip329.Addr, "bc1qaddr")
+	require.NoError(t, err)
+	assert.Equal(t, "rent", l.Label)
+	none, err := repo.Label("labelled", bip329.Tx, "abcd")
+	require.NoError(t, err)
+	assert.Equal(t, bip329.Label{Type: bip329.Tx, Ref: "abcd"}, none)
+
+	// Saving the locked wallet keeps its labels.
+	all, err := repo.GetAll()
+	require.NoError(t, err)
+	require.NoError(t, repo.Save(&all[0]))
+	require.NoError(t, repo.SetLabels("labelled", bip329.Label{Type: bip329.Addr, Ref: "bc1qaddr"}))
+	labels, err := repo.Labels("labelled")
+	require.NoError(t, err)
+	require.Len(t, labels, 1)
+	assert.Equal(t, "output:abcd:0", labels[0].Key())
+	assert.False(t, *labels[0].Spendable)
+
+	require.NoError(t, repo.Delete("labelled"))
+	_, err = repo.Labels("labelled")
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+}
+
+func TestContacts(t *testing.T) {
+	db, _ := openTestDB(t)
+	repo := New(db, &chaincfg.MainNetParams)
+	list, err := repo.Contacts()
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	require.NoError(t, repo.SaveContact(contacts.Contact{Name: "bob", Address: "bc1qbob"}))
+	require.NoError(t, repo.SaveContact(contacts.Contact{Name: "Alice", Address: "bc1qalice", Notes: "rent"}))
+	require.NoError(t, repo.SaveContact(contacts.Contact{Name: "bob", Address: "bc1qbob2"}))
+	list, err = repo.Contacts()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "Alice", list[0].Name)
+	assert.Equal(t, "bc1qbob2", list[1].Address)
+	assert.False(t, list[0].CreatedAt.IsZero())
+
+	require.NoError(t, repo.DeleteContact("bob"))
+	assert.ErrorIs(t, repo.DeleteContact("bob"), ErrContactNotFound)
+	count, err := repo.WalletCount()
+	require.NoError(t, err)
+	assert.Zero(t, count, "contacts are not wallets")
+}
+
+func TestPayments(t *testing.T) {
+	db, _ := openTestDB(t)
+	repo := New(db, &chaincfg.MainNetParams)
+	p, err := repo.Payments("bc1qbob")
+	require.NoError(t, err)
+	assert.Zero(t, p.Count)
+
+	first := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	require.NoError(t, repo.RecordPayment("bc1qbob", first))
+	require.NoError(t, repo.RecordPayment("bc1qbob", first.Add(time.Hour)))
+	p, err = repo.Payments("bc1qbob")
+	require.NoError(t, err)
+	assert.Equal(t, 2, p.Count)
+	assert.True(t, p.First.Equal(first))
+	assert.True(t, p.Last.Equal(first.Add(time.Hour)))
+}
+
+func TestUtxosAndFrozen(t *testing.T) {
+	db, _ := openTestDB(t)
+	repo := New(db, &chaincfg.MainNetParams)
+	w := wallet.New(mnemonic.NewRandom(), "", "", &chaincfg.MainNetParams)
+	w.Name = "coins"
+	require.NoError(t, repo.Seal(w, "", []byte("password")))
+	set, err := repo.Utxos("coins")
+	require.NoError(t, err)
+	assert.Empty(t, set.Utxos)
+	_, err = repo.Utxos("missing")
+	assert.ErrorIs(t, err, ErrWalletNotFound)
+
+	u := wallet.Utxo{Value: 5000, Address: "bc1paddr", Change: true, Index: 3, Height: 800_000}
+	u.OutPoint.Index = 1
+	require.NoError(t, repo.SaveUtxos("coins", UtxoSet{Height: 800_010, Utxos: []wallet.Utxo{u}}))
+	set, err = repo.Utxos("coins")
+	require.NoError(t, err)
+	assert.Equal(t, int32(800_010), set.Height)
+	assert.Equal(t, []wallet.Utxo{u}, set.Utxos)
+
+	require.NoError(t, repo.SetLabels("coins", bip329.Label{Type: bip329.Output, Ref: u.Ref(), Label: "savings"}))
+	require.NoError(t, repo.SetFrozen("coins", u.Ref(), true))
+	frozen, err := repo.Frozen("coins")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]bool{u.Ref(): true}, frozen)
+
+	require.NoError(t, repo.SetFrozen("coins", u.Ref(), false))
+	frozen, err = repo.Frozen("coins")
+	require.NoError(t, err)
+	assert.Empty(t, frozen)
+	l, err := repo.Label("coins", bip329.Output, u.Ref())
+	require.NoError(t, err)
+	assert.Equal(t, "savings", l.Label, "unfreezing keeps the label")
+}
+
+func TestTransactions(t *testing.T) {
+	db, _ := openTestDB(t)
+	repo := New(db, &chaincfg.MainNetParams)
+	w := wallet.New(mnemonic.NewRandom(), "", "", &chaincfg.MainNetParams)
+	w.Name = "sender"
+	require.NoError(t, repo.Seal(w, "", []byte("password")))
+	_, err := repo.Transaction("sender", "aa")
+	assert.ErrorIs(t, err, ErrTransactionNotFound)
+
+	coin := func(i uint32) wallet.Utxo {
+		return wallet.Utxo{OutPoint: wire.OutPoint{Index: i}, Value: 1000, Height: 90}
+	}
+	now := time.Now()
+	orig := spend.Transaction{Txid: "aa", Inputs: []wallet.Utxo{coin(0)}, Status: spend.Pending, CreatedAt: now}
+	other := spend.Transaction{Txid: "bb", Inputs: []wallet.Utxo{coin(1)}, Status: spend.Pending, CreatedAt: now.Add(time.Minute)}
+	bump := spend.Transaction{Txid: "cc", Inputs: []wallet.Utxo{coin(0), coin(2)}, Status: spend.Pending, Replaces: "aa", CreatedAt: now.Add(2 * time.Minute)}
+	// A child paying for an unconfirmed parent spends an output no scan has seen.
+	child := spend.Transaction{Txid: "dd", Inputs: []wallet.Utxo{{OutPoint: wire.OutPoint{Index: 3}, Value: 1000}}, Status: spend.Pending, Parent: "ee", CreatedAt: now}
+	require.NoError(t, repo.SaveTransactions("sender", orig, other, bump, child))
+	list, err := repo.Transactions("sender")
+	require.NoError(t, err)
+	require.Len(t, list, 4)
+	assert.Equal(t, "cc", list[0].Txid, "newest first")
+	spent, err := repo.PendingSpends("sender")
+	require.NoError(t, err)
+	assert.Len(t, spent, 4)
+
+	// The original confirmed before its replacement; the other payment is still waiting.
+	require.NoError(t, repo.ReconcileTransactions("sender", map[string]int32{"aa": 100}, []wallet.Utxo{coin(1), coin(2)}))
+	got, err := repo.Transaction("sender", "aa")
+	require.NoError(t, err)
+	assert.Equal(t, spend.Confirmed, got.Status)
+	assert.Equal(t, int32(100), got.Height)
+	got, err = repo.Transaction("sender", "cc")
+	require.NoError(t, err)
+	assert.Equal(t, spend.Replaced, got.Status)
+	assert.Equal(t, "aa", got.ReplacedBy)
+	got, err = repo.Transaction("sender", "bb")
+	require.NoError(t, err)
+	assert.Equal(t, spend.Pending, got.Status)
+	got, err = repo.Transaction("sender", "dd")
+	require.NoError(t, err)
+	assert.Equal(t, spend.Pending, got.Status)
+}
+
+func TestQueue(t *testing.T) {
+	db, _ := openTestDB(t)
+	repo := New(db, &chaincfg.MainNetParams)
+	list, err := repo.Queue()
+	require.NoError(t, err)
+	assert.Empty(t, list)
+
+	now := time.Now()
+	first := QueuedTx{Txid: "aa", Raw: "00", Wallet: "sender", CreatedAt: now}
+	second := QueuedTx{Txid: "bb", Raw: "01", CreatedAt: now.Add(time.Minute)}
+	require.NoError(t, repo.SaveQueued(second, first))
+	first.Attempts, first.Height = 3, 120
+	require.NoError(t, repo.SaveQueued(first))
+	list, err = repo.Queue()
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.Equal(t, "aa", list[0].Txid, "oldest first")
+	assert.Equal(t, 3, list[0].Attempts)
+	assert.False(t, list[0].Active(), "seen in a block")
+	assert.True(t, list[1].Active())
+
+	require.NoError(t, repo.RemoveQueued("aa"))
+	list, err = repo.Queue()
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+}
+
+func TestConnect_InUse(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "wallets.db")
+	db, err := Connect(path)
+	require.NoError(t, err)
+	defer db.Close()
+	timeout := defaultDBTimeout
+	defaultDBTimeout = 50 * time.Millisecond
+	t.Cleanup(func() { defaultDBTimeout = timeout })
+	_, err = Connect(path)
+	assert.ErrorIs(t, err, ErrInUse)
+}
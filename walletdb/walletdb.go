@@ -1,12 +1,15 @@
 package walletdb
 
 import (
+	"errors"
+	"fmt"
 	"os"
 	"path/filepath"
 	"time"
 
 	"github.com/btcsuite/btcwallet/walletdb"
 	_ "github.com/btcsuite/btcwallet/walletdb/bdb"
+	"go.etcd.io/bbolt"
 )
 
 var (
@@ -14,6 +17,10 @@ var (
 	walletStoreKey   = []byte("wallets")
 )
 
+// ErrInUse is returned when another process, such as a running app, holds the lock of
+// a database until the open times out.
+var ErrInUse = errors.New("database is in use by another satellion process")
+
 type DB struct {
 	db walletdb.DB
 }
@@ -38,9 +45,42 @@ func Connect(dataDir string) (walletdb.DB, error) {
 }
 
 func openOrCreate(path string) (walletdb.DB, error) {
-	_, statErr := os.Stat(path)
-	if os.IsNotExist(statErr) {
-		return walletdb.Create("bdb", path, true, defaultDBTimeout, false)
+	var db walletdb.DB
+	var err error
+	if _, statErr := os.Stat(path); os.IsNotExist(statErr) {
+		db, err = walletdb.Create("bdb", path, true, defaultDBTimeout, false)
+	} else {
+		db, err = walletdb.Open("bdb", path, true, defaultDBTimeout, false)
+	}
+	if errors.Is(err, bbolt.ErrTimeout) {
+		return nil, fmt.Errorf("%w: %s", ErrInUse, path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+// DefaultPath returns the location of the wallets database, ~/.satellion/wallets.db.
+func DefaultPath() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		home = "."
+	}
+	return filepath.Join(home, ".satellion", "wallets.db")
+}
+
+// Open connects to the wallets database at path and upgrades it to the latest schema,
+// keeping a timestamped copy next to it when a migration is needed.
+func Open(path string) (walletdb.DB, error) {
+	db, err := Connect(path)
+	if err != nil {
+		return nil, err
+	}
+	backupPath := fmt.Sprintf("%s.%s.bak", path, time.Now().Format("20060102-150405"))
+	if err := Upgrade(db, backupPath); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("failed to upgrade wallets db: %w", err)
 	}
-	return walletdb.Open("bdb", path, true, defaultDBTimeout, false)
+	return db, nil
 }